@@ -0,0 +1,168 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The dependency_graph command imports a capture, builds its dependency graph
+// and writes it in the Graphviz DOT format. Each node is a command, and each
+// edge points from a command to a command it depends on.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	_ "github.com/google/gapid/gapis/api/gles"
+	_ "github.com/google/gapid/gapis/api/gvr"
+	_ "github.com/google/gapid/gapis/api/vulkan"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/resolve/dependencygraph2"
+)
+
+var (
+	path   = flag.String("file", "capture.gfxtrace", "The capture file to analyse")
+	output = flag.String("out", "capture.dot", "The output DOT file, or - for stdout")
+	start  = flag.Int("start", 0, "The index of the first command to include")
+	end    = flag.Int("end", -1, "The index one past the last command to include. -1 for the end of the trace.")
+)
+
+func main() {
+	app.ShortHelp = "dependency_graph writes the dependency graph of a capture as a DOT file"
+	app.Name = "dependency_graph"
+	app.Run(run)
+}
+
+func run(ctx context.Context) error {
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	name := filepath.Base(*path)
+
+	p, err := capture.Import(ctx, name, name, &capture.File{Path: *path})
+	if err != nil {
+		return err
+	}
+	capt, err := capture.ResolveGraphicsFromPath(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	first, last := api.CmdID(*start), api.CmdID(*end)
+	if *end == -1 {
+		last = api.CmdID(len(capt.Commands))
+	}
+	switch {
+	case *start < 0 || int(first) > len(capt.Commands):
+		return log.Errf(ctx, nil, "Start command %d is outside the range of the trace: [0, %d]", *start, len(capt.Commands))
+	case last < first:
+		return log.Errf(ctx, nil, "End command %d is before the start command %d", last, first)
+	case int(last) > len(capt.Commands):
+		return log.Errf(ctx, nil, "End command %d exceeds the total number of commands in the trace: %d", last, len(capt.Commands))
+	}
+
+	graph, err := dependencygraph2.GetDependencyGraph(ctx, p, dependencygraph2.DependencyGraphConfig{
+		MergeSubCmdNodes: true,
+	})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to build the dependency graph")
+	}
+	log.I(ctx, "Built dependency graph with %d nodes and %d dependencies",
+		graph.NumNodes(), graph.NumDependencies())
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	if err := writeDOT(graph, first, last, w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if *output != "-" {
+		log.I(ctx, "Dependency graph of commands [%d, %d) written to: %v", first, last, *output)
+	}
+	return nil
+}
+
+// writeDOT writes the dependencies between the commands in [first, last) of
+// graph to w. Memory observation nodes are merged into the command they belong
+// to, and dependencies on commands outside of the range are dropped.
+func writeDOT(graph dependencygraph2.DependencyGraph, first, last api.CmdID, w io.Writer) error {
+	cmdOf := func(id dependencygraph2.NodeID) (api.CmdID, bool) {
+		var cmd api.CmdID
+		switch n := graph.GetNode(id).(type) {
+		case dependencygraph2.CmdNode:
+			if len(n.Index) == 0 {
+				return 0, false
+			}
+			cmd = api.CmdID(n.Index[0])
+		case dependencygraph2.ObsNode:
+			cmd = n.CmdID
+		default:
+			return 0, false
+		}
+		return cmd, cmd.IsReal() && first <= cmd && cmd < last
+	}
+
+	type edge struct{ from, to api.CmdID }
+	edges := map[edge]struct{}{}
+	err := graph.ForeachDependency(func(src, tgt dependencygraph2.NodeID) error {
+		from, ok := cmdOf(src)
+		if !ok {
+			return nil
+		}
+		to, ok := cmdOf(tgt)
+		if !ok || from == to {
+			return nil
+		}
+		edges[edge{from, to}] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sorted := make([]edge, 0, len(edges))
+	for e := range edges {
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		return a.from < b.from || (a.from == b.from && a.to < b.to)
+	})
+
+	fmt.Fprintln(w, "digraph dependencies {")
+	fmt.Fprintln(w, "  node [shape=box];")
+	for id := first; id < last; id++ {
+		fmt.Fprintf(w, "  c%d [label=\"%d: %s\"];\n", id, id, graph.GetCommand(id).CmdName())
+	}
+	for _, e := range sorted {
+		fmt.Fprintf(w, "  c%d -> c%d;\n", e.from, e.to)
+	}
+	_, err = fmt.Fprintln(w, "}")
+	return err
+}
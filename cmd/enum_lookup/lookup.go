@@ -46,6 +46,22 @@ func LookupEnum(value int64) []enum {
 	return enums[value]
 }
 
+// LookupName returns the enums whose names contain name, or are name if exact
+// is true. Names are matched case-insensitively.
+func LookupName(name string, exact bool) []found {
+	name = strings.ToLower(name)
+	r := []found{}
+	for value, es := range enums {
+		for _, e := range es {
+			n := strings.ToLower(e.Name)
+			if n == name || (!exact && strings.Contains(n, name)) {
+				r = append(r, found{value, e})
+			}
+		}
+	}
+	return r
+}
+
 func LookupBitfields(value int64) []enum {
 	r := []enum{}
 	if value != 0 {
@@ -14,12 +14,15 @@
 
 // The enum_lookup command parses its parameters as decimal and/or hex ints
 // and then prints out all the API enums with that value.
+// Parameters that are not numbers are matched against the enum names instead.
 package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"sort"
@@ -34,6 +37,8 @@ var (
 	filterType    = flag.String("type", "", "Only show enums of the given type")
 	showEnums     = flag.Bool("e", true, "Lookup the value as an enum")
 	showBitfields = flag.Bool("b", false, "Attempt to expand the value as or'ed bitfield")
+	exactNames    = flag.Bool("exact", false, "Match enum names exactly instead of by substring")
+	jsonOutput    = flag.Bool("json", false, "Print the results as a JSON array")
 )
 
 type found struct {
@@ -42,69 +47,92 @@ type found struct {
 }
 
 func main() {
-	app.ShortHelp = "enum_lookup looks up API enums by value"
+	app.ShortHelp = "enum_lookup looks up API enums by value or name"
 	app.Name = "enum_lookup"
 	app.Run(run)
 }
 
 func run(ctx context.Context) error {
 	todo := map[int64]bool{}
+	names := []string{}
 
-	for _, arg := range os.Args {
+	for _, arg := range flag.Args() {
 		arg = strings.ToLower(arg)
-		if strings.HasPrefix(arg, "0x") {
-			parse(todo, arg[2:], 16)
-		} else {
-			parse(todo, arg, 10)
-			parse(todo, arg, 16)
+		if strings.HasPrefix(arg, "0x") && parse(todo, arg[2:], 16) {
+			continue
 		}
+		// Without the prefix, words such as "face" are both hexadecimal
+		// numbers and parts of names, so they are looked up as both.
+		parse(todo, arg, 10)
+		parse(todo, arg, 16)
+		names = append(names, arg)
 	}
 
+	var enumResults, bitfieldResults []found
 	if *showEnums {
-		lookup(todo, LookupEnum)
+		for v := range todo {
+			for _, r := range LookupEnum(v) {
+				enumResults = append(enumResults, found{v, r})
+			}
+		}
+		for _, name := range names {
+			enumResults = append(enumResults, LookupName(name, *exactNames)...)
+		}
+		enumResults = filtered(enumResults)
+	}
+	if *showBitfields {
+		for v := range todo {
+			for _, r := range LookupBitfields(v) {
+				bitfieldResults = append(bitfieldResults, found{v, r})
+			}
+		}
+		bitfieldResults = filtered(bitfieldResults)
+	}
+
+	if *jsonOutput {
+		return writeJSON(os.Stdout, append(enumResults, bitfieldResults...))
+	}
+	if *showEnums {
+		display(enumResults)
 	}
 	if *showBitfields {
 		if *showEnums {
 			fmt.Println("\n============= Bitfields =============\n")
 		}
-		lookup(todo, LookupBitfields)
+		display(bitfieldResults)
 	}
 
 	return nil
 }
 
-func parse(m map[int64]bool, s string, base int) {
+// parse adds the integer in s to m, returning false if s is not an integer
+// in the given base.
+func parse(m map[int64]bool, s string, base int) bool {
 	if strings.HasPrefix(s, "-") {
 		if val, err := strconv.ParseInt(s, base, 64); err == nil {
 			m[val] = true
+			return true
 		}
 	} else {
 		if val, err := strconv.ParseUint(s, base, 64); err == nil {
 			m[int64(val)] = true
+			return true
 		}
 	}
+	return false
 }
 
-func lookup(todo map[int64]bool, look func(v int64) []enum) {
+// filtered returns the results that pass the filters, without duplicates,
+// sorted by API, type and value.
+func filtered(all []found) []found {
 	results := []found{}
-	maxAPILength, maxTypeLength, maxVal := 0, 0, uint64(0)
-	for v := range todo {
-		for _, r := range look(v) {
-			if filter(r) {
-				continue
-			}
-
-			results = append(results, found{v, r})
-			if l := len(r.API); l > maxAPILength {
-				maxAPILength = l
-			}
-			if l := len(r.Type); l > maxTypeLength {
-				maxTypeLength = l
-			}
-			if l := uint64(v); l > maxVal {
-				maxVal = l
-			}
+	seen := map[found]bool{}
+	for _, r := range all {
+		if filter(r.enum) || seen[r] {
+			continue
 		}
+		seen[r] = true
+		results = append(results, r)
 	}
 	sort.Slice(results, func(i, j int) bool {
 		a, b := results[i], results[j]
@@ -118,6 +146,23 @@ func lookup(todo map[int64]bool, look func(v int64) []enum) {
 			return a.API < b.API
 		}
 	})
+	return results
+}
+
+// display prints the results as an aligned table.
+func display(results []found) {
+	maxAPILength, maxTypeLength, maxVal := 0, 0, uint64(0)
+	for _, r := range results {
+		if l := len(r.API); l > maxAPILength {
+			maxAPILength = l
+		}
+		if l := len(r.Type); l > maxTypeLength {
+			maxTypeLength = l
+		}
+		if l := uint64(r.val); l > maxVal {
+			maxVal = l
+		}
+	}
 
 	f := fmt.Sprintf("%%%dd 0x%%0%dx: %%%ds %%%ds  %%s\n",
 		int(math.Ceil(math.Log10(float64(maxVal+1)))),
@@ -132,3 +177,20 @@ func filter(e enum) bool {
 	return (*filterAPI != "" && !strings.EqualFold(*filterAPI, e.API)) ||
 		(*filterType != "" && !strings.EqualFold(*filterType, e.Type))
 }
+
+// writeJSON writes the results to w as a JSON array.
+func writeJSON(w io.Writer, results []found) error {
+	type entry struct {
+		Value int64  `json:"value"`
+		API   string `json:"api"`
+		Type  string `json:"type"`
+		Name  string `json:"name"`
+	}
+	entries := make([]entry, len(results))
+	for i, r := range results {
+		entries[i] = entry{r.val, r.API, r.Type, r.Name}
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(entries)
+}
@@ -30,9 +30,11 @@ import (
 
 const (
 	versionPrefix = `version "`
+	archPrefix    = "os.arch = "
 	googleInfix   = "-google-"
 	minJavaMajor  = 1
 	minJavaMinor  = 8
+	vmArgsEnv     = "GAPID_VMARGS"
 )
 
 type config struct {
@@ -71,6 +73,9 @@ func run() int {
 			fmt.Println(" --jar             Path to the gapic JAR to use")
 			fmt.Println(" --vm              Path to the JVM to use")
 			fmt.Println(" --vmarg           Extra argument for the JVM (repeatable)")
+			fmt.Println("                   Arguments are also read from the " + vmArgsEnv + " environment")
+			fmt.Println("                   variable, separated by spaces or colons. Use double quotes")
+			fmt.Println("                   around arguments containing spaces or colons")
 			fmt.Println(" --console         Run GAPID inside a terminal console")
 			fmt.Println(" --verbose-startup Log verbosely in the launcher")
 		}()
@@ -122,6 +127,10 @@ func run() int {
 func newConfig() *config {
 	c := &config{}
 
+	// JVM arguments from the environment come first, so that the ones given
+	// with --vmarg override them.
+	c.vmArgs = splitVMArgs(os.Getenv(vmArgsEnv))
+
 	// Doing our own flag handling (rather than using go's flag package) to avoid
 	// it attempting to parse the GAPIC flags, which may be in a different format.
 	// This loop simply looks for the launcher flags, but hands everything else to
@@ -157,6 +166,31 @@ func newConfig() *config {
 	return c
 }
 
+// splitVMArgs splits the space or colon separated list of JVM arguments s.
+// Arguments containing spaces or colons, such as -Xbootclasspath/a:foo.jar,
+// must be enclosed in double quotes, which are removed.
+func splitVMArgs(s string) []string {
+	args := []string{}
+	arg, quoted, inArg := "", false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted, inArg = !quoted, true
+		case (r == ' ' || r == '\t' || r == '\n' || r == ':') && !quoted:
+			if inArg {
+				args = append(args, arg)
+			}
+			arg, inArg = "", false
+		default:
+			arg, inArg = arg+string(r), true
+		}
+	}
+	if inArg {
+		args = append(args, arg)
+	}
+	return args
+}
+
 func (c *config) logIfVerbose(args ...interface{}) {
 	if c.verbose {
 		fmt.Println(args...)
@@ -272,8 +306,9 @@ func (c *config) checkVM(java string, checkVersion bool) bool {
 				useIt := major > minJavaMajor || (major == minJavaMajor && minor >= minJavaMinor)
 				if !useIt {
 					c.logIfVerbose("Not using " + java + ": unsupported version")
+					return false
 				}
-				return useIt
+				return c.checkArch(java)
 			}
 		}
 	}
@@ -282,6 +317,50 @@ func (c *config) checkVM(java string, checkVersion bool) bool {
 	return false
 }
 
+// javaArchs maps the GOARCH values to the os.arch values reported by the JVM.
+var javaArchs = map[string][]string{
+	"386":   {"x86", "i386", "i486", "i586", "i686"},
+	"amd64": {"amd64", "x86_64"},
+	"arm64": {"aarch64", "arm64"},
+}
+
+// checkArch returns false if the JVM's architecture does not match the
+// architecture of the host. A JVM of the wrong architecture may start, but
+// then fails to load GAPIC's native libraries.
+func (c *config) checkArch(java string) bool {
+	archs, ok := javaArchs[runtime.GOARCH]
+	if !ok {
+		return true
+	}
+
+	settings, err := exec.Command(java, "-XshowSettings:properties", "-version").CombinedOutput()
+	if err != nil {
+		c.logIfVerbose("Not using " + java + ": failed to get properties")
+		return false
+	}
+
+	settingsStr := string(settings)
+	p := strings.Index(settingsStr, archPrefix)
+	if p < 0 {
+		c.logIfVerbose("Using " + java + " without checking its architecture: os.arch not found")
+		return true
+	}
+	p += len(archPrefix)
+	arch := settingsStr[p:]
+	if q := strings.IndexAny(arch, "\r\n"); q >= 0 {
+		arch = arch[:q]
+	}
+	arch = strings.TrimSpace(arch)
+
+	for _, a := range archs {
+		if arch == a {
+			return true
+		}
+	}
+	c.logIfVerbose("Not using " + java + ": wrong architecture JRE (" + arch + ", expected " + runtime.GOARCH + ")")
+	return false
+}
+
 func (c *config) locateGAPIC() error {
 	gapic := c.gapic
 	if gapic == "" {
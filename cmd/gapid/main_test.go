@@ -0,0 +1,44 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+)
+
+func TestSplitVMArgs(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		in       string
+		expected []string
+	}{
+		{"", []string{}},
+		{"  ", []string{}},
+		{"-Xmx4g", []string{"-Xmx4g"}},
+		{"-Xmx4g -Dfoo=bar", []string{"-Xmx4g", "-Dfoo=bar"}},
+		{"-Xmx4g:-Dfoo=bar", []string{"-Xmx4g", "-Dfoo=bar"}},
+		{" -Xmx4g \t:: -Dfoo=bar\n", []string{"-Xmx4g", "-Dfoo=bar"}},
+		{`"-Dname=a b" -Xmx4g`, []string{"-Dname=a b", "-Xmx4g"}},
+		{`"-Xbootclasspath/a:foo.jar":-Xmx4g`, []string{"-Xbootclasspath/a:foo.jar", "-Xmx4g"}},
+		{`-Dpath="C:\gapid":-Xss1m`, []string{`-Dpath=C:\gapid`, "-Xss1m"}},
+		{`""`, []string{""}},
+		{`"unterminated arg`, []string{"unterminated arg"}},
+	} {
+		assert.For(ctx, "splitVMArgs(%q)", test.in).That(splitVMArgs(test.in)).DeepEquals(test.expected)
+	}
+}
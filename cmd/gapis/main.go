@@ -20,6 +20,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -52,7 +54,7 @@ import (
 
 var (
 	rpc              = flag.String("rpc", "localhost:0", "TCP host:port of the server's RPC listener")
-	stringsPath      = flag.String("strings", "strings", "_Directory containing string table packages")
+	stringsPath      = flag.String("strings", "strings", "_List of directories containing string table packages, separated by the OS path list separator")
 	persist          = flag.Bool("persist", false, "Server will keep running even when no connections remain")
 	gapisAuthToken   = flag.String("gapis-auth-token", "", "_The connection authorization token for gapis")
 	gapirAuthToken   = flag.String("gapir-auth-token", "", "_The connection authorization token for gapir")
@@ -63,6 +65,10 @@ var (
 	adbPath          = flag.String("adb", "", "Path to the adb executable; leave empty to search the environment")
 	enableLocalFiles = flag.Bool("enable-local-files", false, "Allow clients to access local .gfxtrace files by path")
 	remoteSSHConfig  = flag.String("ssh-config", "", "_Path to an ssh config file for remote devices")
+	tlsCert          = flag.String("tls-cert", "", "Path to a PEM encoded certificate to serve the RPCs over TLS")
+	tlsKey           = flag.String("tls-key", "", "Path to the PEM encoded private key of --tls-cert")
+	tlsClientCA      = flag.String("tls-client-ca", "", "Path to PEM encoded certificate authorities that client certificates must be signed by")
+	metricsAddr      = flag.String("metrics-addr", "", "TCP host:port to serve Prometheus metrics on; leave empty to disable")
 )
 
 func main() {
@@ -108,6 +114,10 @@ func run(ctx context.Context) error {
 	// Grpc is very verbose, turn that down
 	grpclog.SetLogger(log.From(ctx).SetFilter(log.SeverityFilter(log.Error)))
 
+	if *metricsAddr != "" {
+		crash.Go(func() { serveMetrics(ctx, *metricsAddr) })
+	}
+
 	var hostDevice *path.Device
 
 	if *addLocalDevice {
@@ -153,6 +163,9 @@ func run(ctx context.Context) error {
 		DeviceScanDone:   deviceScanDone,
 		LogBroadcaster:   logBroadcaster,
 		IdleTimeout:      *idleTimeout,
+		TLSCertFile:      *tlsCert,
+		TLSKeyFile:       *tlsKey,
+		TLSClientCAFile:  *tlsClientCA,
 	})
 }
 
@@ -169,7 +182,13 @@ func monitorAndroidDevices(ctx context.Context, r *bind.Registry, scanDone func(
 		}
 	}()
 
-	if err := adb.Monitor(ctx, r, time.Second*3); err != nil {
+	// Devices that connect after the initial scan need their launch arguments
+	// set as they appear.
+	setLaunchArgs := bind.NewDeviceListener(func(ctx context.Context, d bind.Device) {
+		r.SetDeviceProperty(ctx, d, client.LaunchArgsKey, text.SplitArgs(*gapirArgStr))
+	}, nil)
+
+	if err := adb.Monitor(ctx, r, time.Second*3, setLaunchArgs); err != nil {
 		log.W(ctx, "Could not scan for local Android devices. Error: %v", err)
 	}
 }
@@ -225,23 +244,51 @@ func monitorGGPDevices(ctx context.Context, r *bind.Registry, scanDone func()) {
 	}
 }
 
+// loadStrings loads the string tables from each of the directories listed in
+// --strings. Tables with the same culture code are merged, with the entries of
+// tables loaded later taking precedence.
 func loadStrings(ctx context.Context) []*stringtable.StringTable {
-	files, err := filepath.Glob(filepath.Join(*stringsPath, "*.stb"))
-	if err != nil {
-		log.E(ctx, "Couldn't scan for stringtables. Error: %v", err)
-		return nil
-	}
+	out := []*stringtable.StringTable{}
+	byCulture := map[string]*stringtable.StringTable{}
 
-	out := make([]*stringtable.StringTable, 0, len(files))
-
-	for _, path := range files {
-		ctx := log.V{"path": path}.Bind(ctx)
-		st, err := stringtable.Load(path)
+	for _, dir := range filepath.SplitList(*stringsPath) {
+		files, err := filepath.Glob(filepath.Join(dir, "*.stb"))
 		if err != nil {
-			log.E(ctx, "Couldn't load stringtable file. Error: %v", err)
+			log.E(ctx, "Couldn't scan for stringtables in %v. Error: %v", dir, err)
 			continue
 		}
-		out = append(out, st)
+
+		for _, path := range files {
+			ctx := log.V{"path": path}.Bind(ctx)
+			st, err := stringtable.Load(path)
+			if err != nil {
+				log.E(ctx, "Couldn't load stringtable file. Error: %v", err)
+				continue
+			}
+
+			culture := st.GetInfo().GetCultureCode()
+			existing, ok := byCulture[culture]
+			if !ok {
+				byCulture[culture] = st
+				out = append(out, st)
+				continue
+			}
+			if existing.Entries == nil {
+				existing.Entries = map[string]*stringtable.Node{}
+			}
+			duplicates := []string{}
+			for key, node := range st.Entries {
+				if _, dup := existing.Entries[key]; dup {
+					duplicates = append(duplicates, key)
+				}
+				existing.Entries[key] = node
+			}
+			if len(duplicates) > 0 {
+				sort.Strings(duplicates)
+				log.W(ctx, "Stringtable overrides %d existing entries for culture '%v': %v",
+					len(duplicates), culture, strings.Join(duplicates, ", "))
+			}
+		}
 	}
 
 	return out
@@ -0,0 +1,59 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+
+	"github.com/google/gapid/core/app/benchmark"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/database"
+)
+
+var (
+	heapAllocCounter  = benchmark.Integer("memory.heap.alloc")
+	heapSysCounter    = benchmark.Integer("memory.heap.sys")
+	sysCounter        = benchmark.Integer("memory.sys")
+	goroutinesCounter = benchmark.Integer("goroutines")
+	dbBytesCounter    = benchmark.Integer("database.bytes")
+)
+
+// serveMetrics serves the benchmark counters over HTTP at addr, in the
+// Prometheus text format. This is a blocking call.
+func serveMetrics(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		heapAllocCounter.Set(int64(stats.HeapAlloc))
+		heapSysCounter.Set(int64(stats.HeapSys))
+		sysCounter.Set(int64(stats.Sys))
+		goroutinesCounter.Set(int64(runtime.NumGoroutine()))
+		db := database.Get(ctx).Stats(ctx)
+		dbBytesCounter.Set(int64(db.Bytes))
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := benchmark.GlobalCounters.WritePrometheus(w); err != nil {
+			log.W(ctx, "Failed to write metrics: %v", err)
+		}
+	})
+
+	log.I(ctx, "Serving metrics on http://%v/metrics", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.E(ctx, "Could not serve metrics at %v: %v", addr, err)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package main
+
+import "os"
+
+// link creates a symlink at dst to src.
+func link(src, dst string) error {
+	return os.Symlink(src, dst)
+}
+
+// isLink is a predicate that returns true if there is a link at path.
+func isLink(path string) bool {
+	return isSymlink(path)
+}
+
+// isStale returns true if the link at the mapping destination no longer refers
+// to the mapping source. Symlinks follow their target path, so are never stale.
+func (m mapping) isStale() bool {
+	return false
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// link creates a link at dst to src.
+// Symlinks are used if the user is allowed to create them, which is the case
+// in Developer Mode. Otherwise directories are linked with NTFS junctions and
+// files are hardlinked, which requires dst to be on the same NTFS volume as
+// src.
+func link(src, dst string) error {
+	if err := os.Symlink(src, dst); err == nil {
+		return nil
+	}
+	if isDir(src) {
+		if out, err := exec.Command("cmd", "/c", "mklink", "/J", dst, src).CombinedOutput(); err != nil {
+			return fmt.Errorf("Failed to create junction %v -> %v: %v\n%s", dst, src, err, out)
+		}
+		return nil
+	}
+	if err := os.Link(src, dst); err != nil {
+		return fmt.Errorf("Failed to hardlink %v -> %v: %v\n"+
+			"The fused directory must be on the same NTFS volume as the linked files. "+
+			"Use --dir to pick a different fused directory, or enable Developer Mode to allow symlinks", dst, src, err)
+	}
+	return nil
+}
+
+// isLink is a predicate that returns true if there is a symlink, or a file with
+// more than one hardlink, at path.
+func isLink(path string) bool {
+	return isSymlink(path) || hardlinks(path) > 1
+}
+
+// isStale returns true if the link at the mapping destination no longer refers
+// to the mapping source. This is the case for hardlinks once bazel replaces
+// the source file.
+func (m mapping) isStale() bool {
+	if isSymlink(m.dst) {
+		return false
+	}
+	src, err := os.Stat(m.src)
+	if err != nil {
+		return false
+	}
+	dst, err := os.Stat(m.dst)
+	if err != nil {
+		return false
+	}
+	return !os.SameFile(src, dst)
+}
+
+// hardlinks returns the number of hardlinks to the file at path.
+func hardlinks(path string) uint32 {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0
+	}
+	h, err := syscall.CreateFile(p, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return 0
+	}
+	defer syscall.CloseHandle(h)
+	var info syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &info); err != nil {
+		return 0
+	}
+	return info.NumberOfLinks
+}
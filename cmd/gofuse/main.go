@@ -22,13 +22,16 @@
 // These symlinks are 'fused' into a single, common directory structure that
 // is expected by the typical GOPATH rules used by go tooling.
 //
-// Note: the extensive use of symlinks makes Windows support unlikely.
+// On Windows, where creating symlinks usually needs elevated privileges, files
+// are hardlinked and directories are linked with NTFS junctions instead. The
+// fused directory must then be on the same NTFS volume as the linked files.
 //
 // Examples:
 //   bazel run //cmd/gofuse
 //   bazel run //cmd/gofuse -- --bazelout=k8-fastbuild
 //   bazel run //cmd/gofuse -- --bazelout=k8-dbg
 //   bazel run //cmd/gofuse -- --bazelout=darwin-fastbuild
+//   bazel run //cmd/gofuse -- --watch
 package main
 
 import (
@@ -39,6 +42,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // Map of bazel external package names to the expected import names.
@@ -62,6 +66,12 @@ var (
 
 	bazelOutDirectory = flag.String("bazelout", "",
 		"The bazel-out/X directory name from which to include .go files. E.g. k8-fastbuild, darwin-fastbuild, k8-dbg, etc.")
+
+	watchFiles = flag.Bool("watch", false,
+		"Keep running, and update the fused directory as files are added or removed")
+
+	watchInterval = flag.Duration("watch-interval", 2*time.Second,
+		"How often to check for changes with --watch")
 )
 
 func main() {
@@ -82,6 +92,8 @@ func run() error {
 			break
 		case "darwin":
 			*bazelOutDirectory = "darwin-fastbuild"
+		case "windows":
+			*bazelOutDirectory = "x64_windows-fastbuild"
 		default:
 		}
 
@@ -113,10 +125,31 @@ func run() error {
 
 	fmt.Println("Updating fused directory at:", fusedRoot)
 
-	// Collect all the existing symlinks under the fused root.
-	fusedFiles := collect(fusedRoot, always).ifTrue(and(isFile, isSymlink))
+	allMappings, err := collectMappings(projectRoot, fusedRoot, true)
+	if err != nil {
+		return err
+	}
+	if err := update(fusedRoot, allMappings); err != nil {
+		return err
+	}
 
-	fmt.Print("Collecting files from:", projectRoot)
+	if *watchFiles {
+		return watch(projectRoot, fusedRoot, allMappings)
+	}
+	return nil
+}
+
+// collectMappings returns the mappings of all the files that should be linked
+// in the fused directory. If verbose is true then the directories searched are
+// printed.
+func collectMappings(projectRoot, fusedRoot string, verbose bool) (mappings, error) {
+	say := func(a ...interface{}) {
+		if verbose {
+			fmt.Println(a...)
+		}
+	}
+
+	say("Collecting files from:", projectRoot)
 	srcMapping := collect(projectRoot,
 		and(
 			// Don't traverse the fused root
@@ -134,7 +167,7 @@ func run() error {
 
 	// E.g. bazel-out/k8-dbg/genfiles
 	genfilesOut := filepath.Join(projectRoot, "bazel-out", *bazelOutDirectory, "genfiles")
-	fmt.Println("Collecting generated .go files from:", genfilesOut)
+	say("Collecting generated .go files from:", genfilesOut)
 	genfilesMappingOut := collect(genfilesOut, always).
 		ifTrue(and(isFile, hasSuffix(".go"))). // Only consider .go files
 		mapping(func(path string) string {
@@ -144,7 +177,7 @@ func run() error {
 	// E.g. bazel-out/k8-dbg/bin
 	// Currently just gets generated gapid files.
 	binOut := filepath.Join(projectRoot, "bazel-out", *bazelOutDirectory, "bin")
-	fmt.Println("Collecting generated .go files from:", binOut)
+	say("Collecting generated .go files from:", binOut)
 	binMappingOut := collect(binOut, always).ifTrue(and(
 		isFile,
 		contains(filepath.Join("github.com", "google", "gapid")),
@@ -183,11 +216,11 @@ func run() error {
 	// E.g. /home/paulthomson/.cache/bazel/_bazel_paulthomson/1234/execroot/gapid
 	bazelGapidResolved, err := filepath.EvalSymlinks(filepath.Join(projectRoot, "bazel-out"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	bazelGapidResolved, err = filepath.Abs(bazelGapidResolved)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// E.g. /home/paulthomson/.cache/bazel/_bazel_paulthomson/1234/
@@ -199,7 +232,7 @@ func run() error {
 	extMapping := mappings{}
 	for pkg, imp := range externals {
 		src := filepath.Join(bazelExternals, pkg)
-		fmt.Println("Collecting .go files from:", src)
+		say("Collecting .go files from:", src)
 		dst := filepath.Join(fusedRoot, "src", imp)
 		m := collect(src, always).ifTrue(and(isFile, hasSuffix(".go"))).
 			mapping(func(path string) string {
@@ -209,7 +242,7 @@ func run() error {
 	}
 
 	thirdPartiesOut := filepath.Join(projectRoot, "bazel-out", *bazelOutDirectory, "bin", "tools", "build", "third_party")
-	fmt.Println("Collecting generated .go from:", thirdPartiesOut)
+	say("Collecting generated .go from:", thirdPartiesOut)
 	perfettoProtosMappingOut := collect(thirdPartiesOut, always).ifTrue(and(
 		isFile,
 		contains(filepath.Join("protos", "perfetto")),
@@ -219,6 +252,13 @@ func run() error {
 	})
 	// Every mapping we're going to deal with.
 	allMappings := join(srcMapping, genfilesMappingOut, binMappingOut, templateGenedGofiles, templateGenedCppfiles, extMapping, perfettoProtosMappingOut)
+	return allMappings, nil
+}
+
+// update makes the links in the fused directory match allMappings.
+func update(fusedRoot string, allMappings mappings) error {
+	// Collect all the existing links under the fused root.
+	fusedFiles := collect(fusedRoot, always).ifTrue(and(isFile, isLink))
 
 	// Remove all existing symlinks in the fused directory that are not part of the
 	// mappings. This may never happen if the OS automatically deletes deleted
@@ -227,6 +267,14 @@ func run() error {
 		return err
 	}
 
+	// Remove all the links that no longer refer to their source file, so they
+	// are created again below.
+	stale := allMappings.ifTrue(mapping.isStale).dsts()
+	if err := stale.foreach(remove); err != nil {
+		return err
+	}
+	fusedFiles = fusedFiles.ifFalse(stale.set().contains)
+
 	// Create symlinks for all of the missing mappings.
 	if err := allMappings.ifDstFalse(fusedFiles.set().contains).foreach(mapping.symlink); err != nil {
 		return err
@@ -328,6 +376,34 @@ func (l mappings) dsts() paths {
 	return out
 }
 
+// equals returns true if l and o hold the same mappings, in any order.
+func (l mappings) equals(o mappings) bool {
+	if len(l) != len(o) {
+		return false
+	}
+	set := make(map[mapping]struct{}, len(l))
+	for _, m := range l {
+		set[m] = struct{}{}
+	}
+	for _, m := range o {
+		if _, ok := set[m]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ifTrue returns all the mappings in l where the predicate p returns true.
+func (l mappings) ifTrue(p func(mapping) bool) mappings {
+	out := make(mappings, 0, len(l))
+	for _, m := range l {
+		if p(m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
 // ifDstTrue returns all the mappings in l where the predicate p returns true
 // for the destination path.
 func (l mappings) ifDstTrue(p pred) mappings {
@@ -356,14 +432,15 @@ func (l mappings) foreach(f func(mapping) error) error {
 	return nil
 }
 
-// symlink creates a symlink from the mapping source to the mapping destination.
+// symlink creates a link from the mapping source to the mapping destination.
+// This is a symlink, unless the OS does not support them. See link.
 func (m mapping) symlink() error {
 	fmt.Println("--- Symlinking source file:\n", m.src, "->", m.dst)
 	dir, _ := filepath.Split(m.dst)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
-	return os.Symlink(m.src, m.dst)
+	return link(m.src, m.dst)
 }
 
 // contains returns true if the set s contains str.
@@ -374,7 +451,7 @@ func (s set) contains(str string) bool {
 
 // remove deletes the file or directory at p.
 func remove(p string) error {
-	fmt.Println("--- Removing symlink:\n", p)
+	fmt.Println("--- Removing link:\n", p)
 	return os.Remove(p)
 }
 
@@ -0,0 +1,56 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// watch keeps the fused directory up to date as files are added to or removed
+// from the source tree and the bazel output directories. current holds the
+// mappings the fused directory was last updated with.
+//
+// The directories are polled every watchInterval. As bazel writes files in
+// bursts, the fused directory is only updated once the set of files stops
+// changing between two polls.
+func watch(projectRoot, fusedRoot string, current mappings) error {
+	fmt.Println("Watching for changes. Press Ctrl-C to stop.")
+	poll := func() mappings {
+		for {
+			time.Sleep(*watchInterval)
+			m, err := collectMappings(projectRoot, fusedRoot, false)
+			if err == nil {
+				return m
+			}
+			// bazel-out may be missing part way through a 'bazel clean'.
+			fmt.Println("Failed to collect files:", err)
+		}
+	}
+	for {
+		next := poll()
+		if next.equals(current) {
+			continue
+		}
+		for settled := poll(); !settled.equals(next); settled = poll() {
+			next = settled
+		}
+		fmt.Println("Files changed. Updating fused directory at:", fusedRoot)
+		if err := update(fusedRoot, next); err != nil {
+			return err
+		}
+		current = next
+	}
+}
@@ -36,7 +36,9 @@ import (
 var (
 	path      = flag.String("file", "capture.gfxtrace", "The capture file to linearize")
 	output    = flag.String("out", "capture.linear.gfxtrace", "The output file")
-	nCommands = flag.Int("num_commands", -1, "How many commands from the original trace should be included. -1 for all.")
+	nCommands = flag.Int("num_commands", -1, "How many commands from the original trace should be included, counting from -start. 0 or less for all.")
+	start     = flag.Int("start", 0, "The index of the first command from the original trace to include")
+	end       = flag.Int("end", -1, "The index one past the last command from the original trace to include. -1 for the end of the trace.")
 )
 
 func main() {
@@ -68,20 +70,30 @@ func run(ctx context.Context) error {
 
 	log.I(ctx, "Generated %v initial commands", len(initialCmds))
 
-	if *nCommands < 0 {
-		if *nCommands == -1 {
-			capt.Commands = append(initialCmds, capt.Commands...)
-		} else {
-			return log.Errf(ctx, nil, "Invalid number of commands requested: %d", *nCommands)
-		}
-	} else {
-		if *nCommands <= len(capt.Commands) {
-			capt.Commands = append(initialCmds, capt.Commands[0:*nCommands]...)
-		} else {
-			return log.Errf(ctx, nil, "Number of commands requested: %d exceeds the total number of commands in the original trace: %d", len(capt.Commands), *nCommands)
+	first, last := *start, *end
+	if *nCommands > 0 {
+		if *end != -1 {
+			return log.Errf(ctx, nil, "Only one of -num_commands and -end can be specified")
 		}
+		last = first + *nCommands
+	} else if last == -1 {
+		last = len(capt.Commands)
+	}
+
+	switch {
+	case first < 0 || first > len(capt.Commands):
+		return log.Errf(ctx, nil, "Start command %d is outside the range of the original trace: [0, %d]", first, len(capt.Commands))
+	case last < first:
+		return log.Errf(ctx, nil, "End command %d is before the start command %d", last, first)
+	case last > len(capt.Commands):
+		return log.Errf(ctx, nil, "End command %d exceeds the total number of commands in the original trace: %d", last, len(capt.Commands))
+	case last == first:
+		return log.Errf(ctx, nil, "The requested command range [%d, %d) is empty", first, last)
 	}
 
+	log.I(ctx, "Including commands [%d, %d) of the original trace", first, last)
+	capt.Commands = append(initialCmds, capt.Commands[first:last]...)
+
 	capt.InitialState = nil
 
 	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
@@ -48,6 +48,7 @@ func main() {
 			return err
 		}
 		scanner := bufio.NewScanner(file)
+		rel = filepath.ToSlash(rel) // Keep the output the same on all hosts.
 		lib := entry{path: rel}
 		key := ""
 		for scanner.Scan() {
@@ -86,6 +87,13 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	// Sort the libraries so the output does not depend on the walk order.
+	sort.Slice(libs, func(i, j int) bool {
+		if libs[i].name != libs[j].name {
+			return libs[i].name < libs[j].name
+		}
+		return libs[i].path < libs[j].path
+	})
 	fmt.Printf(`# AUTOGENERATED FILE
 # This file is automatically generated from the LLVMBuild.txt files
 # Do not change this file by hand.
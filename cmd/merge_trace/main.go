@@ -0,0 +1,95 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The merge_trace command takes two linear traces, and writes a single trace
+// that holds the commands of the first followed by those of the second.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/google/gapid/core/app"
+	log "github.com/google/gapid/core/log"
+	_ "github.com/google/gapid/gapis/api/gles"
+	_ "github.com/google/gapid/gapis/api/gvr"
+	_ "github.com/google/gapid/gapis/api/vulkan"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+)
+
+var (
+	first        = flag.String("first", "first.gfxtrace", "The capture file holding the first commands")
+	second       = flag.String("second", "second.gfxtrace", "The capture file holding the commands to append")
+	output       = flag.String("out", "capture.merged.gfxtrace", "The output file")
+	allowDevices = flag.Bool("allow-device-mismatch", false, "Merge the captures even if they were taken on different devices")
+	allowAPIs    = flag.Bool("allow-api-mismatch", false, "Merge the captures even if they use different APIs")
+	allowStructs = flag.Bool("allow-unrebaseable", false, "Keep the commands of the second capture that cannot be rebased as they are")
+)
+
+func main() {
+	app.ShortHelp = "merge_trace concatenates two linear captures into a single capture"
+	app.Name = "merge_trace"
+	app.Run(run)
+}
+
+func load(ctx context.Context, path string) (*capture.GraphicsCapture, error) {
+	name := filepath.Base(path)
+	p, err := capture.Import(ctx, name, name, &capture.File{Path: path})
+	if err != nil {
+		return nil, err
+	}
+	return capture.ResolveGraphicsFromPath(ctx, p)
+}
+
+func run(ctx context.Context) error {
+
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	a, err := load(ctx, *first)
+	if err != nil {
+		return err
+	}
+	b, err := load(ctx, *second)
+	if err != nil {
+		return err
+	}
+
+	opts := capture.MergeOptions{
+		AllowDeviceMismatch: *allowDevices,
+		AllowAPIMismatch:    *allowAPIs,
+		AllowUnrebaseable:   *allowStructs,
+	}
+	merged, err := capture.Merge(ctx, filepath.Base(*output), a, b, opts)
+	if err != nil {
+		return err
+	}
+
+	log.I(ctx, "Merged %v and %v commands", len(a.Commands), len(b.Commands))
+
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = merged.Export(ctx, f); err != nil {
+		return err
+	}
+	log.I(ctx, "Capture written to: %v", *output)
+
+	return nil
+}
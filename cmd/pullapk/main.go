@@ -34,13 +34,14 @@ const (
 )
 
 var (
-	output  = flag.String("out", "", "The output file path")
-	serial  = flag.String("device", "", "The serial of the device to pull from")
-	skipOBB = flag.Bool("skip-obb", false, "Set this flag to skip trying to pull a matching OBB file from the device")
+	output   = flag.String("out", "", "The output file path")
+	serial   = flag.String("device", "", "The serial of the device to pull from")
+	skipOBB  = flag.Bool("skip-obb", false, "Set this flag to skip trying to pull a matching OBB file from the device")
+	baseOnly = flag.Bool("base-only", false, "Set this flag to only pull the base APK of a package installed as split APKs")
 )
 
 func main() {
-	app.ShortHelp = "pullapk pulls an APK from an Android device."
+	app.ShortHelp = "pullapk pulls an APK, and any split APKs, from an Android device."
 	app.Run(run)
 }
 
@@ -102,5 +103,15 @@ func run(ctx context.Context) error {
 		}
 	}
 
-	return found.Pull(ctx, out)
+	if *baseOnly {
+		return found.Pull(ctx, out)
+	}
+	pulled, err := found.PullAll(ctx, out)
+	if err != nil {
+		return err
+	}
+	for _, apk := range pulled[1:] {
+		log.I(ctx, "Pulled split APK to %v", apk)
+	}
+	return nil
 }
@@ -0,0 +1,139 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+
+	"github.com/google/gapid/core/git"
+	"github.com/google/gapid/core/log"
+)
+
+// bisecting returns true if regres was asked to bisect a regression instead of
+// profiling a fixed number of changelists.
+func bisecting() bool {
+	return *bisectGood != "" || *bisectBad != ""
+}
+
+// checkBisectFlags returns an error if the bisect flags are incomplete or
+// name an unknown metric.
+func checkBisectFlags() error {
+	if !bisecting() {
+		return nil
+	}
+	if *bisectGood == "" || *bisectBad == "" {
+		return fmt.Errorf("Both --bisect-good and --bisect-bad must be specified")
+	}
+	_, err := bisectColumn()
+	return err
+}
+
+// bisectColumn returns the column named by the bisect-metric flag.
+func bisectColumn() (column, error) {
+	names := []string{}
+	for _, c := range columns() {
+		switch c.get(stats{}).Kind() {
+		case reflect.Int, reflect.Float64:
+			if c.name == *bisectMetric {
+				return c, nil
+			}
+			names = append(names, c.name)
+		}
+	}
+	return column{}, fmt.Errorf("Unknown bisect metric '%v'. Must be one of: %v", *bisectMetric, names)
+}
+
+// bisect searches the changelists after bisectGood up to and including
+// bisectBad for the first one whose bisect metric is above bisectThreshold.
+// The changelists are assumed to be ordered such that, once the metric has
+// exceeded the threshold, it remains above it.
+// bisect returns the stats of the measured changelists, oldest first.
+func bisect(ctx context.Context, g git.Git, rnd *rand.Rand) ([]stats, error) {
+	metric, err := bisectColumn()
+	if err != nil {
+		return nil, err
+	}
+
+	cls, err := g.LogRange(ctx, *bisectGood, *bisectBad)
+	if err != nil {
+		return nil, err
+	}
+	if len(cls) == 0 {
+		return nil, fmt.Errorf("No changelists found between '%v' and '%v'", *bisectGood, *bisectBad)
+	}
+	// Order the changelists oldest first.
+	for i, j := 0, len(cls)-1; i < j; i, j = i+1, j-1 {
+		cls[i], cls[j] = cls[j], cls[i]
+	}
+
+	measured := map[git.SHA]stats{}
+	skipped := 0
+
+	// The last changelist is known to be bad, so search the ones before it.
+	candidates := cls
+	lo, hi := 0, len(candidates)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		cl := candidates[mid]
+		log.I(ctx, "Bisecting: %d changelists left to test", hi-lo)
+		r, err := measure(ctx, g, cl, "bisect", rnd)
+		if err != nil {
+			return nil, err
+		}
+		if r == nil {
+			// The changelist could not be measured. Drop it and carry on
+			// with the remaining candidates.
+			log.W(ctx, "Skipping %v: %v", cl.SHA.String()[:6], cl.Subject)
+			skipped++
+			candidates = append(candidates[:mid:mid], candidates[mid+1:]...)
+			hi--
+			continue
+		}
+		measured[cl.SHA] = *r
+
+		value := metric.get(*r)
+		var v float64
+		switch value.Kind() {
+		case reflect.Int:
+			v = float64(value.Int())
+		case reflect.Float64:
+			v = value.Float()
+		}
+		if v > *bisectThreshold {
+			log.I(ctx, "%v is bad: %v = %v", r.SHA, metric.name, v)
+			hi = mid
+		} else {
+			log.I(ctx, "%v is good: %v = %v", r.SHA, metric.name, v)
+			lo = mid + 1
+		}
+	}
+
+	culprit := candidates[lo]
+	log.I(ctx, "First bad changelist: %v: %v", culprit.SHA, culprit.Subject)
+	if skipped > 0 {
+		log.W(ctx, "%d changelists could not be measured, so the regression may instead have been introduced by one of those preceding %v", skipped, culprit.SHA.String()[:6])
+	}
+
+	res := []stats{}
+	for _, cl := range cls {
+		if r, ok := measured[cl.SHA]; ok {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
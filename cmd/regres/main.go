@@ -18,8 +18,11 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -39,6 +42,7 @@ import (
 
 var (
 	root      = flag.String("root", "", "Path to the root GAPID source directory")
+	clone     = flag.String("clone", "", "URL of a GAPID repository to shallow clone into root")
 	verbose   = flag.Bool("verbose", false, "Verbose logging")
 	incBuild  = flag.Bool("inc", true, "Time incremental builds")
 	optimize  = flag.Bool("optimize", false, "Build using '-c opt'")
@@ -46,6 +50,12 @@ var (
 	atSHA     = flag.String("at", "", "The SHA or branch of the first changelist to profile")
 	count     = flag.Int("count", 2, "The number of changelists to profile since HEAD")
 	tracePath = flag.String("trace", "", "Path to a .gfxtrace used for report timing")
+	format    = flag.String("format", "table", "The output format: table, csv or json")
+
+	bisectGood      = flag.String("bisect-good", "", "The SHA or branch of a changelist known not to have the regression")
+	bisectBad       = flag.String("bisect-bad", "", "The SHA or branch of a changelist known to have the regression")
+	bisectMetric    = flag.String("bisect-metric", "", "The name of the statistic to bisect on, like report-time")
+	bisectThreshold = flag.Float64("bisect-threshold", 0, "The value of the bisect metric above which a changelist has the regression")
 )
 
 func main() {
@@ -75,13 +85,27 @@ type stats struct {
 		Commands int `name:"commands"`
 	}
 	ReplayStats struct {
-		ReportTime    float64 `name:"report-time"`    // in seconds
-		LinearizeTime float64 `name:"linearize-time"` // in seconds
+		ReportTime       float64 `name:"report-time"`        // in seconds
+		ReportPeakRSS    int     `name:"report-peak-rss"`    // in bytes
+		LinearizeTime    float64 `name:"linearize-time"`     // in seconds
+		LinearizePeakRSS int     `name:"linearize-peak-rss"` // in bytes
 	}
 }
 
 func run(ctx context.Context) error {
+	switch *format {
+	case "table", "csv", "json":
+	default:
+		return fmt.Errorf("Unknown output format '%v'. Must be table, csv or json", *format)
+	}
+	if err := checkBisectFlags(); err != nil {
+		return err
+	}
+
 	if *root == "" {
+		if *clone != "" {
+			return fmt.Errorf("--root must be set to the directory to clone into")
+		}
 		wd, err := os.Getwd()
 		if err != nil {
 			return err
@@ -89,10 +113,20 @@ func run(ctx context.Context) error {
 		*root = wd
 	}
 
-	g, err := git.New(*root)
+	var g git.Git
+	var err error
+	if *clone != "" {
+		log.I(ctx, "Cloning %v into %v", *clone, *root)
+		g, err = git.Clone(ctx, *clone, *root, *count)
+	} else {
+		g, err = git.New(*root)
+	}
 	if err != nil {
 		return err
 	}
+	if err := fetchHistory(ctx, g); err != nil {
+		return err
+	}
 	s, err := g.Status(ctx)
 	if err != nil {
 		return err
@@ -108,153 +142,287 @@ func run(ctx context.Context) error {
 
 	defer g.CheckoutBranch(ctx, branch)
 
-	cls, err := g.LogFrom(ctx, *atSHA, *count)
+	rnd := rand.New(rand.NewSource(time.Now().Unix()))
+
+	var res []stats
+	if bisecting() {
+		res, err = bisect(ctx, g, rnd)
+	} else {
+		res, err = profile(ctx, g, rnd)
+	}
 	if err != nil {
 		return err
 	}
 
-	rnd := rand.New(rand.NewSource(time.Now().Unix()))
+	switch *format {
+	case "csv":
+		return writeCSV(os.Stdout, res)
+	case "json":
+		return writeJSON(os.Stdout, res)
+	default:
+		writeTable(os.Stdout, res)
+		return nil
+	}
+}
+
+// historyStep is the number of commits fetched at a time when deepening a
+// shallow clone.
+const historyStep = 32
+
+// fetchHistory deepens a shallow clone until it holds all the changelists that
+// are to be profiled or bisected.
+func fetchHistory(ctx context.Context, g git.Git) error {
+	for {
+		shallow, err := g.IsShallow(ctx)
+		if err != nil || !shallow {
+			return err
+		}
+		if ok, err := hasHistory(ctx, g); err != nil || ok {
+			return err
+		}
+		log.I(ctx, "Fetching %d more commits of history", historyStep)
+		if err := g.Deepen(ctx, historyStep); err != nil {
+			return err
+		}
+	}
+}
+
+// hasHistory returns true if g holds all the changelists that are to be
+// profiled or bisected.
+func hasHistory(ctx context.Context, g git.Git) (bool, error) {
+	if bisecting() {
+		return g.HasCommit(ctx, *bisectGood) && g.HasCommit(ctx, *bisectBad), nil
+	}
+	at := *atSHA
+	if at == "" {
+		at = "HEAD"
+	}
+	if !g.HasCommit(ctx, at) {
+		return false, nil
+	}
+	cls, err := g.LogFrom(ctx, at, *count)
+	if err != nil {
+		return false, err
+	}
+	return len(cls) == *count, nil
+}
+
+// profile measures the count changelists up to atSHA, oldest first.
+func profile(ctx context.Context, g git.Git, rnd *rand.Rand) ([]stats, error) {
+	cls, err := g.LogFrom(ctx, *atSHA, *count)
+	if err != nil {
+		return nil, err
+	}
 
 	res := []stats{}
 	for i := range cls {
 		i := len(cls) - 1 - i
-		cl := cls[i]
-		sha := cl.SHA.String()[:6]
+		r, err := measure(ctx, g, cls[i], fmt.Sprintf("HEAD~%.2d", i), rnd)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			res = append(res, *r)
+		}
+	}
+	return res, nil
+}
 
-		r := stats{SHA: sha}
+// measure builds and measures the changelist cl, using label to identify it
+// in the logs. measure returns nil stats if the changelist could not be built
+// or captured.
+func measure(ctx context.Context, g git.Git, cl git.ChangeList, label string, rnd *rand.Rand) (*stats, error) {
+	sha := cl.SHA.String()[:6]
+	r := stats{SHA: sha}
 
-		log.I(ctx, "HEAD~%.2d: Building at %v: %v", i, sha, cl.Subject)
-		if err := g.Checkout(ctx, cl.SHA); err != nil {
-			return err
-		}
+	log.I(ctx, "%v: Building at %v: %v", label, sha, cl.Subject)
+	if err := g.Checkout(ctx, cl.SHA); err != nil {
+		return nil, err
+	}
 
-		_, err := build(ctx)
+	_, err := build(ctx)
+	if err != nil {
+		return nil, nil
+	}
+
+	// Gather file size build stats
+	pkgDir := filepath.Join(*root, "bazel-bin", "pkg")
+	for _, f := range []struct {
+		path string
+		size *int
+	}{
+		{filepath.Join(pkgDir, "lib", dllExt("libgapii")), &r.FileSizes.LibGAPII},
+		{filepath.Join(pkgDir, "lib", dllExt("libVkLayer_VirtualSwapchain")), &r.FileSizes.LibVkLayerVirtualSwapchain},
+		{filepath.Join(pkgDir, "lib", dllExt("libVkLayer_CPUTiming")), &r.FileSizes.LibVkLayerCPUTiming},
+		{filepath.Join(pkgDir, "lib", dllExt("libVkLayer_MemoryTracker")), &r.FileSizes.LibVkLayerMemoryTracker},
+		{filepath.Join(pkgDir, "gapid-armeabi-v7a.apk"), &r.FileSizes.GAPIDARMv7aAPK},
+		{filepath.Join(pkgDir, "gapid-arm64-v8a.apk"), &r.FileSizes.GAPIDARMv8aAPK},
+		{filepath.Join(pkgDir, "gapid-x86.apk"), &r.FileSizes.GAPIDX86APK},
+		{filepath.Join(pkgDir, exeExt("gapid")), &r.FileSizes.GAPID},
+		{filepath.Join(pkgDir, exeExt("gapir")), &r.FileSizes.GAPIR},
+		{filepath.Join(pkgDir, exeExt("gapis")), &r.FileSizes.GAPIS},
+		{filepath.Join(pkgDir, exeExt("gapit")), &r.FileSizes.GAPIT},
+	} {
+		fi, err := os.Stat(f.path)
 		if err != nil {
+			log.W(ctx, "Couldn't stat file '%v': %v", f.path, err)
 			continue
 		}
+		*f.size = int(fi.Size())
+	}
 
-		// Gather file size build stats
-		pkgDir := filepath.Join(*root, "bazel-bin", "pkg")
-		for _, f := range []struct {
-			path string
-			size *int
-		}{
-			{filepath.Join(pkgDir, "lib", dllExt("libgapii")), &r.FileSizes.LibGAPII},
-			{filepath.Join(pkgDir, "lib", dllExt("libVkLayer_VirtualSwapchain")), &r.FileSizes.LibVkLayerVirtualSwapchain},
-			{filepath.Join(pkgDir, "lib", dllExt("libVkLayer_CPUTiming")), &r.FileSizes.LibVkLayerCPUTiming},
-			{filepath.Join(pkgDir, "lib", dllExt("libVkLayer_MemoryTracker")), &r.FileSizes.LibVkLayerMemoryTracker},
-			{filepath.Join(pkgDir, "gapid-armeabi-v7a.apk"), &r.FileSizes.GAPIDARMv7aAPK},
-			{filepath.Join(pkgDir, "gapid-arm64-v8a.apk"), &r.FileSizes.GAPIDARMv8aAPK},
-			{filepath.Join(pkgDir, "gapid-x86.apk"), &r.FileSizes.GAPIDX86APK},
-			{filepath.Join(pkgDir, exeExt("gapid")), &r.FileSizes.GAPID},
-			{filepath.Join(pkgDir, exeExt("gapir")), &r.FileSizes.GAPIR},
-			{filepath.Join(pkgDir, exeExt("gapis")), &r.FileSizes.GAPIS},
-			{filepath.Join(pkgDir, exeExt("gapit")), &r.FileSizes.GAPIT},
-		} {
-			fi, err := os.Stat(f.path)
-			if err != nil {
-				log.W(ctx, "Couldn't stat file '%v': %v", f.path, err)
-				continue
-			}
-			*f.size = int(fi.Size())
+	// Gather capture stats
+	if *pkg != "" {
+		file, err := trace(ctx)
+		if err != nil {
+			log.W(ctx, "Couldn't capture trace: %v", err)
+			return nil, nil
 		}
-
-		// Gather capture stats
-		if *pkg != "" {
-			file, err := trace(ctx)
-			if err != nil {
-				log.W(ctx, "Couldn't capture trace: %v", err)
-				continue
-			}
-			defer os.Remove(file)
-			frames, draws, cmds, err := captureStats(ctx, file)
-			if err != nil {
-				continue
-			}
-			r.CaptureStats.Frames = frames
-			r.CaptureStats.Draws = draws
-			r.CaptureStats.Commands = cmds
-			*tracePath = file
+		defer os.Remove(file)
+		frames, draws, cmds, err := captureStats(ctx, file)
+		if err != nil {
+			return nil, nil
 		}
+		r.CaptureStats.Frames = frames
+		r.CaptureStats.Draws = draws
+		r.CaptureStats.Commands = cmds
+		*tracePath = file
+	}
 
-		if *tracePath != "" {
-			start := time.Now()
-			if err := report(ctx, *tracePath); err != nil {
-				return err
-			}
-			r.ReplayStats.ReportTime = time.Since(start).Seconds()
+	if *tracePath != "" {
+		start := time.Now()
+		rss, err := report(ctx, *tracePath)
+		if err != nil {
+			return nil, err
+		}
+		r.ReplayStats.ReportTime = time.Since(start).Seconds()
+		r.ReplayStats.ReportPeakRSS = rss
 
-			start = time.Now()
-			if err := linearize(ctx, *tracePath); err != nil {
-				return err
-			}
-			r.ReplayStats.LinearizeTime = time.Since(start).Seconds()
+		start = time.Now()
+		rss, err = linearize(ctx, *tracePath)
+		if err != nil {
+			return nil, err
 		}
+		r.ReplayStats.LinearizeTime = time.Since(start).Seconds()
+		r.ReplayStats.LinearizePeakRSS = rss
+	}
 
-		// Gather incremental build stats
-		if *incBuild {
-			if err := withTouchedGLES(ctx, rnd, func() error {
-				log.I(ctx, "HEAD~%.2d: Building incremental change at %v: %v", i, sha, cl.Subject)
-				if duration, err := build(ctx); err == nil {
-					r.IncrementalBuildTime = duration.Seconds()
-				}
-				return nil
-			}); err != nil {
-				continue
+	// Gather incremental build stats
+	if *incBuild {
+		if err := withTouchedGLES(ctx, rnd, func() error {
+			log.I(ctx, "%v: Building incremental change at %v: %v", label, sha, cl.Subject)
+			if duration, err := build(ctx); err == nil {
+				r.IncrementalBuildTime = duration.Seconds()
 			}
+			return nil
+		}); err != nil {
+			return nil, nil
 		}
-
-		res = append(res, r)
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 1, 4, 0, ' ', 0)
-	defer w.Flush()
+	return &r, nil
+}
 
-	var display func(get func(stats) reflect.Value, ty reflect.Type, name string)
-	display = func(get func(stats) reflect.Value, ty reflect.Type, name string) {
+// column is a single statistic of the stats struct.
+type column struct {
+	name string                    // The name from the field's name tag.
+	get  func(stats) reflect.Value // Returns the statistic's value.
+}
+
+// columns returns the statistics of the stats struct, in declaration order.
+func columns() []column {
+	out := []column{}
+	var walk func(get func(stats) reflect.Value, ty reflect.Type, name string)
+	walk = func(get func(stats) reflect.Value, ty reflect.Type, name string) {
 		switch ty.Kind() {
 		case reflect.Struct:
 			for i, c := 0, ty.NumField(); i < c; i++ {
-				f := ty.Field(i)
+				i, f := i, ty.Field(i)
 				get := func(s stats) reflect.Value { return get(s).Field(i) }
 				name := f.Name
 				if n := f.Tag.Get("name"); n != "" {
 					name = n
 				}
-				display(get, f.Type, name)
+				walk(get, f.Type, name)
 			}
 		default:
-			fmt.Fprint(w, name)
-			var prev reflect.Value
-			for i, s := range res {
-				v := get(s)
-				var old, new float64
-				if i > 0 {
-					switch v.Kind() {
-					case reflect.Int:
-						old, new = float64(prev.Int()), float64(v.Int())
-					case reflect.Float64:
-						old, new = prev.Float(), v.Float()
-					}
-				}
-				if old != new {
-					percent := 100 * (new - old) / old
-					fmt.Fprintf(w, "\t | %v \t(%+4.1f%%)", v.Interface(), percent)
-				} else {
-					fmt.Fprintf(w, "\t | %v \t", v.Interface())
-				}
-				prev = v
-			}
-			fmt.Fprintln(w)
+			out = append(out, column{name, get})
 		}
 	}
-
-	display(
+	walk(
 		func(s stats) reflect.Value { return reflect.ValueOf(s) },
 		reflect.TypeOf(stats{}),
 		"")
+	return out
+}
 
-	return nil
+// writeTable writes the statistics to w as an aligned table, with a row per
+// statistic and a column per changelist.
+func writeTable(out io.Writer, res []stats) {
+	w := tabwriter.NewWriter(out, 1, 4, 0, ' ', 0)
+	defer w.Flush()
+
+	for _, c := range columns() {
+		fmt.Fprint(w, c.name)
+		var prev reflect.Value
+		for i, s := range res {
+			v := c.get(s)
+			var old, new float64
+			if i > 0 {
+				switch v.Kind() {
+				case reflect.Int:
+					old, new = float64(prev.Int()), float64(v.Int())
+				case reflect.Float64:
+					old, new = prev.Float(), v.Float()
+				}
+			}
+			if old != new {
+				percent := 100 * (new - old) / old
+				fmt.Fprintf(w, "\t | %v \t(%+4.1f%%)", v.Interface(), percent)
+			} else {
+				fmt.Fprintf(w, "\t | %v \t", v.Interface())
+			}
+			prev = v
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// writeCSV writes the statistics to w as CSV, with a header row holding the
+// statistic names followed by a row per changelist.
+func writeCSV(out io.Writer, res []stats) error {
+	cols := columns()
+	w := csv.NewWriter(out)
+	row := make([]string, len(cols))
+	for i, c := range cols {
+		row[i] = c.name
+	}
+	w.Write(row)
+	for _, s := range res {
+		for i, c := range cols {
+			row[i] = fmt.Sprint(c.get(s).Interface())
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeJSON writes the statistics to w as a JSON array holding an object per
+// changelist, keyed by the statistic names.
+func writeJSON(out io.Writer, res []stats) error {
+	cols := columns()
+	rows := make([]map[string]interface{}, len(res))
+	for i, s := range res {
+		row := make(map[string]interface{}, len(cols))
+		for _, c := range cols {
+			row[c.name] = c.get(s).Interface()
+		}
+		rows[i] = row
+	}
+	e := json.NewEncoder(out)
+	e.SetIndent("", "  ")
+	return e.Encode(rows)
 }
 
 func withTouchedGLES(ctx context.Context, r *rand.Rand, f func() error) error {
@@ -329,17 +497,16 @@ func trace(ctx context.Context) (string, error) {
 	return file, err
 }
 
-func report(ctx context.Context, trace string) error {
+func report(ctx context.Context, trace string) (int, error) {
 	cmd := shell.Cmd{
 		Name:      gapitPath(),
 		Args:      []string{"--log-style", "raw", "report", trace},
 		Verbosity: *verbose,
 	}
-	_, err := cmd.Call(ctx)
-	return err
+	return runWithPeakRSS(ctx, cmd)
 }
 
-func linearize(ctx context.Context, trace string) error {
+func linearize(ctx context.Context, trace string) (int, error) {
 	args := []string{
 		"run", "cmd/linearize_trace",
 		"-c", "opt",
@@ -353,8 +520,54 @@ func linearize(ctx context.Context, trace string) error {
 		Verbosity: *verbose,
 		Dir:       *root,
 	}
-	_, err := cmd.Call(ctx)
-	return err
+	return runWithPeakRSS(ctx, cmd)
+}
+
+// rssPollInterval is the interval between samples of the resident set size
+// taken by runWithPeakRSS.
+const rssPollInterval = 100 * time.Millisecond
+
+// runWithPeakRSS runs cmd to completion, returning the peak resident set size
+// in bytes of the process and its descendants, such as the gapis instance
+// started by gapit. The peak is 0 if memory usage cannot be sampled on this
+// OS.
+func runWithPeakRSS(ctx context.Context, cmd shell.Cmd) (int, error) {
+	process, err := cmd.Start(ctx)
+	if err != nil {
+		return 0, log.Err(ctx, err, "Failed to start process")
+	}
+
+	peak := 0
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		p, ok := process.(interface{ PID() int })
+		if !ok {
+			return
+		}
+		for {
+			rss, ok := processRSS(p.PID())
+			if !ok {
+				return
+			}
+			if rss > peak {
+				peak = rss
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(rssPollInterval):
+			}
+		}
+	}()
+
+	err = process.Wait(ctx)
+	close(stop)
+	<-stopped
+	if err != nil {
+		return 0, log.Err(ctx, err, "Process returned error")
+	}
+	return peak, nil
 }
 
 func captureStats(ctx context.Context, file string) (numFrames, numDraws, numCmds int, err error) {
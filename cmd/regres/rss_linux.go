@@ -0,0 +1,97 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processRSS returns the total resident set size in bytes of the process with
+// the given identifier and all of its descendants. processRSS returns false if
+// the process is no longer running.
+func processRSS(pid int) (int, bool) {
+	children := map[int][]int{}
+	dirs, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return 0, false
+	}
+	for _, d := range dirs {
+		child, err := strconv.Atoi(d.Name())
+		if err != nil {
+			continue
+		}
+		if parent, ok := parentPID(child); ok {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	total, found := 0, false
+	pending := []int{pid}
+	for len(pending) > 0 {
+		p := pending[len(pending)-1]
+		pending = append(pending[:len(pending)-1], children[p]...)
+		if rss, ok := residentSize(p); ok {
+			total, found = total+rss, true
+		}
+	}
+	return total, found
+}
+
+// parentPID returns the identifier of the parent of the process pid, as
+// listed in /proc/<pid>/stat.
+func parentPID(pid int) (int, bool) {
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, false
+	}
+	// The command name is in parentheses and may itself contain spaces or
+	// parentheses, so the fields are parsed from the last closing one.
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	return ppid, err == nil
+}
+
+// residentSize returns the resident set size in bytes of the process pid, as
+// listed by the VmRSS line of /proc/<pid>/status.
+func residentSize(pid int) (int, bool) {
+	f, err := os.Open("/proc/" + strconv.Itoa(pid) + "/status")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || fields[0] != "VmRSS:" {
+			continue
+		}
+		kb, err := strconv.Atoi(fields[1])
+		return kb * 1024, err == nil
+	}
+	// Zombie processes have no VmRSS line.
+	return 0, false
+}
@@ -0,0 +1,24 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package main
+
+// processRSS returns the total resident set size in bytes of the process with
+// the given identifier and all of its descendants. Sampling memory usage is
+// not supported on this OS, so processRSS always returns false.
+func processRSS(pid int) (int, bool) {
+	return 0, false
+}
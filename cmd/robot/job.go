@@ -26,6 +26,9 @@ import (
 	"github.com/google/gapid/core/app/crash"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/net/grpcutil"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/core/os/device/host"
 	"github.com/google/gapid/core/os/file"
 	"github.com/google/gapid/test/robot/job"
 	"github.com/google/gapid/test/robot/master"
@@ -116,9 +119,11 @@ func (v *workerStartFlags) Run(ctx context.Context, flags flag.FlagSet) error {
 			Report: report.NewRemote(ctx, conn),
 			Replay: replay.NewRemote(ctx, conn),
 		}
-		if err := startAllWorkers(ctx, managers, tempDir); err != nil {
+		workerCtx, err := startAllWorkers(ctx, managers, tempDir)
+		if err != nil {
 			return err
 		}
+		m.Devices = func(context.Context) []*device.Instance { return satelliteDevices(workerCtx) }
 		shutdown, err := m.Orbit(ctx, master.ServiceList{Worker: true})
 		if err != nil {
 			return err
@@ -130,11 +135,23 @@ func (v *workerStartFlags) Run(ctx context.Context, flags flag.FlagSet) error {
 	}, grpc.WithInsecure())
 }
 
-func startAllWorkers(ctx context.Context, managers monitor.Managers, tempDir file.Path) error {
+// startAllWorkers starts the workers, returning the context they run in, which
+// holds the registry of their devices.
+func startAllWorkers(ctx context.Context, managers monitor.Managers, tempDir file.Path) (context.Context, error) {
 	ctx = job.BindRegistry(ctx)
 	// TODO: not just ignore all the errors...
 	crash.Go(func() { trace.Run(ctx, managers.Stash, managers.Trace, tempDir) })
 	crash.Go(func() { report.Run(ctx, managers.Stash, managers.Report, tempDir) })
 	crash.Go(func() { replay.Run(ctx, managers.Stash, managers.Replay, tempDir) })
-	return nil
+	return ctx, nil
+}
+
+// satelliteDevices returns the host and the devices in the registry of ctx,
+// which are reported to the master as the devices of the satellite.
+func satelliteDevices(ctx context.Context) []*device.Instance {
+	devices := []*device.Instance{host.Instance(ctx)}
+	for _, d := range bind.GetRegistry(ctx).Devices() {
+		devices = append(devices, d.Instance())
+	}
+	return devices
 }
@@ -22,11 +22,14 @@ import (
 	"net"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/net/grpcutil"
+	"github.com/google/gapid/core/os/device"
 	"github.com/google/gapid/core/os/file"
 	"github.com/google/gapid/core/os/flock"
 	"github.com/google/gapid/test/robot/build"
@@ -46,7 +49,11 @@ import (
 	"google.golang.org/grpc"
 )
 
-const defaultMasterPort = 8081
+const (
+	defaultMasterPort = 8081
+	// snapshotInterval is how often the master saves the monitor data.
+	snapshotInterval = time.Minute
+)
 
 var defaultMasterAddress = fmt.Sprintf("localhost:%v", defaultMasterPort)
 
@@ -55,13 +62,14 @@ func init() {
 		Name:      "master",
 		ShortHelp: "Starts a robot master server",
 		Action: &masterVerb{
-			BaseAddr:     file.Abs("."),
-			StashAddr:    "",
-			ShelfAddr:    "",
-			Port:         defaultMasterPort,
-			StartWorkers: true,
-			StartWeb:     true,
-			WebPort:      8080,
+			BaseAddr:         file.Abs("."),
+			StashAddr:        "",
+			ShelfAddr:        "",
+			Port:             defaultMasterPort,
+			StartWorkers:     true,
+			StartWeb:         true,
+			WebPort:          8080,
+			HeartbeatTimeout: master.DefaultHeartbeatTimeout,
 		},
 	})
 	searchVerb.Add(&app.Verb{
@@ -73,14 +81,15 @@ func init() {
 }
 
 type masterVerb struct {
-	BaseAddr     file.Path `help:"The base path for all robot files"`
-	StashAddr    string    `help:"The address of the stash, defaults to a directory below base"`
-	ShelfAddr    string    `help:"The path to the persisted data, defaults to a directory below base"`
-	Port         int       `help:"The port to serve the master on"`
-	StartWorkers bool      `help:"Enables local workers"`
-	StartWeb     bool      `help:"Enables serving the web client"`
-	WebPort      int       `help:"The port to serve the website on"`
-	Root         file.Path `help:"The directory to use as the root of static content"`
+	BaseAddr         file.Path     `help:"The base path for all robot files"`
+	StashAddr        string        `help:"The address of the stash, defaults to a directory below base"`
+	ShelfAddr        string        `help:"The path to the persisted data, defaults to a directory below base"`
+	Port             int           `help:"The port to serve the master on"`
+	StartWorkers     bool          `help:"Enables local workers"`
+	StartWeb         bool          `help:"Enables serving the web client"`
+	WebPort          int           `help:"The port to serve the website on"`
+	Root             file.Path     `help:"The directory to use as the root of static content"`
+	HeartbeatTimeout time.Duration `help:"The time after which a silent satellite is dropped"`
 }
 
 func (v *masterVerb) Run(ctx context.Context, flags flag.FlagSet) error {
@@ -91,6 +100,9 @@ func (v *masterVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	tempDir := file.Abs(tempName)
 	restart := false
 	serverAddress := fmt.Sprintf(":%v", v.Port)
+	owner := monitor.NewDataOwner()
+	snapshot := v.BaseAddr.Join("monitor.snapshot")
+	monitoring := false
 	err = grpcutil.Serve(ctx, serverAddress, func(ctx context.Context, listener net.Listener, server *grpc.Server) error {
 		managers := monitor.Managers{}
 		err := error(nil)
@@ -115,7 +127,7 @@ func (v *masterVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		if managers.Stash, err = stash.Dial(ctx, stashURL); err != nil {
 			return log.Errf(ctx, err, "Could not open stash: %v", stashURL)
 		}
-		managers.Master = master.NewLocal(ctx)
+		managers.Master = master.NewLocal(ctx, v.HeartbeatTimeout)
 		if managers.Subject, err = subject.NewLocal(ctx, library, managers.Stash); err != nil {
 			return err
 		}
@@ -140,16 +152,37 @@ func (v *masterVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		if err := flock.ReleaseAllLocks(); err != nil {
 			return log.Errf(ctx, err, "Could not remove FLock files for all devices")
 		}
+		var devices func(context.Context) []*device.Instance
 		if v.StartWorkers {
-			if err := startAllWorkers(ctx, managers, tempDir); err != nil {
+			workerCtx, err := startAllWorkers(ctx, managers, tempDir)
+			if err != nil {
 				return err
 			}
+			devices = func(context.Context) []*device.Instance { return satelliteDevices(workerCtx) }
 		}
+		if snapshot.Exists() {
+			if err := owner.Load(ctx, snapshot); err != nil {
+				log.W(ctx, "Ignoring monitor snapshot. Error: %v", err)
+			}
+		}
+		monitoring = true
 		crash.Go(func() {
-			if err := monitor.Run(ctx, managers, monitor.NewDataOwner(), scheduler.Tick); err != nil {
+			if err := monitor.Run(ctx, managers, owner, scheduler.Tick); err != nil {
 				log.E(ctx, "Scheduler died. Error: %v", err)
 			}
 		})
+		crash.Go(func() {
+			for {
+				select {
+				case <-task.ShouldStop(ctx):
+					return
+				case <-time.After(snapshotInterval):
+					if err := owner.Save(ctx, snapshot); err != nil {
+						log.E(ctx, "Saving monitor snapshot failed. Error: %v", err)
+					}
+				}
+			}
+		})
 
 		if v.StartWeb {
 			config := web.Config{
@@ -165,6 +198,7 @@ func (v *masterVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		}
 
 		c := master.NewClient(ctx, managers.Master)
+		c.Devices = devices
 		services := master.ServiceList{
 			Master: true,
 			Worker: v.StartWorkers,
@@ -188,6 +222,13 @@ func (v *masterVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		})
 		return nil
 	})
+	if monitoring {
+		// Save once more on the way out, so that nothing observed since the
+		// last periodic save is lost.
+		if err := owner.Save(ctx, snapshot); err != nil {
+			log.E(ctx, "Saving monitor snapshot failed. Error: %v", err)
+		}
+	}
 	if restart {
 		return app.Restart
 	}
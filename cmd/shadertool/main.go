@@ -17,11 +17,15 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/google/gapid/core/app"
@@ -34,6 +38,7 @@ var (
 	check = flag.Bool("check", true, "Verify that the output compiles")
 	debug = flag.Bool("debug", false, "Make the shader debuggable")
 	asm   = flag.Bool("asm", false, "Print disassembled info")
+	spirv = flag.Bool("spirv", false, "Also write the compiled SPIR-V module (.spv) for each shader")
 )
 
 func main() {
@@ -70,6 +75,13 @@ func run(ctx context.Context) error {
 				return
 			}
 
+			if *spirv {
+				if err := writeSpirv(string(source), input); err != nil {
+					fmt.Printf("%v: %v\n", input, err)
+					return
+				}
+			}
+
 			// Write output
 			if *out == "" {
 				fmt.Print(result)
@@ -88,15 +100,35 @@ func run(ctx context.Context) error {
 	return nil
 }
 
-func convert(source, shaderType string) (result string, err error) {
+// shaderTypes maps the shader file extensions to their shader types.
+var shaderTypes = map[string]shadertools.ShaderType{
+	".vert": shadertools.TypeVertex,
+	".tesc": shadertools.TypeTessControl,
+	".tese": shadertools.TypeTessEvaluation,
+	".geom": shadertools.TypeGeometry,
+	".frag": shadertools.TypeFragment,
+	".comp": shadertools.TypeCompute,
+}
+
+func shaderType(ext string) (shadertools.ShaderType, error) {
+	if ty, ok := shaderTypes[ext]; ok {
+		if !ty.Supported() {
+			return 0, fmt.Errorf("shadertool was built without support for %v shaders (seen %v)", ty, ext)
+		}
+		return ty, nil
+	}
+	exts := make([]string, 0, len(shaderTypes))
+	for ext := range shaderTypes {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return 0, fmt.Errorf("File extension must be one of %v (seen %v)", strings.Join(exts, ", "), ext)
+}
+
+func convert(source, ext string) (result string, err error) {
 	opts := shadertools.ConvertOptions{}
-	switch shaderType {
-	case ".vert":
-		opts.ShaderType = shadertools.TypeVertex
-	case ".frag":
-		opts.ShaderType = shadertools.TypeFragment
-	default:
-		return "", fmt.Errorf("File extension must be .vert or .frag (seen %v)", shaderType)
+	if opts.ShaderType, err = shaderType(ext); err != nil {
+		return "", err
 	}
 	opts.MakeDebuggable = *debug
 	opts.CheckAfterChanges = *check
@@ -112,3 +144,27 @@ func convert(source, shaderType string) (result string, err error) {
 	result += res.SourceCode
 	return result, nil
 }
+
+// writeSpirv compiles the shader source read from input to SPIR-V, and writes
+// the module to a .spv file in the output directory, or next to input if no
+// output directory was given.
+func writeSpirv(source, input string) error {
+	ty, err := shaderType(filepath.Ext(input))
+	if err != nil {
+		return err
+	}
+	words, err := shadertools.CompileGlsl(source, shadertools.CompileOptions{
+		ShaderType: ty,
+		ClientType: shadertools.Vulkan,
+	})
+	if err != nil {
+		return err
+	}
+	output := input + ".spv"
+	if *out != "" {
+		output = filepath.Join(*out, filepath.Base(output))
+	}
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, words)
+	return ioutil.WriteFile(output, buf.Bytes(), 0666)
+}
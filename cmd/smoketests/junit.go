@@ -0,0 +1,94 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// junitReport records the result of each gapit command, so that they can be
+// written as a JUnit XML report. A nil report records nothing.
+type junitReport struct {
+	mutex sync.Mutex
+	cases []junitTestCase
+}
+
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     float64         `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",chardata"`
+}
+
+// add records the gapit command name run on the trace. If the command failed
+// with err, then its output is recorded as the failure.
+func (r *junitReport) add(trace, name string, duration time.Duration, output []byte, err error) {
+	if r == nil {
+		return
+	}
+	c := junitTestCase{
+		ClassName: trace,
+		Name:      name,
+		Time:      duration.Seconds(),
+	}
+	if err != nil {
+		c.Failure = &junitFailure{Message: err.Error(), Output: string(output)}
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cases = append(r.cases, c)
+}
+
+// write writes the recorded results to the file at path.
+func (r *junitReport) write(path string) error {
+	if r == nil {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	suite := junitTestSuite{
+		Name:  "smoketests",
+		Tests: len(r.cases),
+		Cases: r.cases,
+	}
+	for _, c := range r.cases {
+		suite.Time += c.Time
+		if c.Failure != nil {
+			suite.Failures++
+		}
+	}
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append([]byte(xml.Header), data...), 0666)
+}
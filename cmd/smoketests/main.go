@@ -17,7 +17,6 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -26,15 +25,25 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/shell"
+	"github.com/pkg/errors"
 )
 
 var (
-	gapitArg  = flag.String("gapit", "gapit", "Path to gapit executable")
-	keepArg   = flag.Bool("keep", false, "Keep the temporary directory even if no errors are found")
-	tracesArg = flag.String("traces", "traces", "The directory containing traces to run smoke tests on")
+	gapitArg   = flag.String("gapit", "gapit", "Path to gapit executable")
+	jobsArg    = flag.Int("jobs", 1, "Number of gapit commands to run in parallel")
+	junitArg   = flag.String("junit", "", "Path of a JUnit XML report to write the results to")
+	keepArg    = flag.Bool("keep", false, "Keep the temporary directory even if no errors are found")
+	onlyArg    = flag.String("only", "", "Comma-separated list of the gapit subcommands to run, all are run if empty")
+	skipArg    = flag.String("skip", "", "Comma-separated list of the gapit subcommands to skip")
+	tracesArg  = flag.String("traces", "traces", "The directory containing traces to run smoke tests on")
+	timeoutArg = flag.Duration("timeout", 0, "Time after which a gapit command and its children are killed, no limit if 0")
 )
 
 func main() {
@@ -74,6 +83,17 @@ func run(ctx context.Context) error {
 		traceDir = filepath.Join(startwd, traceDir)
 	}
 
+	// Jobs argument
+	if *jobsArg < 1 {
+		return errors.New("Number of jobs must be at least 1")
+	}
+
+	// Subcommand filter arguments
+	filter, err := testFilter(*onlyArg, *skipArg)
+	if err != nil {
+		return err
+	}
+
 	// Gapit path argument
 	gapitPath := *gapitArg
 	// We assume gapitPath is found in PATH environment unless it
@@ -89,10 +109,23 @@ func run(ctx context.Context) error {
 		return err
 	}
 
+	// Run the gapit commands on a pool of workers
+	events := &task.Events{}
+	pool, shutdown := task.Pool(0, *jobsArg)
+	defer shutdown(ctx)
+	executor := task.Batch(pool, events)
+	handles := []task.Handle{}
+
+	// Optional JUnit report
+	var report *junitReport
+	if *junitArg != "" {
+		report = &junitReport{}
+	}
+
 	// For each trace, run gapit tests
 	traces, err := ioutil.ReadDir(traceDir)
 	atLeastOneTraceFound := false
-	nbErr := 0
+	var nbErr uint32
 
 	for _, t := range traces {
 
@@ -113,11 +146,17 @@ func run(ctx context.Context) error {
 		if err := os.Mkdir(tracewd, 0777); err != nil {
 			return err
 		}
-		os.Chdir(tracewd)
-		if err := testTrace(ctx, &nbErr, gapitPath, tracepath); err != nil {
+		handles = append(handles, testTrace(ctx, executor, filter, &nbErr, report, gapitPath, tracewd, tracepath)...)
+	}
+
+	events.Wait(ctx)
+	if err := report.write(*junitArg); err != nil {
+		return err
+	}
+	for _, h := range handles {
+		if err := h.Result(ctx); err != nil {
 			return err
 		}
-		os.Chdir(startwd)
 	}
 
 	if !atLeastOneTraceFound {
@@ -146,12 +185,32 @@ func run(ctx context.Context) error {
 	return nil
 }
 
-func testTrace(ctx context.Context, nbErr *int, gapitPath string, tracepath string) error {
+// testTrace submits the gapit commands to test the trace at tracepath to the
+// executor, and returns their handles. The commands are run in tracewd, and
+// only those whose subcommand passes filter are run.
+func testTrace(ctx context.Context, executor task.Executor, filter func(string) bool, nbErr *uint32, report *junitReport, gapitPath, tracewd, tracepath string) []task.Handle {
+	handles := []task.Handle{}
+	for _, test := range tests(tracepath) {
+		test := test
+		if !filter(test[0]) {
+			continue
+		}
+		handles = append(handles, executor(ctx, func(ctx context.Context) error {
+			return gapit(ctx, nbErr, report, gapitPath, tracewd, test...)
+		}))
+	}
+
+	return handles
+}
+
+// tests returns the arguments of each of the gapit commands to run on the
+// trace at tracepath. The first argument is the gapit subcommand.
+func tests(tracepath string) [][]string {
 
 	// The trace basename is used for some commands argument
 	trace := filepath.Base(tracepath)
 
-	tests := [][]string{
+	return [][]string{
 
 		{"commands", tracepath},
 		{"commands", "-context", "0", tracepath},
@@ -176,17 +235,51 @@ func testTrace(ctx context.Context, nbErr *int, gapitPath string, tracepath stri
 		{"trim", tracepath},
 		{"unpack", tracepath},
 	}
+}
 
-	for _, test := range tests {
-		if err := gapit(ctx, nbErr, gapitPath, test...); err != nil {
-			return err
+// testFilter returns a predicate that returns true for the gapit subcommands
+// to run. If only is not empty, then only the subcommands in the
+// comma-separated list are run. The subcommands in the comma-separated list
+// skip are never run. An error is returned if either list names a
+// subcommand that is not tested.
+func testFilter(only, skip string) (func(string) bool, error) {
+	known := map[string]bool{}
+	names := []string{}
+	for _, test := range tests("") {
+		if !known[test[0]] {
+			known[test[0]] = true
+			names = append(names, test[0])
 		}
 	}
-
-	return nil
+	parse := func(flag, list string) (map[string]bool, error) {
+		out := map[string]bool{}
+		if list == "" {
+			return out, nil
+		}
+		for _, name := range strings.Split(list, ",") {
+			name = strings.TrimSpace(name)
+			if !known[name] {
+				return nil, fmt.Errorf("Unknown gapit subcommand '%s' given to --%s. Expected one of: %s",
+					name, flag, strings.Join(names, ", "))
+			}
+			out[name] = true
+		}
+		return out, nil
+	}
+	onlySet, err := parse("only", only)
+	if err != nil {
+		return nil, err
+	}
+	skipSet, err := parse("skip", skip)
+	if err != nil {
+		return nil, err
+	}
+	return func(name string) bool {
+		return (len(onlySet) == 0 || onlySet[name]) && !skipSet[name]
+	}, nil
 }
 
-func gapit(ctx context.Context, nbErr *int, gapitPath string, args ...string) error {
+func gapit(ctx context.Context, nbErr *uint32, report *junitReport, gapitPath, wd string, args ...string) error {
 	// Print command description
 	arglen := len(args)
 	argsWithoutTrace := args[:arglen-1]
@@ -194,23 +287,36 @@ func gapit(ctx context.Context, nbErr *int, gapitPath string, args ...string) er
 	printCmd := "gapit " + strings.Join(argsWithoutTrace, " ") + " " + trace
 
 	// Execute, check error, print status
-	cmd := exec.Command(gapitPath, args...)
-	output, err := cmd.CombinedOutput()
+	if *timeoutArg > 0 {
+		var cancel task.CancelFunc
+		ctx, cancel = task.WithTimeout(ctx, *timeoutArg)
+		defer cancel()
+	}
+	start := time.Now()
+	out, err := shell.Command(gapitPath, args...).In(wd).Group().Call(ctx)
+	output := []byte(out)
 	if err != nil {
-		if _, ok := err.(*exec.ExitError); ok {
-			// Here the gapit command raised an error
-			fmt.Printf("FAIL %s\n", printCmd)
-			*nbErr += 1
+		cause := errors.Cause(err)
+		if _, ok := cause.(*exec.ExitError); ok || cause == shell.ErrTimeout {
+			// Here the gapit command raised an error or timed out
+			if cause == shell.ErrTimeout {
+				fmt.Printf("FAIL %s (timed out after %v)\n", printCmd, *timeoutArg)
+			} else {
+				fmt.Printf("FAIL %s\n", printCmd)
+			}
+			atomic.AddUint32(nbErr, 1)
+			report.add(trace, printCmd, time.Since(start), output, err)
 		} else {
 			// Here the error comes from somewhere else
 			return err
 		}
 	} else {
 		fmt.Printf("PASS %s\n", printCmd)
+		report.add(trace, printCmd, time.Since(start), output, nil)
 	}
 
 	// Write output in log
-	logFilename := strings.Join(argsWithoutTrace, "_") + ".log"
+	logFilename := filepath.Join(wd, strings.Join(argsWithoutTrace, "_")+".log")
 	if err := ioutil.WriteFile(logFilename, output, 0666); err != nil {
 		return err
 	}
@@ -23,9 +23,11 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/fault"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapis/stringtable"
@@ -44,12 +46,19 @@ const (
 	ErrNoStringtables     = fault.Const("No string table files provided")
 	ErrNoEntry            = fault.Const("No entry provided")
 	ErrParameterList      = fault.Const("Parameter list different")
+	ErrParameterType      = fault.Const("Parameter type different")
+	ErrMissingPluralCase  = fault.Const("Plural is missing a case")
+
+	// watchInterval is the interval between checks for changes to the string
+	// table files in watch mode.
+	watchInterval = 500 * time.Millisecond
 )
 
 var (
 	defGo  = flag.String("def-go", "", "The path to the Go string definition file")
 	defAPI = flag.String("def-api", "", "The path to the API string definition file")
 	pkg    = flag.String("pkg", "", "The directory to hold the output string packages.")
+	watch  = flag.Bool("watch", false, "Keep running, regenerating the outputs whenever a string table file changes.")
 )
 
 func main() {
@@ -73,6 +82,58 @@ func (k tableKey) toProto() *stringtable.Info {
 }
 
 func run(ctx context.Context) error {
+	if !*watch {
+		return generate(ctx)
+	}
+	paths := flag.Args()
+	if len(paths) == 0 {
+		return log.Err(ctx, ErrNoStringtables, "")
+	}
+	for {
+		stamps := timestamps(paths)
+		if err := generate(ctx); err != nil {
+			log.E(ctx, "%v", err)
+		} else {
+			log.I(ctx, "Generated string packages from %d file(s)", len(paths))
+		}
+		if !waitForChange(ctx, paths, stamps) {
+			return nil
+		}
+	}
+}
+
+// timestamps returns the modification times of the files at paths. Files that
+// cannot be read have the zero time.
+func timestamps(paths []string) []time.Time {
+	out := make([]time.Time, len(paths))
+	for i, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			out[i] = info.ModTime()
+		}
+	}
+	return out
+}
+
+// waitForChange blocks until the modification time of one of the files at
+// paths differs from stamps, returning false if ctx is stopped first.
+func waitForChange(ctx context.Context, paths []string, stamps []time.Time) bool {
+	for {
+		select {
+		case <-task.ShouldStop(ctx):
+			return false
+		case <-time.After(watchInterval):
+			for i, t := range timestamps(paths) {
+				if !t.Equal(stamps[i]) {
+					return true
+				}
+			}
+		}
+	}
+}
+
+// generate parses and validates the string table files, and writes the string
+// packages and definition files.
+func generate(ctx context.Context) error {
 	tables := map[tableKey]*tableAndTypeMap{}
 
 	for _, path := range flag.Args() {
@@ -143,7 +204,7 @@ func run(ctx context.Context) error {
 }
 
 // entry is a map of Info -> parameter list
-type entry map[tableKey][]string
+type entry map[tableKey][]EntryParameter
 
 func writePackages(tables map[tableKey]*tableAndTypeMap, path string) error {
 	for info, table := range tables {
@@ -206,11 +267,7 @@ func validate(ctx context.Context, tables map[tableKey]*tableAndTypeMap) error {
 			if e == nil {
 				e = make(entry)
 			}
-			strParams := []string{}
-			for _, p := range params(key, node, table.paramTypeMap) {
-				strParams = append(strParams, p.Identifier)
-			}
-			e[info] = strParams
+			e[info] = params(key, node, table.paramTypeMap)
 			all[key] = e
 		}
 	}
@@ -225,7 +282,7 @@ func validate(ctx context.Context, tables map[tableKey]*tableAndTypeMap) error {
 			}
 		}
 		var validInfo *stringtable.Info
-		var validParams []string
+		var validParams []EntryParameter
 		for info, params := range entry {
 			if info.cultureCode == defaultCultureCode {
 				validInfo, validParams = info.toProto(), params
@@ -236,23 +293,81 @@ func validate(ctx context.Context, tables map[tableKey]*tableAndTypeMap) error {
 			return log.Errf(ctx, ErrNoEntry, "code: %v", defaultCultureCode)
 		}
 		for info, params := range entry {
-			if info.cultureCode != defaultCultureCode &&
-				!reflect.DeepEqual(validParams, params) {
+			if info.cultureCode == defaultCultureCode {
+				continue
+			}
+			if !reflect.DeepEqual(identifiers(validParams), identifiers(params)) {
 				return log.Errf(ctx, ErrParameterList, "first: %v, second: %v", *validInfo, info)
 			}
+			for i, p := range params {
+				if valid := validParams[i]; p.Type != valid.Type {
+					return log.Errf(ctx, ErrParameterType, "parameter: %v, first: %v (%v), second: %v (%v)",
+						p.Identifier, *validInfo, valid.Type, info, p.Type)
+				}
+			}
+		}
+	}
+
+	// Check that each plural has a case for every plural category of the
+	// table's culture.
+	for info, table := range tables {
+		for key, node := range table.stringTable.Entries {
+			for _, p := range plurals(node) {
+				for _, c := range stringtable.PluralCategories(info.cultureCode) {
+					if _, found := p.Cases[c]; !found {
+						return log.Errf(ctx, ErrMissingPluralCase, "key: %v, table: %v, parameter: %v, case: %v",
+							key, info, p.Key, c)
+					}
+				}
+			}
 		}
 	}
 
 	return nil
 }
 
+// identifiers returns the identifiers of the parameters.
+func identifiers(params []EntryParameter) []string {
+	out := make([]string, len(params))
+	for i, p := range params {
+		out[i] = p.Identifier
+	}
+	return out
+}
+
+// plurals returns all the plurals used by the stringtable node.
+func plurals(n *stringtable.Node) []*stringtable.Plural {
+	switch n := n.Node.(type) {
+	case *stringtable.Node_Block:
+		p := []*stringtable.Plural{}
+		for _, n := range n.Block.Children {
+			p = append(p, plurals(n)...)
+		}
+		return p
+	case *stringtable.Node_Plural:
+		p := []*stringtable.Plural{n.Plural}
+		for _, c := range n.Plural.Cases {
+			p = append(p, plurals(c)...)
+		}
+		return p
+	}
+	return nil
+}
+
 // params returns the list of parameters used by the stringtable node.
 func params(entryKey string, n *stringtable.Node, typeMap parser.ParameterTypeMap) []EntryParameter {
 	switch n := n.Node.(type) {
 	case *stringtable.Node_Block:
 		p := []EntryParameter{}
+		seen := map[string]bool{}
 		for _, n := range n.Block.Children {
-			p = append(p, params(entryKey, n, typeMap)...)
+			for _, cp := range params(entryKey, n, typeMap) {
+				// A plural's count may also be used as a parameter.
+				if !seen[cp.Identifier] {
+					seen[cp.Identifier] = true
+					p = append(p, cp)
+				}
+			}
 		}
 		return p
 	case *stringtable.Node_Parameter:
@@ -262,6 +377,31 @@ func params(entryKey string, n *stringtable.Node, typeMap parser.ParameterTypeMa
 				Type:       typeMap[parser.ParameterID{ParameterKey: n.Parameter.Key, EntryKey: entryKey}],
 			},
 		}
+	case *stringtable.Node_Plural:
+		p := []EntryParameter{
+			{
+				Identifier: n.Plural.Key,
+				Type:       typeMap[parser.ParameterID{ParameterKey: n.Plural.Key, EntryKey: entryKey}],
+			},
+		}
+		// Cases may use the same parameters, and each locale may have a
+		// different set of cases, so gather the parameters in a stable order
+		// without duplicates.
+		seen := map[string]bool{n.Plural.Key: true}
+		cases := make([]string, 0, len(n.Plural.Cases))
+		for c := range n.Plural.Cases {
+			cases = append(cases, c)
+		}
+		sort.Strings(cases)
+		for _, c := range cases {
+			for _, cp := range params(entryKey, n.Plural.Cases[c], typeMap) {
+				if !seen[cp.Identifier] {
+					seen[cp.Identifier] = true
+					p = append(p, cp)
+				}
+			}
+		}
+		return p
 	}
 	return nil
 }
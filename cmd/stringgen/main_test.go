@@ -0,0 +1,69 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/stringtable/parser"
+)
+
+func parse(ctx context.Context, cultureCode, source string) *tableAndTypeMap {
+	table, paramTypeMap, errs := parser.Parse(cultureCode+".stb.md", source)
+	assert.For(ctx, "errs").ThatSlice(errs).IsEmpty()
+	return &tableAndTypeMap{stringTable: table, paramTypeMap: paramTypeMap}
+}
+
+func TestValidate(t *testing.T) {
+	ctx := log.Testing(t)
+
+	for _, test := range []struct {
+		name     string
+		en, fr   string
+		expected error
+	}{
+		{
+			"matching",
+			"# MSG\n{{name}} has {{count:u32}} frames",
+			"# MSG\n{{name}} a {{count:u32}} images",
+			nil,
+		}, {
+			"different names",
+			"# MSG\n{{name}} has {{count:u32}} frames",
+			"# MSG\n{{name}} a {{total:u32}} images",
+			ErrParameterList,
+		}, {
+			"different types",
+			"# MSG\n{{name}} has {{count:u32}} frames",
+			"# MSG\n{{name}} a {{count:string}} images",
+			ErrParameterType,
+		},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		tables := map[tableKey]*tableAndTypeMap{
+			{"en-us"}: parse(ctx, "en-us", test.en),
+			{"fr"}:    parse(ctx, "fr", test.fr),
+		}
+		err := validate(ctx, tables)
+		if test.expected == nil {
+			assert.For(ctx, "err").ThatError(err).Succeeded()
+		} else {
+			assert.For(ctx, "err").ThatError(err).HasCause(test.expected)
+		}
+	}
+}
@@ -32,9 +32,14 @@ import (
 var (
 	apiPath   = flag.String("api", "", "Filename of the api file to verify (required)")
 	cacheDir  = flag.String("cache", "", "Directory for caching downloaded files (required)")
+	aliases   = flag.String("param-aliases", "", "Comma separated list of [cmd.]registry=api parameter names that are allowed to differ")
 	apiRoot   *semantic.API
 	mappings  *semantic.Mappings
 	numErrors = 0
+
+	// paramAliases maps a registry parameter name, optionally prefixed with
+	// the command name and a dot, to the name used in the api file.
+	paramAliases = map[string]string{}
 )
 
 func main() {
@@ -49,6 +54,16 @@ func run(ctx context.Context) error {
 	if *cacheDir == "" {
 		app.Usage(ctx, "Must supply cache dir")
 	}
+	for _, alias := range strings.Split(*aliases, ",") {
+		if alias == "" {
+			continue
+		}
+		parts := strings.Split(alias, "=")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			app.Usage(ctx, "Invalid parameter alias %q, expected [cmd.]registry=api", alias)
+		}
+		paramAliases[parts[0]] = parts[1]
+	}
 	processor := gapil.NewProcessor()
 	mappings = processor.Mappings
 	api, errs := processor.Resolve(*apiPath)
@@ -94,7 +109,7 @@ func VerifyEnum(r *Registry, bitfields bool) {
 				if enum.Name == "GL_TIMEOUT_IGNORED" || enum.Name == "GL_TIMEOUT_IGNORED_APPLE" {
 					continue
 				}
-				if enum.API == "" || enum.API == GLES1API || enum.API == GLES2API {
+				if enum.API == "" || enum.API.IsGLES() {
 					var value uint32
 					if v, err := strconv.ParseUint(enum.Value, 0, 32); err == nil {
 						value = uint32(v)
@@ -177,6 +192,23 @@ func VerifyType(cmd string, paramIndex int, expected string, seen semantic.Type)
 	return false
 }
 
+// VerifyParamName checks that the name of the api parameter seen matches the
+// registry parameter name expected, or one of its aliases.
+func VerifyParamName(cmd string, paramIndex int, expected string, seen string) bool {
+	if seen == expected {
+		return true
+	}
+	if alias, ok := paramAliases[cmd+"."+expected]; ok {
+		if seen == alias {
+			return true
+		}
+	} else if alias, ok := paramAliases[expected]; ok && seen == alias {
+		return true
+	}
+	PrintError("%s: Param %v: Expected name %s but seen %s\n", cmd, paramIndex, expected, seen)
+	return false
+}
+
 func UniqueStrings(strs []string) (res []string) {
 	seen := map[string]struct{}{}
 	for _, str := range strs {
@@ -190,9 +222,8 @@ func UniqueStrings(strs []string) (res []string) {
 
 func VerifyCommand(reg *Registry, cmd *Command) {
 	cmdName := cmd.Name()
-	versions := append(reg.GetVersions(GLES1API, cmdName), reg.GetVersions(GLES2API, cmdName)...)
-	extensions := append(reg.GetExtensions(GLES1API, cmdName), reg.GetExtensions(GLES2API, cmdName)...)
-	extensions = UniqueStrings(extensions)
+	versions := reg.GetAllVersions(cmdName)
+	extensions := reg.GetAllExtensions(cmdName)
 	if len(versions) == 0 && len(extensions) == 0 {
 		return // It is not a GLES command.
 	}
@@ -260,11 +291,12 @@ func VerifyCommand(reg *Registry, cmd *Command) {
 	}
 	CompareSets(expected, seen, fmt.Sprintf("%s: ", cmdName))
 
-	// Check parameter types.
+	// Check parameter names and types.
 	if len(cmd.Param) != len(apiCmd.CallParameters()) {
 		PrintError("%s: Expected %v parameters but seen %v\n", cmdName, len(cmd.Param), len(apiCmd.CallParameters()))
 	} else {
 		for i, p := range cmd.Param {
+			VerifyParamName(cmdName, i, p.Name, apiCmd.FullParameters[i].Name())
 			VerifyType(cmdName, i, p.Type(), apiCmd.FullParameters[i].Type)
 		}
 	}
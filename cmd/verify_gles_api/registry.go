@@ -45,6 +45,24 @@ type Version string
 const GLES1API = KhronosAPI("gles1")
 const GLES2API = KhronosAPI("gles2") // Includes GLES 3.0 and later
 
+// GLESAPIs are the Khronos APIs that make up GLES.
+// The registry has no separate API for GLES 3.x, these are features of
+// GLES2API with a version number of 3.0 or later.
+var GLESAPIs = []KhronosAPI{GLES1API, GLES2API}
+
+// GLES2Versions are the versions of GLES2API, in order.
+var GLES2Versions = []Version{"2.0", "3.0", "3.1", "3.2"}
+
+// IsGLES returns true if api is one of the GLES APIs.
+func (api KhronosAPI) IsGLES() bool {
+	for _, a := range GLESAPIs {
+		if a == api {
+			return true
+		}
+	}
+	return false
+}
+
 func (v Version) String() string { return fmt.Sprintf("%s", string(v)) }
 
 type Registry struct {
@@ -192,24 +210,39 @@ func (r *Registry) GetVersions(api KhronosAPI, name string) []Version {
 			}
 		}
 	}
-	if found {
-		switch version {
-		case "1.0":
-			return []Version{"1.0"}
-		case "2.0":
-			return []Version{"2.0", "3.0", "3.1", "3.2"}
-		case "3.0":
-			return []Version{"3.0", "3.1", "3.2"}
-		case "3.1":
-			return []Version{"3.1", "3.2"}
-		case "3.2":
-			return []Version{"3.2"}
-		default:
-			panic(fmt.Errorf("Uknown GLES version: %v", version))
-		}
-	} else {
+	if !found {
 		return nil
 	}
+	if version == "1.0" {
+		return []Version{"1.0"}
+	}
+	// The symbol is supported by all the later versions too.
+	for i, v := range GLES2Versions {
+		if v == version {
+			return GLES2Versions[i:]
+		}
+	}
+	panic(fmt.Errorf("Uknown GLES version: %v", version))
+}
+
+// GetAllVersions returns sorted list of versions of all the GLES APIs which
+// support the given symbol.
+func (r *Registry) GetAllVersions(name string) []Version {
+	var versions []Version
+	for _, api := range GLESAPIs {
+		versions = append(versions, r.GetVersions(api, name)...)
+	}
+	return versions
+}
+
+// GetAllExtensions returns the extensions of all the GLES APIs which define
+// the given symbol, without duplicates.
+func (r *Registry) GetAllExtensions(name string) []string {
+	var extensions []string
+	for _, api := range GLESAPIs {
+		extensions = append(extensions, r.GetExtensions(api, name)...)
+	}
+	return UniqueStrings(extensions)
 }
 
 // GetExtensions returns extensions which define the given symbol.
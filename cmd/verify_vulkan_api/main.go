@@ -0,0 +1,357 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/gapil"
+	"github.com/google/gapid/gapil/semantic"
+)
+
+var (
+	apiPath      = flag.String("api", "", "Filename of the api file to verify (required)")
+	cacheDir     = flag.String("cache", "", "Directory for caching downloaded files (required unless -registry is given)")
+	registryPath = flag.String("registry", "", "Filename of a local vk.xml to verify against, instead of downloading it")
+	apiRoot      *semantic.API
+	numErrors    = 0
+)
+
+func main() {
+	app.ShortHelp = "verify_vulkan_api checks the Vulkan api file against the Khronos registry."
+	app.Run(run)
+}
+
+func run(ctx context.Context) error {
+	if *apiPath == "" {
+		app.Usage(ctx, "Must supply api path")
+	}
+	if *cacheDir == "" && *registryPath == "" {
+		app.Usage(ctx, "Must supply cache dir or registry path")
+	}
+	processor := gapil.NewProcessor()
+	api, errs := processor.Resolve(*apiPath)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			PrintError("%v\n", err.Message)
+		}
+		os.Exit(2)
+	}
+	apiRoot = api
+	reg := LoadRegistry(*registryPath)
+	VerifyApi(reg)
+	if numErrors > 0 {
+		return fmt.Errorf("Found %d differences", numErrors)
+	}
+	return nil
+}
+
+func PrintError(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format, a...)
+	numErrors = numErrors + 1
+}
+
+func VerifyApi(reg *Registry) {
+	extensions := SupportedExtensions()
+	VerifyEnums(reg, extensions)
+	commands := reg.Commands()
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		VerifyCommand(reg, commands[name], extensions)
+	}
+}
+
+// SupportedExtensions returns the extensions declared by the api file, using
+// the VK_*_EXTENSION_NAME definitions.
+func SupportedExtensions() map[string]bool {
+	extensions := map[string]bool{}
+	for _, d := range apiRoot.Definitions {
+		if strings.HasSuffix(d.Name(), "_EXTENSION_NAME") {
+			if name, ok := d.Expression.(semantic.StringValue); ok {
+				extensions[string(name)] = true
+			}
+		}
+	}
+	return extensions
+}
+
+// enumEntry is an enum entry of the registry, with the number of the
+// extension that adds it.
+type enumEntry struct {
+	Enum
+	enums     string
+	extNumber int
+}
+
+func VerifyEnums(r *Registry, extensions map[string]bool) {
+	// Only the enums declared in the api file are verified, so that the
+	// values of the other enums of the registry are not parsed.
+	declared := map[string]bool{}
+	for _, e := range apiRoot.Enums {
+		declared[e.Name()] = true
+	}
+
+	entries := []enumEntry{}
+	for _, enums := range r.Enums {
+		if declared[enums.Name] && (enums.Type == "enum" || enums.Type == "bitmask") {
+			for _, enum := range enums.Enum {
+				entries = append(entries, enumEntry{enum, enums.Name, 0})
+			}
+		}
+	}
+	for _, feature := range r.Feature {
+		if IsVulkan(feature.API) {
+			for _, require := range feature.Require {
+				for _, enum := range require.Enum {
+					if declared[enum.Extends] {
+						entries = append(entries, enumEntry{enum, enum.Extends, 0})
+					}
+				}
+			}
+		}
+	}
+	for _, extension := range r.Extension {
+		if extension.IsSupported() && extensions[extension.Name] {
+			for _, require := range extension.Require {
+				for _, enum := range require.Enum {
+					if declared[enum.Extends] {
+						entries = append(entries, enumEntry{enum, enum.Extends, extension.Number})
+					}
+				}
+			}
+		}
+	}
+
+	// Aliases are allowed in the api file, but are not required.
+	expected := map[string]map[string]struct{}{}
+	optional := map[string]map[string]struct{}{}
+	values := map[string]map[string]uint64{}
+	for _, e := range entries {
+		if e.Alias != "" || !IsVulkan(e.API) {
+			continue
+		}
+		value, ok := e.EnumValue(e.extNumber)
+		if !ok {
+			PrintError("Failed to parse value of enum %v\n", e.Name)
+			continue
+		}
+		if values[e.enums] == nil {
+			values[e.enums] = map[string]uint64{}
+			expected[e.enums] = map[string]struct{}{}
+		}
+		values[e.enums][e.Name] = value
+		expected[e.enums][fmt.Sprintf("%s = 0x%08X", e.Name, value)] = struct{}{}
+	}
+	for _, e := range entries {
+		if e.Alias == "" || !IsVulkan(e.API) {
+			continue
+		}
+		if value, ok := values[e.enums][e.Alias]; ok {
+			if optional[e.enums] == nil {
+				optional[e.enums] = map[string]struct{}{}
+			}
+			optional[e.enums][fmt.Sprintf("%s = 0x%08X", e.Name, value)] = struct{}{}
+		}
+	}
+
+	for _, e := range apiRoot.Enums {
+		name := e.Name()
+		if _, found := expected[name]; !found {
+			continue // Not a Vulkan enum, or an alias of one.
+		}
+		seen := make(map[string]struct{})
+		for _, m := range e.Entries {
+			if value, ok := enumValue(m.Value); ok {
+				seen[fmt.Sprintf("%s = 0x%08X", m.Name(), value)] = struct{}{}
+			} else {
+				PrintError("%s: Unsupported value of %s: %v\n", name, m.Name(), m.Value)
+			}
+		}
+		CompareSets(expected[name], optional[name], seen, name+": ")
+	}
+}
+
+// enumValue returns the value of an api file enum entry. Signed values are
+// returned as their 32-bit two's complement, as for the registry values.
+func enumValue(v semantic.Expression) (uint64, bool) {
+	switch v := v.(type) {
+	case semantic.Int8Value:
+		return uint64(uint32(v)), true
+	case semantic.Uint8Value:
+		return uint64(v), true
+	case semantic.Int16Value:
+		return uint64(uint32(v)), true
+	case semantic.Uint16Value:
+		return uint64(v), true
+	case semantic.Int32Value:
+		return uint64(uint32(v)), true
+	case semantic.Uint32Value:
+		return uint64(v), true
+	case semantic.Int64Value:
+		return uint64(v), true
+	case semantic.Uint64Value:
+		return uint64(v), true
+	}
+	return 0, false
+}
+
+// CompareSets reports the entries of expected that were not seen, and the
+// entries seen that are in neither expected nor optional.
+func CompareSets(expected, optional, seen map[string]struct{}, msg_prefix string) {
+	for k := range expected {
+		if _, found := seen[k]; !found {
+			PrintError("%sMissing %s\n", msg_prefix, k)
+		}
+	}
+	for k := range seen {
+		_, isExpected := expected[k]
+		_, isOptional := optional[k]
+		if !isExpected && !isOptional {
+			PrintError("%sUnexpected %s\n", msg_prefix, k)
+		}
+	}
+}
+
+var builtins = map[string]string{
+	"void":     "void",
+	"char":     "char",
+	"int":      "int",
+	"float":    "f32",
+	"double":   "f64",
+	"int8_t":   "s8",
+	"uint8_t":  "u8",
+	"int16_t":  "s16",
+	"uint16_t": "u16",
+	"int32_t":  "s32",
+	"uint32_t": "u32",
+	"int64_t":  "s64",
+	"uint64_t": "u64",
+	"size_t":   "size",
+}
+
+var re_const_ptr_pre = regexp.MustCompile(`^const (\w+)\s*\*$`)
+var re_const_ptr_post = regexp.MustCompile(`^(.+)\bconst\s*\*$`)
+var re_array = regexp.MustCompile(`^(?:const )?(.+)\[(\w+)\]$`)
+
+func VerifyType(cmd string, paramIndex int, expected string, seen semantic.Type) bool {
+	expected = strings.TrimSpace(expected)
+	name := seen.(semantic.NamedNode).Name()
+	switch s := seen.(type) {
+	case *semantic.Pointer:
+		if s.Const {
+			if match := re_const_ptr_pre.FindStringSubmatch(expected); match != nil {
+				return VerifyType(cmd, paramIndex, match[1], s.To)
+			}
+			if match := re_const_ptr_post.FindStringSubmatch(expected); match != nil {
+				return VerifyType(cmd, paramIndex, match[1], s.To)
+			}
+		} else {
+			if strings.HasSuffix(expected, "*") {
+				return VerifyType(cmd, paramIndex, strings.TrimSuffix(expected, "*"), s.To)
+			}
+		}
+	case *semantic.StaticArray:
+		if match := re_array.FindStringSubmatch(expected); match != nil {
+			// Sizes given by a constant are not checked.
+			if size, err := strconv.ParseUint(match[2], 10, 32); err != nil || uint32(size) == s.Size {
+				return VerifyType(cmd, paramIndex, match[1], s.ValueType)
+			}
+		}
+	case *semantic.Pseudonym:
+		if s.Name() == expected {
+			return true
+		} else {
+			return VerifyType(cmd, paramIndex, expected, s.To)
+		}
+	case *semantic.Class:
+		if s.Name() == expected {
+			return true
+		}
+	case *semantic.Enum:
+		if s.Name() == expected {
+			return true
+		}
+	case *semantic.Builtin:
+		if builtins[expected] == s.Name() {
+			return true
+		} else if expected == "const char*" && s.Name() == "string" {
+			return true
+		}
+	}
+	PrintError("%s: Param %v: Expected type %s but seen %s (%T)\n", cmd, paramIndex, expected, name, seen)
+	return false
+}
+
+func VerifyCommand(reg *Registry, cmd *Command, supported map[string]bool) {
+	cmdName := cmd.Name()
+	version := reg.GetVersion(cmdName)
+	extensions := []string{}
+	for _, extension := range reg.GetExtensions(cmdName) {
+		if supported[extension] {
+			extensions = append(extensions, extension)
+		}
+	}
+	if version == "" && len(extensions) == 0 {
+		return // It is not a core command, or a command of a supported extension.
+	}
+
+	// Find existing API function.
+	var apiCmd *semantic.Function
+	for _, apiFunction := range apiRoot.Functions {
+		if apiFunction.Name() == cmdName {
+			apiCmd = apiFunction
+		}
+	}
+
+	params := cmd.Params()
+
+	// Print command to stdout if it is missing.
+	if apiCmd == nil {
+		annots := []string{}
+		for _, extension := range extensions {
+			annots = append(annots, fmt.Sprintf(`@extension("%s")`, extension))
+		}
+		decls := []string{}
+		for _, param := range params {
+			decls = append(decls, param.Type()+" "+param.Name)
+		}
+		PrintError("%s: Missing command\n", cmdName)
+		fmt.Printf("%s\ncmd %s %s(%s) { }\n", strings.Join(annots, "\n"),
+			cmd.Proto.Type(), cmdName, strings.Join(decls, ", "))
+		return
+	}
+
+	// Check return and parameter types.
+	VerifyType(cmdName, -1, cmd.Proto.Type(), apiCmd.Return.Type)
+	if len(params) != len(apiCmd.CallParameters()) {
+		PrintError("%s: Expected %v parameters but seen %v\n", cmdName, len(params), len(apiCmd.CallParameters()))
+	} else {
+		for i, p := range params {
+			VerifyType(cmdName, i, p.Type(), apiCmd.FullParameters[i].Type)
+		}
+	}
+}
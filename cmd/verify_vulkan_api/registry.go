@@ -0,0 +1,307 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const registry_url = "https://raw.githubusercontent.com/KhronosGroup/Vulkan-Docs/main/xml/vk.xml"
+
+// extensionEnumBase is the value of the first enum added by an extension.
+const extensionEnumBase = 1000000000
+
+// extensionEnumBlockSize is the number of enum values reserved for each
+// extension.
+const extensionEnumBlockSize = 1000
+
+// LoadRegistry reads the Khronos XML registry file from path, or downloads it
+// if path is empty.
+func LoadRegistry(path string) *Registry {
+	var bytes []byte
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		bytes = data
+	} else {
+		bytes = Download(registry_url)
+	}
+	if len(bytes) == 0 {
+		panic(fmt.Errorf("Can not download %s", registry_url))
+	}
+	reg := &Registry{}
+	if err := xml.Unmarshal(bytes, reg); err != nil {
+		panic(err.Error())
+	}
+	return reg
+}
+
+type Registry struct {
+	Enums     []*Enums            `xml:"enums"`
+	Command   []*Command          `xml:"commands>command"`
+	Feature   []*Feature          `xml:"feature"`
+	Extension []*ExtensionElement `xml:"extensions>extension"`
+}
+
+type NamedElementList []NamedElement
+type NamedElement struct {
+	Name string `xml:"name,attr"`
+}
+
+type Enums struct {
+	NamedElement
+	Type string `xml:"type,attr"` // "enum" or "bitmask"
+	Enum []Enum `xml:"enum"`
+}
+
+type Enum struct {
+	NamedElement
+	Value     string `xml:"value,attr"`
+	BitPos    string `xml:"bitpos,attr"`
+	Alias     string `xml:"alias,attr"`
+	API       string `xml:"api,attr"`
+	Extends   string `xml:"extends,attr"`   // for features and extensions only
+	ExtNumber string `xml:"extnumber,attr"` // for features and extensions only
+	Offset    string `xml:"offset,attr"`    // for features and extensions only
+	Dir       string `xml:"dir,attr"`       // for features and extensions only
+}
+
+type Command struct {
+	AliasName string         `xml:"name,attr"` // for aliases only
+	Alias     string         `xml:"alias,attr"`
+	API       string         `xml:"api,attr"`
+	Proto     ProtoOrParam   `xml:"proto"`
+	Param     []ProtoOrParam `xml:"param"`
+}
+
+type ProtoOrParam struct {
+	InnerXML string `xml:",innerxml"`
+	API      string `xml:"api,attr"`
+	Name     string `xml:"name"`
+}
+
+type Feature struct {
+	NamedElement
+	API     string      `xml:"api,attr"`
+	Number  string      `xml:"number,attr"`
+	Require RequireList `xml:"require"`
+}
+
+type ExtensionElement struct {
+	NamedElement
+	Number    int         `xml:"number,attr"`
+	Supported string      `xml:"supported,attr"`
+	Require   RequireList `xml:"require"`
+}
+
+type RequireList []Require
+type Require struct {
+	Enum    []Enum           `xml:"enum"`
+	Command NamedElementList `xml:"command"`
+}
+
+// IsVulkan returns true if the comma separated list of APIs includes Vulkan.
+// An empty list applies to all APIs.
+func IsVulkan(apis string) bool {
+	if apis == "" {
+		return true
+	}
+	for _, api := range strings.Split(apis, ",") {
+		if api == "vulkan" {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *ExtensionElement) IsSupported() bool {
+	for _, v := range strings.Split(e.Supported, ",") {
+		if v == "vulkan" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Command) Name() string {
+	if c.Alias != "" {
+		return c.AliasName
+	}
+	return c.Proto.Name
+}
+
+var re_tags = regexp.MustCompile(`</?(type|enum)>`)
+
+func (p ProtoOrParam) Type() string {
+	name := p.InnerXML
+	start := strings.Index(name, "<name>")
+	end := strings.Index(name, "</name>") + len("</name>")
+	// Array sizes follow the name.
+	name = name[:start] + name[end:]
+	name = re_tags.ReplaceAllString(name, "")
+	name = strings.TrimSpace(name)
+	return name
+}
+
+// Params returns the parameters of the command for the Vulkan API.
+func (c Command) Params() []ProtoOrParam {
+	params := []ProtoOrParam{}
+	for _, p := range c.Param {
+		if IsVulkan(p.API) {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// Commands returns all the Vulkan commands by name, with aliases resolved.
+func (r *Registry) Commands() map[string]*Command {
+	commands := map[string]*Command{}
+	for _, cmd := range r.Command {
+		if cmd.Alias == "" && IsVulkan(cmd.API) {
+			commands[cmd.Name()] = cmd
+		}
+	}
+	for _, cmd := range r.Command {
+		if cmd.Alias != "" {
+			if target, ok := commands[cmd.Alias]; ok {
+				alias := *target
+				alias.Proto.Name = cmd.AliasName
+				commands[cmd.AliasName] = &alias
+			}
+		}
+	}
+	return commands
+}
+
+// GetVersion returns the Vulkan version which requires the given command, or
+// an empty string if it is not a core command.
+func (r *Registry) GetVersion(name string) string {
+	for _, feature := range r.Feature {
+		if IsVulkan(feature.API) && feature.Require.ContainsCommand(name) {
+			return feature.Number
+		}
+	}
+	return ""
+}
+
+// GetExtensions returns the supported extensions which require the given
+// command.
+func (r *Registry) GetExtensions(name string) []string {
+	var extensions []string
+	for _, extension := range r.Extension {
+		if extension.IsSupported() && extension.Require.ContainsCommand(name) {
+			extensions = append(extensions, extension.Name)
+		}
+	}
+	return extensions
+}
+
+func (l NamedElementList) Contains(name string) bool {
+	for _, v := range l {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (l RequireList) ContainsCommand(name string) bool {
+	for _, v := range l {
+		if v.Command.Contains(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnumValue returns the value of the enum, which is added by the extension
+// with the given number if it has an offset. Negative values are returned as
+// their 32-bit two's complement, as the api file declares them.
+func (e Enum) EnumValue(extNumber int) (uint64, bool) {
+	switch {
+	case e.Value != "":
+		if v, err := strconv.ParseUint(e.Value, 0, 64); err == nil {
+			return v, true
+		} else if v, err := strconv.ParseInt(e.Value, 0, 32); err == nil {
+			return uint64(uint32(v)), true
+		}
+	case e.BitPos != "":
+		if v, err := strconv.ParseUint(e.BitPos, 0, 6); err == nil {
+			return 1 << v, true
+		}
+	case e.Offset != "":
+		if e.ExtNumber != "" {
+			n, err := strconv.Atoi(e.ExtNumber)
+			if err != nil {
+				return 0, false
+			}
+			extNumber = n
+		}
+		offset, err := strconv.Atoi(e.Offset)
+		if err != nil {
+			return 0, false
+		}
+		v := int32(extensionEnumBase + (extNumber-1)*extensionEnumBlockSize + offset)
+		if e.Dir == "-" {
+			v = -v
+		}
+		return uint64(uint32(v)), true
+	}
+	return 0, false
+}
+
+// Download the given URL.  Returns empty slice if the page can not be found (404).
+func Download(url string) []byte {
+	filename := url
+	filename = strings.TrimPrefix(filename, "https://")
+	filename = strings.Replace(filename, "/", "-", strings.Count(filename, "/")-1)
+	filename = strings.Replace(filename, "/", string(os.PathSeparator), 1)
+	filename = *cacheDir + string(os.PathSeparator) + filename
+	if bytes, err := ioutil.ReadFile(filename); err == nil {
+		return bytes
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		panic(err)
+	}
+	bytes := []byte{}
+	if resp.StatusCode == 200 {
+		bytes, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			panic(err)
+		}
+	} else if resp.StatusCode != 404 {
+		panic(fmt.Errorf("%s: %s", url, resp.Status))
+	}
+	resp.Body.Close()
+	dir := filename[0:strings.LastIndex(filename, string(os.PathSeparator))]
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(filename, bytes, 0666); err != nil {
+		panic(err)
+	}
+	return bytes
+}
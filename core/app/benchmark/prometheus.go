@@ -0,0 +1,72 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus writes all the counters to w in the Prometheus text
+// exposition format.
+//
+// Counter names are converted to valid metric names by replacing every
+// character other than letters, digits, underscores and colons with an
+// underscore. IntegerCounters are written as gauges, as they may be set to any
+// value. DurationCounters are written as counters in seconds, with a _seconds
+// suffix.
+func (m *Counters) WritePrometheus(w io.Writer) error {
+	all := m.All()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := bufio.NewWriter(w)
+	for _, name := range names {
+		metric := prometheusName(name)
+		switch c := all[name].(type) {
+		case *IntegerCounter:
+			fmt.Fprintf(buf, "# TYPE %s gauge\n%s %d\n", metric, metric, c.Get())
+		case *DurationCounter:
+			metric += "_seconds"
+			seconds := strconv.FormatFloat(c.Get().Seconds(), 'g', -1, 64)
+			fmt.Fprintf(buf, "# TYPE %s counter\n%s %s\n", metric, metric, seconds)
+		}
+	}
+	return buf.Flush()
+}
+
+// prometheusName returns name with all the characters that are not valid in a
+// Prometheus metric name replaced with underscores.
+func prometheusName(name string) string {
+	out := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == ':':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if out == "" || (out[0] >= '0' && out[0] <= '9') {
+		out = "_" + out
+	}
+	return out
+}
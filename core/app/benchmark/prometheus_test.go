@@ -0,0 +1,43 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/app/benchmark"
+	"github.com/google/gapid/core/assert"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	assert := assert.To(t)
+
+	m := benchmark.NewCounters()
+	m.Integer("replay.active").Set(3)
+	m.Integer("9lives").Add(9)
+	m.Duration("replay.execute").Add(1500 * time.Millisecond)
+
+	buf := &bytes.Buffer{}
+	assert.For("err").ThatError(m.WritePrometheus(buf)).Succeeded()
+	assert.For("out").ThatString(buf.String()).Equals(
+		"# TYPE _9lives gauge\n" +
+			"_9lives 9\n" +
+			"# TYPE replay_active gauge\n" +
+			"replay_active 3\n" +
+			"# TYPE replay_execute_seconds counter\n" +
+			"replay_execute_seconds 1.5\n")
+}
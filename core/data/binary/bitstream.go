@@ -14,7 +14,11 @@
 
 package binary
 
-import "github.com/google/gapid/core/math/u32"
+import (
+	"fmt"
+
+	"github.com/google/gapid/core/math/u32"
+)
 
 // BitStream provides methods for reading and writing bits to a slice of bytes.
 // Bits are packed in a least-significant-bit to most-significant-bit order.
@@ -128,3 +132,32 @@ func (s *BitStream) Write(bits uint64, count uint32) {
 		s.WritePos += count
 	}
 }
+
+// ReadSigned reads the specified number of bits from the BitStream as a two's
+// complement signed integer, incrementing the ReadPos by the specified number
+// of bits and returning the sign-extended value.
+func (s *BitStream) ReadSigned(count uint32) int64 {
+	if count == 0 {
+		return 0
+	}
+	shift := 64 - count
+	return int64(s.Read(count)<<shift) >> shift
+}
+
+// WriteSigned writes val as a two's complement signed integer of the specified
+// number of bits, incrementing the WritePos by the specified number of bits.
+// WriteSigned returns an error and writes nothing if val cannot be represented
+// with count bits.
+func (s *BitStream) WriteSigned(val int64, count uint32) error {
+	if count < 64 {
+		min, max := int64(-1)<<count>>1, int64(1)<<count>>1-1
+		if count == 0 {
+			min, max = 0, 0
+		}
+		if val < min || val > max {
+			return fmt.Errorf("Value %d does not fit in a %d-bit signed integer", val, count)
+		}
+	}
+	s.Write(uint64(val), count)
+	return nil
+}
@@ -15,6 +15,7 @@
 package binary_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/google/gapid/core/assert"
@@ -178,3 +179,56 @@ func BenchmarkBitstreamWrite(b *testing.B) {
 		bs.Write(uint64(i), uint32(i&31))
 	}
 }
+
+func TestBitStreamSigned(t *testing.T) {
+	assert := assert.To(t)
+	for _, test := range []struct {
+		val   int64
+		count uint32
+	}{
+		{0, 1},
+		{-1, 1},
+		{511, 10},
+		{-512, 10},
+		{-1, 10},
+		{1, 2},
+		{-2, 2},
+		{0x7fffffff, 32},
+		{-0x80000000, 32},
+		{math.MaxInt64, 64},
+		{math.MinInt64, 64},
+	} {
+		bs := binary.BitStream{}
+		bs.Write(0x5, 3) // Misalign the value.
+		err := bs.WriteSigned(test.val, test.count)
+		assert.For("write %d:%d", test.val, test.count).ThatError(err).Succeeded()
+		bs.Write(0x5, 3)
+		assert.For("read %d:%d", test.val, test.count).That(bs.Read(3)).Equals(uint64(0x5))
+		assert.For("read %d:%d", test.val, test.count).That(bs.ReadSigned(test.count)).Equals(test.val)
+		assert.For("read %d:%d", test.val, test.count).That(bs.Read(3)).Equals(uint64(0x5))
+	}
+
+	// 10_10_10_2 packed -1, 511, -512, 1.
+	bs := binary.BitStream{Data: []byte{0xff, 0xff, 0x07, 0x60}}
+	assert.For("x").That(bs.ReadSigned(10)).Equals(int64(-1))
+	assert.For("y").That(bs.ReadSigned(10)).Equals(int64(511))
+	assert.For("z").That(bs.ReadSigned(10)).Equals(int64(-512))
+	assert.For("w").That(bs.ReadSigned(2)).Equals(int64(1))
+
+	for _, test := range []struct {
+		val   int64
+		count uint32
+	}{
+		{1, 0},
+		{1, 1},
+		{-2, 1},
+		{512, 10},
+		{-513, 10},
+		{0x80000000, 32},
+	} {
+		bs := binary.BitStream{}
+		err := bs.WriteSigned(test.val, test.count)
+		assert.For("write %d:%d", test.val, test.count).ThatError(err).Failed()
+		assert.For("pos %d:%d", test.val, test.count).That(bs.WritePos).Equals(uint32(0))
+	}
+}
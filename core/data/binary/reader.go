@@ -52,8 +52,20 @@ type Reader interface {
 	Float64() float64
 	// String decodes and returns a string from the Reader.
 	String() string
+	// ReadStringLP decodes and returns a string prefixed with its unsigned,
+	// 32 bit length from the Reader.
+	ReadStringLP() string
+	// ReadUint32s decodes unsigned, 32 bit integer values from the Reader to
+	// fill the slice.
+	ReadUint32s([]uint32)
+	// ReadFloat32s decodes 32 bit floating-point values from the Reader to fill
+	// the slice.
+	ReadFloat32s([]float32)
 	// Decode a collection count from the stream.
 	Count() uint32
+	// Align skips the bytes up to the next multiple of n bytes from the start
+	// of the Reader.
+	Align(n int)
 	// If there is an error reading any input, all further reading returns the
 	// zero value of the type read. Error() returns the error which stopped
 	// reading from the stream. If reading has not stopped it returns nil.
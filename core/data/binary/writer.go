@@ -51,6 +51,18 @@ type Writer interface {
 	Float64(float64)
 	// String encodes a string to the Writer.
 	String(string)
+	// WriteStringLP encodes a string prefixed with its unsigned, 32 bit length
+	// to the Writer.
+	WriteStringLP(string)
+	// WriteUint32s encodes all the unsigned, 32 bit integer values of the
+	// slice to the Writer.
+	WriteUint32s([]uint32)
+	// WriteFloat32s encodes all the 32 bit floating-point values of the slice
+	// to the Writer.
+	WriteFloat32s([]float32)
+	// Align writes zero bytes up to the next multiple of n bytes from the
+	// start of the Writer.
+	Align(n int)
 	// If there is an error writing any output, all further writing becomes
 	// a no-op. Error() returns the error which stopped writing to the stream.
 	// If writing has not stopped it returns nil.
@@ -19,8 +19,11 @@
 // zero represents true.
 //
 // Numeric types are all encoded as the simple native representation, but no
-// attempt is made to align them.
+// attempt is made to align them. Align can be called before a value to pad it
+// to its natural alignment.
 //
-// Strings are encoded in C style null terminated form.
+// Strings are encoded in C style null terminated form. ReadStringLP and
+// WriteStringLP instead encode strings as an unsigned, 32 bit length followed
+// by the string bytes, which preserves any embedded null characters.
 //
 package endian
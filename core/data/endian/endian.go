@@ -15,9 +15,11 @@
 package endian
 
 import (
+	"bytes"
 	eb "encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 
 	"github.com/google/gapid/core/data/binary"
@@ -39,29 +41,86 @@ func byteOrder(endian device.Endian) eb.ByteOrder {
 // Reader creates a binary.Reader that reads from the provided io.Reader, with
 // the specified byte order.
 func Reader(r io.Reader, endian device.Endian) binary.Reader {
-	return &reader{reader: r, byteOrder: byteOrder(endian)}
+	c := &readCounter{reader: r}
+	return &reader{reader: c, counter: c, byteOrder: byteOrder(endian)}
 }
 
 // Writer creates a binary.Writer that writes to the supplied stream, with the
 // specified byte order.
 func Writer(w io.Writer, endian device.Endian) binary.Writer {
-	return &writer{writer: w, byteOrder: byteOrder(endian)}
+	c := &writeCounter{writer: w}
+	return &writer{writer: c, counter: c, byteOrder: byteOrder(endian)}
 }
 
+// batchSize is the maximum number of bytes read or written at a time by the
+// slice methods.
+const batchSize = 64 << 10
+
 type reader struct {
 	reader    io.Reader
+	counter   *readCounter
 	tmp       [8]byte
+	scratch   []byte
 	byteOrder eb.ByteOrder
 	err       error
 }
 
 type writer struct {
 	writer    io.Writer
+	counter   *writeCounter
 	tmp       [8]byte
+	scratch   []byte
 	byteOrder eb.ByteOrder
 	err       error
 }
 
+// readCounter is an io.Reader that counts the bytes read, so that the reader
+// can align to offsets from its start.
+type readCounter struct {
+	reader io.Reader
+	count  uint64
+}
+
+func (c *readCounter) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.count += uint64(n)
+	return n, err
+}
+
+// writeCounter is an io.Writer that counts the bytes written, so that the
+// writer can align to offsets from its start.
+type writeCounter struct {
+	writer io.Writer
+	count  uint64
+}
+
+func (c *writeCounter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.count += uint64(n)
+	return n, err
+}
+
+// padding returns the number of bytes from offset to the next multiple of n.
+func padding(offset uint64, n int) (uint64, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("Invalid alignment %d", n)
+	}
+	return (uint64(n) - offset%uint64(n)) % uint64(n), nil
+}
+
+// batch returns the number of elements of elSize bytes to process in one go
+// out of count, and a scratch buffer large enough to hold them.
+func batch(scratch *[]byte, count, elSize int) (int, []byte) {
+	if max := batchSize / elSize; count > max {
+		count = max
+	}
+	size := count * elSize
+	if cap(*scratch) < size {
+		*scratch = make([]byte, size)
+	}
+	return count, (*scratch)[:size]
+}
+
 func (r *reader) Read(p []byte) (n int, err error) {
 	return r.reader.Read(p)
 }
@@ -259,6 +318,54 @@ func (w *writer) Float64(v float64) {
 	_, w.err = w.writer.Write(w.tmp[:8])
 }
 
+func (r *reader) ReadUint32s(p []uint32) {
+	for len(p) > 0 && r.err == nil {
+		n, buf := batch(&r.scratch, len(p), 4)
+		if _, r.err = io.ReadFull(r.reader, buf); r.err != nil {
+			return
+		}
+		for i := range p[:n] {
+			p[i] = r.byteOrder.Uint32(buf[i*4:])
+		}
+		p = p[n:]
+	}
+}
+
+func (w *writer) WriteUint32s(v []uint32) {
+	for len(v) > 0 && w.err == nil {
+		n, buf := batch(&w.scratch, len(v), 4)
+		for i, x := range v[:n] {
+			w.byteOrder.PutUint32(buf[i*4:], x)
+		}
+		w.Data(buf)
+		v = v[n:]
+	}
+}
+
+func (r *reader) ReadFloat32s(p []float32) {
+	for len(p) > 0 && r.err == nil {
+		n, buf := batch(&r.scratch, len(p), 4)
+		if _, r.err = io.ReadFull(r.reader, buf); r.err != nil {
+			return
+		}
+		for i := range p[:n] {
+			p[i] = math.Float32frombits(r.byteOrder.Uint32(buf[i*4:]))
+		}
+		p = p[n:]
+	}
+}
+
+func (w *writer) WriteFloat32s(v []float32) {
+	for len(v) > 0 && w.err == nil {
+		n, buf := batch(&w.scratch, len(v), 4)
+		for i, x := range v[:n] {
+			w.byteOrder.PutUint32(buf[i*4:], math.Float32bits(x))
+		}
+		w.Data(buf)
+		v = v[n:]
+	}
+}
+
 func (r *reader) String() string {
 	s := []byte{}
 	for {
@@ -279,10 +386,75 @@ func (w *writer) String(v string) {
 	w.Uint8(0)
 }
 
+func (r *reader) ReadStringLP() string {
+	n := r.Uint32()
+	if r.err != nil {
+		return ""
+	}
+	// Grow the buffer as data arrives rather than trusting the length up front.
+	buf := bytes.Buffer{}
+	if _, err := io.CopyN(&buf, r.reader, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		r.err = err
+		return ""
+	}
+	return buf.String()
+}
+
+func (w *writer) WriteStringLP(v string) {
+	if w.err != nil {
+		return
+	}
+	if uint64(len(v)) > math.MaxUint32 {
+		w.err = fmt.Errorf("String of length %d is too long to encode", len(v))
+		return
+	}
+	w.Uint32(uint32(len(v)))
+	w.Data([]byte(v))
+}
+
 func (r *reader) Count() uint32 {
 	return r.Uint32()
 }
 
+func (r *reader) Align(n int) {
+	if r.err != nil {
+		return
+	}
+	pad, err := padding(r.counter.count, n)
+	if err != nil {
+		r.err = err
+		return
+	}
+	if _, err := io.CopyN(ioutil.Discard, r.reader, int64(pad)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		r.err = err
+	}
+}
+
+func (w *writer) Align(n int) {
+	if w.err != nil {
+		return
+	}
+	pad, err := padding(w.counter.count, n)
+	if err != nil {
+		w.err = err
+		return
+	}
+	for pad > 0 && w.err == nil {
+		_, buf := batch(&w.scratch, int(pad), 1)
+		for i := range buf {
+			buf[i] = 0
+		}
+		w.Data(buf)
+		pad -= uint64(len(buf))
+	}
+}
+
 func (w *writer) Error() error {
 	return w.err
 }
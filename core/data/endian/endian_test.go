@@ -286,3 +286,170 @@ func (d *limitedWriter) Write(b []byte) (int, error) {
 	}
 	return len(b), nil
 }
+
+func TestSlices(t *testing.T) {
+	ctx := log.Testing(t)
+	u32s := []uint32{0, 0x01234567, 0x10abcdef}
+	f32s := []float32{0, 1, 64.5}
+	raw := []byte{
+		0x00, 0x00, 0x00, 0x00,
+		0x67, 0x45, 0x23, 0x01,
+		0xef, 0xcd, 0xab, 0x10,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x80, 0x3f,
+		0x00, 0x00, 0x81, 0x42,
+	}
+
+	b := &bytes.Buffer{}
+	reader, writer := factory(b, b)
+	writer.WriteUint32s(u32s)
+	writer.WriteFloat32s(f32s)
+	assert.For(ctx, "err").ThatError(writer.Error()).Succeeded()
+	assert.For(ctx, "bytes").ThatSlice(b.Bytes()).Equals(raw)
+
+	gotU32s, gotF32s := make([]uint32, len(u32s)), make([]float32, len(f32s))
+	reader.ReadUint32s(gotU32s)
+	reader.ReadFloat32s(gotF32s)
+	assert.For(ctx, "err").ThatError(reader.Error()).Succeeded()
+	assert.For(ctx, "uint32s").ThatSlice(gotU32s).Equals(u32s)
+	assert.For(ctx, "float32s").ThatSlice(gotF32s).Equals(f32s)
+
+	// Slices larger than a single batch.
+	large := make([]uint32, 100000)
+	for i := range large {
+		large[i] = uint32(i * 7)
+	}
+	b.Reset()
+	writer.WriteUint32s(large)
+	assert.For(ctx, "large bytes").ThatInteger(b.Len()).Equals(len(large) * 4)
+	got := make([]uint32, len(large))
+	reader.ReadUint32s(got)
+	assert.For(ctx, "large err").ThatError(reader.Error()).Succeeded()
+	assert.For(ctx, "large uint32s").ThatSlice(got).Equals(large)
+
+	// Reading past the end of the data.
+	reader.ReadFloat32s(gotF32s)
+	assert.For(ctx, "eof").ThatError(reader.Error()).Equals(io.EOF)
+}
+
+const benchmarkFloatCount = 100000
+
+func BenchmarkReadFloat32Scalars(b *testing.B) {
+	data := make([]byte, benchmarkFloatCount*4)
+	out := make([]float32, benchmarkFloatCount)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		r := endian.Reader(bytes.NewReader(data), device.LittleEndian)
+		for j := range out {
+			out[j] = r.Float32()
+		}
+	}
+}
+
+func BenchmarkReadFloat32Slice(b *testing.B) {
+	data := make([]byte, benchmarkFloatCount*4)
+	out := make([]float32, benchmarkFloatCount)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		r := endian.Reader(bytes.NewReader(data), device.LittleEndian)
+		r.ReadFloat32s(out)
+	}
+}
+
+func BenchmarkWriteFloat32Scalars(b *testing.B) {
+	in := make([]float32, benchmarkFloatCount)
+	buf := bytes.NewBuffer(make([]byte, 0, benchmarkFloatCount*4))
+	b.SetBytes(benchmarkFloatCount * 4)
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		w := endian.Writer(buf, device.LittleEndian)
+		for _, v := range in {
+			w.Float32(v)
+		}
+	}
+}
+
+func BenchmarkWriteFloat32Slice(b *testing.B) {
+	in := make([]float32, benchmarkFloatCount)
+	buf := bytes.NewBuffer(make([]byte, 0, benchmarkFloatCount*4))
+	b.SetBytes(benchmarkFloatCount * 4)
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		w := endian.Writer(buf, device.LittleEndian)
+		w.WriteFloat32s(in)
+	}
+}
+
+func TestStringLP(t *testing.T) {
+	ctx := log.Testing(t)
+	values := []string{"Hello", "", "nul\x00inside"}
+	raw := []byte{
+		0x05, 0x00, 0x00, 0x00, 'H', 'e', 'l', 'l', 'o',
+		0x00, 0x00, 0x00, 0x00,
+		0x0a, 0x00, 0x00, 0x00, 'n', 'u', 'l', 0x00, 'i', 'n', 's', 'i', 'd', 'e',
+	}
+
+	b := &bytes.Buffer{}
+	reader, writer := factory(b, b)
+	for _, v := range values {
+		writer.WriteStringLP(v)
+	}
+	assert.For(ctx, "err").ThatError(writer.Error()).Succeeded()
+	assert.For(ctx, "bytes").ThatSlice(b.Bytes()).Equals(raw)
+	for i, expect := range values {
+		got := reader.ReadStringLP()
+		assert.For(ctx, "err at %v", i).ThatError(reader.Error()).Succeeded()
+		assert.For(ctx, "string at %v", i).ThatString(got).Equals(expect)
+	}
+
+	// Length prefix claims more data than is available.
+	reader, _ = factory(bytes.NewBuffer([]byte{0xff, 0x00, 0x00, 0x00, 'a'}), b)
+	reader.ReadStringLP()
+	assert.For(ctx, "truncated").ThatError(reader.Error()).Equals(io.ErrUnexpectedEOF)
+}
+
+func TestAlign(t *testing.T) {
+	ctx := log.Testing(t)
+	raw := []byte{
+		0x01, 0x00, 0x00, 0x00,
+		0x02, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x04,
+	}
+
+	b := &bytes.Buffer{}
+	reader, writer := factory(b, b)
+	writer.Align(4) // Already aligned.
+	writer.Uint8(1)
+	writer.Align(4)
+	writer.Uint16(2)
+	writer.Align(8)
+	writer.Align(16)
+	writer.Uint64(3)
+	writer.Align(1)
+	writer.Uint8(4)
+	assert.For(ctx, "err").ThatError(writer.Error()).Succeeded()
+	assert.For(ctx, "bytes").ThatSlice(b.Bytes()).Equals(raw)
+
+	reader.Align(4)
+	assert.For(ctx, "1").That(reader.Uint8()).Equals(uint8(1))
+	reader.Align(4)
+	assert.For(ctx, "2").That(reader.Uint16()).Equals(uint16(2))
+	reader.Align(8)
+	reader.Align(16)
+	assert.For(ctx, "3").That(reader.Uint64()).Equals(uint64(3))
+	reader.Align(1)
+	assert.For(ctx, "4").That(reader.Uint8()).Equals(uint8(4))
+	assert.For(ctx, "err").ThatError(reader.Error()).Succeeded()
+
+	// The padding is missing from the data.
+	reader, _ = factory(bytes.NewBuffer([]byte{0x01, 0x00}), b)
+	reader.Uint8()
+	reader.Align(4)
+	assert.For(ctx, "truncated").ThatError(reader.Error()).Equals(io.ErrUnexpectedEOF)
+
+	_, writer = factory(b, &bytes.Buffer{})
+	writer.Align(0)
+	assert.For(ctx, "invalid").ThatError(writer.Error()).Failed()
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pack
+
+import (
+	"bufio"
+	"context"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/math/sint"
+)
+
+// OpenForAppend constructs and returns a new Writer that appends to the pack
+// file in f, which must have been written by a Writer constructed with
+// NewWriter. The existing header is validated, and the existing chunks are
+// scanned so that the type definitions already in the file are not written
+// again, and so that identifiers of groups already in the file can be used as
+// parents of the appended objects.
+// Compressed and indexed files, and files that end with a partially written
+// chunk cannot be appended to, and ErrCannotAppend is returned.
+func OpenForAppend(ctx context.Context, f io.ReadWriteSeeker) (*Writer, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	from := bufio.NewReaderSize(f, initalBufferSize)
+
+	buf := make([]byte, maxHeaderSize)
+	if _, err := io.ReadFull(from, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrIncorrectMagic
+		}
+		return nil, err
+	}
+	version, err := parseVersion(buf)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	if version.Major == compressedMajorVersion {
+		return nil, log.Err(ctx, ErrCannotAppend, "File is compressed")
+	}
+
+	w := &Writer{
+		types:   newTypes(false),
+		buf:     proto.NewBuffer(make([]byte, 0, initalBufferSize)),
+		sizebuf: proto.NewBuffer(make([]byte, 0, maxVarintSize)),
+		to:      f,
+		offset:  int64(maxHeaderSize),
+	}
+	for {
+		size, n, err := readChunkSize(from)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, log.Err(ctx, ErrCannotAppend, "File ends with a partial chunk")
+		}
+		if size == 0 {
+			// A zero sized chunk is only written before an index.
+			return nil, log.Err(ctx, ErrCannotAppend, "File is indexed")
+		}
+		chunkSize := sint.Abs(int(size))
+		if size > 0 {
+			// Objects are skipped without being decoded.
+			if _, err := from.Discard(chunkSize); err != nil {
+				return nil, log.Err(ctx, ErrCannotAppend, "File ends with a partial chunk")
+			}
+		} else {
+			if chunkSize > cap(buf) {
+				buf = make([]byte, chunkSize)
+			}
+			data := buf[:chunkSize]
+			if _, err := io.ReadFull(from, data); err != nil {
+				return nil, log.Err(ctx, ErrCannotAppend, "File ends with a partial chunk")
+			}
+			// Type definitions are registered in the same order as they
+			// were written, so that they are given the same type indices.
+			pb := proto.NewBuffer(data)
+			name, err := pb.DecodeStringBytes()
+			if err != nil {
+				return nil, err
+			}
+			desc := &descriptor.DescriptorProto{}
+			if err := pb.Unmarshal(desc); err != nil {
+				return nil, err
+			}
+			w.types.add(name, desc)
+		}
+		w.offset += int64(n + chunkSize)
+		w.id++
+	}
+
+	if _, err := f.Seek(w.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// readChunkSize reads the zigzag encoded size of the next chunk from r,
+// returning the size and the number of bytes read. It returns io.EOF if r has
+// no more data, and errMalformedChunk if the size does not fit in a varint.
+func readChunkSize(r io.ByteReader) (size int64, n int, err error) {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && n > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, n, err
+		}
+		n++
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			break
+		}
+		if n == maxVarintSize {
+			return 0, n, errMalformedChunk
+		}
+	}
+	return int64(decodeZigzag(v)), n, nil
+}
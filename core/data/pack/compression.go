@@ -0,0 +1,70 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pack
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Compression is the codec used to compress the chunks of a pack file.
+type Compression byte
+
+const (
+	// NoCompression stores the chunks uncompressed.
+	NoCompression Compression = iota
+	// Gzip compresses the chunks with gzip.
+	Gzip
+)
+
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case Gzip:
+		return "gzip"
+	default:
+		return fmt.Sprintf("Compression(%d)", byte(c))
+	}
+}
+
+// ErrUnsupportedCompression is the error returned when the pack file uses a
+// compression codec this package cannot handle.
+type ErrUnsupportedCompression struct{ Compression Compression }
+
+func (e ErrUnsupportedCompression) Error() string {
+	return fmt.Sprintf("Unsupported pack file compression: %v", e.Compression)
+}
+
+// compress returns a writer that compresses to w using the codec c.
+func (c Compression) compress(w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	default:
+		return nil, ErrUnsupportedCompression{c}
+	}
+}
+
+// decompress returns a reader that decompresses r using the codec c.
+func (c Compression) decompress(r io.Reader) (io.Reader, error) {
+	switch c {
+	case Gzip:
+		return gzip.NewReader(r)
+	default:
+		return nil, ErrUnsupportedCompression{c}
+	}
+}
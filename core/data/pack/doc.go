@@ -15,6 +15,8 @@
 // Package pack provides methods to deal with self describing files of proto data.
 //
 // The file format consists of a magic marker, followed by a Header.
+// Compressed files follow the Header with the compression codec, and the rest
+// of the file is compressed.
 // After that is a repeated sequence of uvarint length, tag and matching encoded message pair.
 // Some section tags will also be followed by a string.
 // The tag 0 is special, and marks a type entry, the body will be a descriptor.DescriptorProto.
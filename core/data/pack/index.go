@@ -0,0 +1,285 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/google/gapid/core/math/sint"
+)
+
+const (
+	// indexFooterSize is the size of the footer that follows the index.
+	// The footer is the little-endian offset of the index followed by
+	// indexMagic.
+	indexFooterSize = 16
+)
+
+// indexMagic is the marker at the very end of a file that holds an index.
+var indexMagic = []byte("PackIdx\x00")
+
+// index holds the file offsets of the chunks in a pack file.
+type index struct {
+	// types holds the offset of each type definition chunk, in type index
+	// order (types[0] is type index 1).
+	types []int64
+	// objects holds the offsets of the object chunks for each type, in the
+	// same order as types.
+	objects [][]int64
+}
+
+// addType records a type definition chunk at the given offset.
+func (i *index) addType(offset int64) {
+	i.types = append(i.types, offset)
+	i.objects = append(i.objects, nil)
+}
+
+// addObject records an object chunk of the type with the given type index at
+// the given offset.
+func (i *index) addObject(typeIndex uint64, offset int64) {
+	i.objects[typeIndex-1] = append(i.objects[typeIndex-1], offset)
+}
+
+// encode writes the index to buf.
+// Each type is stored as its chunk offset, followed by the number of objects
+// and the object offsets, each as a delta from the previous offset.
+func (i *index) encode(buf *proto.Buffer) error {
+	if err := buf.EncodeVarint(uint64(len(i.types))); err != nil {
+		return err
+	}
+	for t, offset := range i.types {
+		if err := buf.EncodeVarint(uint64(offset)); err != nil {
+			return err
+		}
+		objects := i.objects[t]
+		if err := buf.EncodeVarint(uint64(len(objects))); err != nil {
+			return err
+		}
+		last := offset
+		for _, o := range objects {
+			if err := buf.EncodeVarint(uint64(o - last)); err != nil {
+				return err
+			}
+			last = o
+		}
+	}
+	return nil
+}
+
+// decode reads the index written by encode from data.
+func (i *index) decode(data []byte) error {
+	buf := proto.NewBuffer(data)
+	count := func() (int, error) {
+		n, err := buf.DecodeVarint()
+		if err != nil {
+			return 0, err
+		}
+		// Every entry takes at least one byte, so this guards against
+		// allocating huge slices for corrupt indices.
+		if n > uint64(len(data)) {
+			return 0, fmt.Errorf("Invalid pack index count: %v", n)
+		}
+		return int(n), nil
+	}
+	numTypes, err := count()
+	if err != nil {
+		return err
+	}
+	i.types = make([]int64, numTypes)
+	i.objects = make([][]int64, numTypes)
+	for t := range i.types {
+		offset, err := buf.DecodeVarint()
+		if err != nil {
+			return err
+		}
+		i.types[t] = int64(offset)
+		numObjects, err := count()
+		if err != nil {
+			return err
+		}
+		objects := make([]int64, numObjects)
+		last := int64(offset)
+		for o := range objects {
+			delta, err := buf.DecodeVarint()
+			if err != nil {
+				return err
+			}
+			last += int64(delta)
+			objects[o] = last
+		}
+		i.objects[t] = objects
+	}
+	return nil
+}
+
+// IndexedReader provides random access to the objects of a pack file that was
+// written by a Writer constructed with NewIndexedWriter.
+// Objects are looked up by their proto message name and their position amongst
+// the other objects of the same type.
+type IndexedReader struct {
+	from  io.ReaderAt
+	types *types
+	index index
+}
+
+// NewIndexedReader reads the header and index of the pack file of the given
+// size from the supplied stream, returning an IndexedReader.
+// If the file was written without an index then ErrNoIndex is returned, and
+// the file should be read with Read instead.
+func NewIndexedReader(from io.ReaderAt, size int64, forceDynamic bool) (*IndexedReader, error) {
+	buf := make([]byte, maxHeaderSize)
+	if err := readAt(from, buf, 0); err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if version, err := parseVersion(buf); err != nil {
+		return nil, err
+	} else if err := checkVersion(version); err != nil {
+		return nil, err
+	} else if version.Major == compressedMajorVersion {
+		// Offsets cannot be used to seek in a compressed stream.
+		return nil, ErrNoIndex
+	}
+
+	if size < maxHeaderSize+indexFooterSize {
+		return nil, ErrNoIndex
+	}
+	footer := make([]byte, indexFooterSize)
+	if err := readAt(from, footer, size-indexFooterSize); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(footer[8:], indexMagic) {
+		return nil, ErrNoIndex
+	}
+	start := int64(binary.LittleEndian.Uint64(footer))
+	if start < maxHeaderSize || start > size-indexFooterSize {
+		return nil, fmt.Errorf("Invalid pack index offset: %v", start)
+	}
+	data := make([]byte, size-indexFooterSize-start)
+	if err := readAt(from, data, start); err != nil {
+		return nil, err
+	}
+
+	r := &IndexedReader{
+		from:  from,
+		types: newTypes(forceDynamic),
+	}
+	if err := r.index.decode(data); err != nil {
+		return nil, err
+	}
+	for _, offset := range r.index.types {
+		chunkSize, pb, err := r.readChunk(offset)
+		if err != nil {
+			return nil, err
+		}
+		if chunkSize >= 0 {
+			return nil, fmt.Errorf("Chunk at offset %v is not a type definition", offset)
+		}
+		name, err := pb.DecodeStringBytes()
+		if err != nil {
+			return nil, err
+		}
+		desc := &descriptor.DescriptorProto{}
+		if err = pb.Unmarshal(desc); err != nil {
+			return nil, err
+		}
+		r.types.add(name, desc)
+	}
+	return r, nil
+}
+
+// Count returns the number of objects of the proto type with the given name.
+func (r *IndexedReader) Count(typeName string) int {
+	t, ok := r.types.byName[typeName]
+	if !ok {
+		return 0
+	}
+	return len(r.index.objects[t.index-1])
+}
+
+// Object reads and returns the i'th object of the proto type with the given
+// name. Group membership of the object is not reported.
+func (r *IndexedReader) Object(typeName string, i int) (proto.Message, error) {
+	t, ok := r.types.byName[typeName]
+	if !ok {
+		return nil, ErrUnknownType{TypeName: typeName}
+	}
+	offsets := r.index.objects[t.index-1]
+	if i < 0 || i >= len(offsets) {
+		return nil, fmt.Errorf("Object index %v out of range [0, %v) for type '%s'", i, len(offsets), typeName)
+	}
+	size, pb, err := r.readChunk(offsets[i])
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("Chunk at offset %v is not an object", offsets[i])
+	}
+	if _, err := pb.DecodeZigzag64(); err != nil { // parent
+		return nil, err
+	}
+	tyIdx, err := pb.DecodeZigzag64()
+	if err != nil {
+		return nil, err
+	}
+	if int64(tyIdx) < 0 {
+		tyIdx = -tyIdx // Absolute value.
+	}
+	if tyIdx != t.index {
+		return nil, fmt.Errorf("Chunk at offset %v has type index %v, expected %v", offsets[i], tyIdx, t.index)
+	}
+	msg := t.create()
+	if err := pb.Unmarshal(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// readChunk reads the chunk at the given offset, returning the signed chunk
+// size and a buffer holding the chunk body.
+func (r *IndexedReader) readChunk(offset int64) (int64, *proto.Buffer, error) {
+	buf := make([]byte, maxVarintSize)
+	// The chunk may be shorter than the largest possible varint.
+	if err := readAt(r.from, buf, offset); err != nil && err != io.ErrUnexpectedEOF {
+		return 0, nil, err
+	}
+	v, n := proto.DecodeVarint(buf)
+	if n == 0 {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	size := int64(decodeZigzag(v))
+	data := make([]byte, sint.Abs(int(size)))
+	if err := readAt(r.from, data, offset+int64(n)); err != nil {
+		return 0, nil, err
+	}
+	return size, proto.NewBuffer(data), nil
+}
+
+// readAt fills buf with the bytes at the given offset, returning
+// io.ErrUnexpectedEOF if the stream ends first.
+func readAt(from io.ReaderAt, buf []byte, offset int64) error {
+	n, err := from.ReadAt(buf, offset)
+	if n == len(buf) {
+		return nil
+	}
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -24,6 +24,17 @@ const (
 	// ErrIncorrectMagic is the error returned when the file header is not matched.
 	ErrIncorrectMagic = fault.Const("Incorrect pack magic header")
 
+	// ErrNoIndex is the error returned by NewIndexedReader when the file does
+	// not end with an index.
+	ErrNoIndex = fault.Const("Pack file has no index")
+
+	// ErrCannotAppend is the error returned by OpenForAppend when the file is
+	// compressed, indexed or truncated, and so cannot be appended to.
+	ErrCannotAppend = fault.Const("Pack file cannot be appended to")
+
+	// errMalformedChunk is the error returned when a chunk cannot be decoded.
+	errMalformedChunk = fault.Const("Malformed pack chunk")
+
 	initalBufferSize = 4096
 	maxVarintSize    = 10
 )
@@ -33,10 +44,19 @@ var (
 	MinMajorVersion = 2
 
 	// MaxMajorVersion is the current maximum supported major version of pack files.
-	MaxMajorVersion = 2
+	MaxMajorVersion = 3
 
 	// header is the header written by this package including the version.
 	header = []byte("ProtoPack\r\n2.0\n\x00")
+
+	// compressedHeader is the header written by this package for compressed
+	// files. It is followed by a single byte holding the Compression.
+	compressedHeader = []byte("ProtoPack\r\n3.0\n\x00")
+)
+
+const (
+	// compressedMajorVersion is the major version used by compressed files.
+	compressedMajorVersion = 3
 )
 
 type Version struct {
@@ -46,8 +66,22 @@ type Version struct {
 
 // ErrUnsupportedVersion is the error returned when the header version is one
 // this package cannot handle.
-type ErrUnsupportedVersion struct{ Version Version }
+type ErrUnsupportedVersion struct {
+	Version Version // The version read from the header.
+	Min     int     // The minimum major version supported by this package.
+	Max     int     // The maximum major version supported by this package.
+}
 
 func (e ErrUnsupportedVersion) Error() string {
-	return fmt.Sprintf("Unsupported pack file version: %v.%v", e.Version.Major, e.Version.Minor)
+	return fmt.Sprintf("Unsupported pack file version: %v.%v (supported versions are %v.x to %v.x)",
+		e.Version.Major, e.Version.Minor, e.Min, e.Max)
+}
+
+// checkVersion returns an ErrUnsupportedVersion if v cannot be read by this
+// package.
+func checkVersion(v Version) error {
+	if v.Major < MinMajorVersion || v.Major > MaxMajorVersion {
+		return ErrUnsupportedVersion{Version: v, Min: MinMajorVersion, Max: MaxMajorVersion}
+	}
+	return nil
 }
@@ -17,6 +17,10 @@ package pack_test
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
@@ -148,3 +152,258 @@ func TestReaderWriter(t *testing.T) {
 	err = pack.Read(ctx, bytes.NewBuffer(buf.Bytes()), &got, true)
 	assert.For(ctx, "Read (force-dynamic)").ThatError(err).Succeeded()
 }
+
+func TestIndexedReaderWriter(t *testing.T) {
+	ctx := log.Testing(t)
+	buf := &bytes.Buffer{}
+
+	var id0 uint64
+	expected := events{
+		eventObject{&testprotos.MsgA{F32: 1, U32: 2, S32: 3, Str: "four"}},
+		eventObject{&testprotos.MsgB{F64: 2, U64: 3, S64: 4, Bool: false}},
+		eventBeginGroup{&testprotos.MsgA{F32: 5, U32: 6, S32: 10, Str: "eleven"}, &id0},
+		eventChildObject{&testprotos.MsgB{F64: 7, U64: 8, S64: 12, Bool: true}, &id0},
+		eventChildObject{&testprotos.MsgA{F32: 7, U32: 8, S32: 12, Str: "thirteen"}, &id0},
+		eventEndGroup{&id0},
+	}
+
+	w, err := pack.NewIndexedWriter(buf)
+	assert.For(ctx, "NewIndexedWriter").ThatError(err).Succeeded()
+	for _, e := range expected {
+		e.write(ctx, w)
+	}
+	assert.For(ctx, "Close").ThatError(w.Close()).Succeeded()
+
+	// The index must not interfere with sequential reading.
+	got := events{}
+	err = pack.Read(ctx, bytes.NewBuffer(buf.Bytes()), &got, false)
+	assert.For(ctx, "Read").ThatError(err).Succeeded()
+	assert.For(ctx, "events").ThatSlice(got).DeepEquals(expected)
+
+	data := bytes.NewReader(buf.Bytes())
+	r, err := pack.NewIndexedReader(data, data.Size(), false)
+	if !assert.For(ctx, "NewIndexedReader").ThatError(err).Succeeded() {
+		return
+	}
+	nameA := proto.MessageName(&testprotos.MsgA{})
+	nameB := proto.MessageName(&testprotos.MsgB{})
+	assert.For(ctx, "Count(MsgA)").ThatInteger(r.Count(nameA)).Equals(3)
+	assert.For(ctx, "Count(MsgB)").ThatInteger(r.Count(nameB)).Equals(2)
+
+	for _, test := range []struct {
+		name     string
+		i        int
+		expected proto.Message
+	}{
+		{nameA, 0, &testprotos.MsgA{F32: 1, U32: 2, S32: 3, Str: "four"}},
+		{nameA, 2, &testprotos.MsgA{F32: 7, U32: 8, S32: 12, Str: "thirteen"}},
+		{nameB, 1, &testprotos.MsgB{F64: 7, U64: 8, S64: 12, Bool: true}},
+	} {
+		msg, err := r.Object(test.name, test.i)
+		if assert.For(ctx, "Object(%v, %v)", test.name, test.i).ThatError(err).Succeeded() {
+			assert.For(ctx, "Object(%v, %v)", test.name, test.i).That(msg).DeepEquals(test.expected)
+		}
+	}
+
+	_, err = r.Object(nameA, 3)
+	assert.For(ctx, "Object out of range").ThatError(err).Failed()
+}
+
+func TestIndexedReaderNoIndex(t *testing.T) {
+	ctx := log.Testing(t)
+	buf := &bytes.Buffer{}
+
+	w, err := pack.NewWriter(buf)
+	assert.For(ctx, "NewWriter").ThatError(err).Succeeded()
+	eventObject{&testprotos.MsgA{F32: 1, U32: 2, S32: 3, Str: "four"}}.write(ctx, w)
+	assert.For(ctx, "Close").ThatError(w.Close()).Succeeded()
+
+	data := bytes.NewReader(buf.Bytes())
+	_, err = pack.NewIndexedReader(data, data.Size(), false)
+	assert.For(ctx, "NewIndexedReader").ThatError(err).Equals(pack.ErrNoIndex)
+}
+
+func TestCompressedReaderWriter(t *testing.T) {
+	ctx := log.Testing(t)
+
+	expected := events{}
+	for i := 0; i < 100; i++ {
+		expected = append(expected, eventObject{&testprotos.MsgA{F32: 1, U32: 2, S32: 3, Str: "compressible"}})
+	}
+
+	plain, compressed := &bytes.Buffer{}, &bytes.Buffer{}
+	for _, test := range []struct {
+		buf         *bytes.Buffer
+		compression pack.Compression
+	}{
+		{plain, pack.NoCompression},
+		{compressed, pack.Gzip},
+	} {
+		w, err := pack.NewCompressedWriter(test.buf, test.compression)
+		assert.For(ctx, "NewCompressedWriter(%v)", test.compression).ThatError(err).Succeeded()
+		for _, e := range expected {
+			e.write(ctx, w)
+		}
+		assert.For(ctx, "Close(%v)", test.compression).ThatError(w.Close()).Succeeded()
+
+		got := events{}
+		err = pack.Read(ctx, bytes.NewBuffer(test.buf.Bytes()), &got, false)
+		assert.For(ctx, "Read(%v)", test.compression).ThatError(err).Succeeded()
+		assert.For(ctx, "events(%v)", test.compression).ThatSlice(got).DeepEquals(expected)
+	}
+
+	assert.For(ctx, "compressed size").ThatInteger(compressed.Len()).IsAtMost(plain.Len() / 2)
+}
+
+func TestUnsupportedVersion(t *testing.T) {
+	ctx := log.Testing(t)
+
+	future := append([]byte("ProtoPack\r\n9.0\n\x00"), 0x02, 0x08, 0x01)
+	expected := pack.ErrUnsupportedVersion{
+		Version: pack.Version{Major: 9, Minor: 0},
+		Min:     pack.MinMajorVersion,
+		Max:     pack.MaxMajorVersion,
+	}
+
+	got := events{}
+	err := pack.Read(ctx, bytes.NewBuffer(future), &got, false)
+	assert.For(ctx, "Read").ThatError(err).Equals(expected)
+	assert.For(ctx, "events").ThatSlice(got).IsEmpty()
+
+	data := bytes.NewReader(future)
+	_, err = pack.NewIndexedReader(data, data.Size(), false)
+	assert.For(ctx, "NewIndexedReader").ThatError(err).Equals(expected)
+	assert.For(ctx, "message").ThatString(expected.Error()).Equals(fmt.Sprintf(
+		"Unsupported pack file version: 9.0 (supported versions are %v.x to %v.x)",
+		pack.MinMajorVersion, pack.MaxMajorVersion))
+}
+
+func TestAppend(t *testing.T) {
+	ctx := log.Testing(t)
+
+	var groupID uint64
+	first := []event{
+		eventObject{&testprotos.MsgA{F32: 1, U32: 2, S32: 3, Str: "four"}},
+		eventBeginGroup{&testprotos.MsgB{F64: 2, U64: 3, S64: 4, Bool: false}, &groupID},
+	}
+	second := []event{
+		eventObject{&testprotos.MsgA{F32: 3, U32: 4, S32: 5, Str: "six"}},
+		eventChildObject{&testprotos.MsgB{F64: 4, U64: 5, S64: 6, Bool: true}, &groupID},
+		eventEndGroup{&groupID},
+	}
+
+	// Write all the events in one go, as the expected file.
+	expected := &bytes.Buffer{}
+	w, err := pack.NewWriter(expected)
+	assert.For(ctx, "NewWriter").ThatError(err).Succeeded()
+	for _, e := range append(append([]event{}, first...), second...) {
+		e.write(ctx, w)
+	}
+	assert.For(ctx, "Close").ThatError(w.Close()).Succeeded()
+
+	// Write the events across two writers, reopening the file for the second.
+	file, err := ioutil.TempFile("", "pack_test")
+	if !assert.For(ctx, "TempFile").ThatError(err).Succeeded() {
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	groupID = 0
+	w, err = pack.NewWriter(file)
+	assert.For(ctx, "NewWriter").ThatError(err).Succeeded()
+	for _, e := range first {
+		e.write(ctx, w)
+	}
+	assert.For(ctx, "Close").ThatError(w.Close()).Succeeded()
+
+	w, err = pack.OpenForAppend(ctx, file)
+	if !assert.For(ctx, "OpenForAppend").ThatError(err).Succeeded() {
+		return
+	}
+	for _, e := range second {
+		e.write(ctx, w)
+	}
+	assert.For(ctx, "Close").ThatError(w.Close()).Succeeded()
+
+	got, err := ioutil.ReadFile(file.Name())
+	assert.For(ctx, "ReadFile").ThatError(err).Succeeded()
+	assert.For(ctx, "data").ThatSlice(got).Equals(expected.Bytes())
+
+	// Truncated and indexed files cannot be appended to.
+	assert.For(ctx, "Truncate").ThatError(file.Truncate(int64(len(got) - 1))).Succeeded()
+	_, err = pack.OpenForAppend(ctx, file)
+	assert.For(ctx, "OpenForAppend(truncated)").ThatError(err).HasCause(pack.ErrCannotAppend)
+
+	assert.For(ctx, "Truncate").ThatError(file.Truncate(0)).Succeeded()
+	_, err = file.Seek(0, io.SeekStart)
+	assert.For(ctx, "Seek").ThatError(err).Succeeded()
+	groupID = 0
+	w, err = pack.NewIndexedWriter(file)
+	assert.For(ctx, "NewIndexedWriter").ThatError(err).Succeeded()
+	for _, e := range first {
+		e.write(ctx, w)
+	}
+	assert.For(ctx, "Close").ThatError(w.Close()).Succeeded()
+	_, err = pack.OpenForAppend(ctx, file)
+	assert.For(ctx, "OpenForAppend(indexed)").ThatError(err).HasCause(pack.ErrCannotAppend)
+}
+
+func TestRepair(t *testing.T) {
+	ctx := log.Testing(t)
+
+	var id0, id1 uint64
+	expected := events{
+		eventObject{&testprotos.MsgA{F32: 1, U32: 2, S32: 3, Str: "four"}},
+		eventBeginGroup{&testprotos.MsgB{F64: 2, U64: 3, S64: 4, Bool: false}, &id0},
+		eventChildObject{&testprotos.MsgA{F32: 3, U32: 4, S32: 5, Str: "six"}, &id0},
+		eventBeginChildGroup{&testprotos.MsgB{F64: 4, U64: 5, S64: 6, Bool: true}, &id1, &id0},
+		eventEndGroup{&id0},
+		eventObject{&testprotos.MsgC{Entries: []*testprotos.MsgC_Entry{
+			&testprotos.MsgC_Entry{Value: 1},
+		}}},
+	}
+
+	for _, compression := range []pack.Compression{pack.NoCompression, pack.Gzip} {
+		id0, id1 = 0, 0
+		buf := &bytes.Buffer{}
+		w, err := pack.NewCompressedWriter(buf, compression)
+		assert.For(ctx, "NewCompressedWriter(%v)", compression).ThatError(err).Succeeded()
+		for _, e := range expected {
+			e.write(ctx, w)
+		}
+		assert.For(ctx, "Close(%v)", compression).ThatError(w.Close()).Succeeded()
+		data := buf.Bytes()
+
+		for size := 16; size <= len(data); size++ {
+			ctx := log.V{"compression": compression, "size": size}.Bind(ctx)
+			repaired := &bytes.Buffer{}
+			res, err := pack.Repair(ctx, bytes.NewReader(data[:size]), repaired)
+			if !assert.For(ctx, "Repair").ThatError(err).Succeeded() {
+				return
+			}
+			if compression == pack.NoCompression {
+				if res.Truncated {
+					assert.For(ctx, "Offset").ThatInteger(int(res.Offset)).IsAtMost(size - 1)
+				} else {
+					// Files truncated between chunks look complete.
+					assert.For(ctx, "Offset").ThatInteger(int(res.Offset)).Equals(size)
+				}
+			}
+
+			got := events{}
+			err = pack.Read(ctx, repaired, &got, false)
+			assert.For(ctx, "Read").ThatError(err).Succeeded()
+			assert.For(ctx, "events").ThatSlice(got).DeepEquals(expected[:len(got)])
+			if size == len(data) {
+				assert.For(ctx, "Truncated").That(res.Truncated).Equals(false)
+				assert.For(ctx, "events").ThatSlice(got).DeepEquals(expected)
+				assert.For(ctx, "Messages").That(res.Messages).Equals(len(expected) - 1)
+				assert.For(ctx, "Types").That(res.Types).Equals(4)
+			}
+		}
+	}
+
+	_, err := pack.Repair(ctx, bytes.NewReader([]byte("not a pack file!")), &bytes.Buffer{})
+	assert.For(ctx, "Repair(not a pack file)").ThatError(err).Equals(pack.ErrIncorrectMagic)
+}
@@ -16,6 +16,7 @@ package pack
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -51,8 +52,12 @@ func Read(ctx context.Context, from io.Reader, events Events, forceDynamic bool)
 	r.pb = proto.NewBuffer(r.buf)
 	if version, err := r.readHeader(); err != nil {
 		return err
-	} else if !(MinMajorVersion <= version.Major && version.Major <= MaxMajorVersion) {
-		return ErrUnsupportedVersion{Version: version}
+	} else if err := checkVersion(version); err != nil {
+		return err
+	} else if version.Major == compressedMajorVersion {
+		if err := r.decompress(); err != nil {
+			return err
+		}
 	}
 	for ; !task.Stopped(ctx); r.id++ {
 		if err := r.unmarshal(ctx); err != nil {
@@ -166,6 +171,24 @@ func (r *reader) readHeader() (Version, error) {
 	return parseVersion(r.pb.Bytes())
 }
 
+// decompress reads the compression codec that follows the header, and
+// replaces the reader's stream with one that decompresses the remaining data.
+func (r *reader) decompress() error {
+	if err := r.readN(1); err != nil {
+		return err
+	}
+	c := Compression(r.pb.Bytes()[0])
+	remains := bytes.NewReader(append([]byte{}, r.buf[r.bufOffset:]...))
+	from, err := c.decompress(io.MultiReader(remains, r.from))
+	if err != nil {
+		return err
+	}
+	r.from = from
+	r.buf = r.buf[:0]
+	r.bufOffset = 0
+	return nil
+}
+
 func parseVersion(buf []byte) (Version, error) {
 	if len(buf) < maxHeaderSize {
 		return Version{}, ErrIncorrectMagic
@@ -199,8 +222,8 @@ func (r *reader) readChunk() (chunkSize int64, err error) {
 	if n == 0 || size == 0 {
 		return 0, io.EOF
 	}
-	size = (size >> 1) ^ uint64((int64(size&1)<<63)>>63) // Decode zig-zag encoding
-	return int64(size), r.readN(sint.Abs(int(size)))
+	chunkSize = int64(decodeZigzag(size))
+	return chunkSize, r.readN(sint.Abs(int(chunkSize)))
 }
 
 // readN makes sure there is size bytes available in the buffer if possible
@@ -0,0 +1,220 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/math/sint"
+)
+
+// RepairResult describes the chunks recovered by Repair.
+type RepairResult struct {
+	// Messages is the number of objects and groups recovered.
+	Messages int
+	// Types is the number of type definitions recovered.
+	Types int
+	// Truncated is true if the file ended with a partial or malformed chunk.
+	// Files truncated between two chunks cannot be told apart from complete
+	// files.
+	Truncated bool
+	// Offset is the offset of the first chunk that was not recovered, or the
+	// end of the chunks if the file was not truncated.
+	// For compressed files the offset is in the decompressed chunks, which
+	// start after the header and the compression codec.
+	Offset int64
+}
+
+func (r RepairResult) String() string {
+	if r.Truncated {
+		return fmt.Sprintf("Recovered %d messages and %d types, truncated at offset %d", r.Messages, r.Types, r.Offset)
+	}
+	return fmt.Sprintf("Recovered %d messages and %d types, not truncated", r.Messages, r.Types)
+}
+
+// Repair reads the pack file from the supplied stream, and writes the complete
+// chunks up to the first partial or malformed chunk to an uncompressed pack
+// file. This salvages the objects of files that were only partially written,
+// for instance because the writing process crashed.
+// Groups that are not ended in the recovered chunks are left open, which
+// Read reports the same as groups that were never ended by the writer.
+// Files with an index are recovered without it.
+func Repair(ctx context.Context, from io.Reader, to io.Writer) (RepairResult, error) {
+	res := RepairResult{}
+	buf := make([]byte, maxHeaderSize)
+	if _, err := io.ReadFull(from, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return res, ErrIncorrectMagic
+		}
+		return res, err
+	}
+	version, err := parseVersion(buf)
+	if err != nil {
+		return res, err
+	}
+	if err := checkVersion(version); err != nil {
+		return res, err
+	}
+	if _, err := to.Write(header); err != nil {
+		return res, err
+	}
+	res.Offset = maxHeaderSize
+	if version.Major == compressedMajorVersion {
+		res.Offset = 0
+		codec := []byte{0}
+		if _, err := io.ReadFull(from, codec); err != nil {
+			if err == io.EOF {
+				res.Truncated = true
+				return res, nil
+			}
+			return res, err
+		}
+		if from, err = Compression(codec[0]).decompress(from); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				res.Truncated = true
+				return res, nil
+			}
+			return res, err
+		}
+	}
+
+	s := &repairScanner{
+		from:   bufio.NewReaderSize(from, initalBufferSize),
+		groups: map[uint64]bool{},
+		out:    proto.NewBuffer(make([]byte, 0, initalBufferSize)),
+	}
+	for ; !task.Stopped(ctx); s.id++ {
+		n, isType, err := s.next()
+		switch {
+		case err == io.EOF:
+			return res, nil
+		case err == io.ErrUnexpectedEOF || err == errMalformedChunk:
+			res.Truncated = true
+			return res, nil
+		case err != nil:
+			return res, err
+		}
+		if _, err := to.Write(s.out.Bytes()); err != nil {
+			return res, err
+		}
+		s.out.Reset()
+		if isType {
+			res.Types++
+		} else if !s.ended {
+			res.Messages++
+		}
+		res.Offset += int64(n)
+	}
+	return res, task.StopReason(ctx)
+}
+
+// repairScanner validates the chunks of a pack file for Repair.
+type repairScanner struct {
+	from   *bufio.Reader
+	id     uint64
+	types  uint64          // The number of type definitions read.
+	groups map[uint64]bool // The identifiers of the open groups.
+	ended  bool            // True if the last chunk ended a group.
+	body   bytes.Buffer
+	out    *proto.Buffer
+}
+
+// next reads and validates the next chunk, encoding it to s.out. It returns
+// the number of bytes read and whether the chunk was a type definition.
+// It returns io.EOF at the end of the chunks, and io.ErrUnexpectedEOF or
+// errMalformedChunk if the chunk is partial or malformed.
+func (s *repairScanner) next() (n int, isType bool, err error) {
+	size, n, err := readChunkSize(s.from)
+	if err != nil {
+		return 0, false, err
+	}
+	if size == 0 {
+		// A zero sized chunk is only written before an index.
+		return 0, false, io.EOF
+	}
+	chunkSize := sint.Abs(int(size))
+	s.body.Reset()
+	// The chunk is copied rather than read into a buffer of the chunk size, as
+	// the size of a partial chunk may be garbage.
+	if c, err := io.CopyN(&s.body, s.from, int64(chunkSize)); err != nil {
+		if err == io.EOF && c < int64(chunkSize) {
+			return 0, false, io.ErrUnexpectedEOF
+		}
+		return 0, false, err
+	}
+	if err := s.validate(size < 0, s.body.Bytes()); err != nil {
+		return 0, false, err
+	}
+	if err := s.out.EncodeZigzag64(uint64(size)); err != nil {
+		return 0, false, err
+	}
+	s.out.SetBuf(append(s.out.Bytes(), s.body.Bytes()...))
+	return n + chunkSize, size < 0, nil
+}
+
+// validate checks that the chunk body can be decoded, and that it only refers
+// to the types and groups that precede it.
+func (s *repairScanner) validate(isType bool, body []byte) error {
+	pb := proto.NewBuffer(body)
+	s.ended = false
+	if isType {
+		if _, err := pb.DecodeStringBytes(); err != nil {
+			return errMalformedChunk
+		}
+		if err := pb.Unmarshal(&descriptor.DescriptorProto{}); err != nil {
+			return errMalformedChunk
+		}
+		s.types++
+		return nil
+	}
+
+	// As with Read, missing fields are implicitly 0.
+	parent, err := pb.DecodeZigzag64()
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return errMalformedChunk
+	}
+	tyIdx, err := pb.DecodeZigzag64()
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return errMalformedChunk
+	}
+	hasParent := int64(parent) < 0
+	parentID := s.id + parent
+	if hasParent && (parentID >= s.id || !s.groups[parentID]) {
+		return errMalformedChunk
+	}
+	if tyIdx == 0 { // Null-terminator
+		if !hasParent {
+			return errMalformedChunk
+		}
+		delete(s.groups, parentID)
+		s.ended = true
+		return nil
+	}
+	if int64(tyIdx) < 0 {
+		s.groups[s.id] = true
+		tyIdx = -tyIdx // Absolute value.
+	}
+	if tyIdx > s.types {
+		return errMalformedChunk
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ package pack
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
 
@@ -25,11 +26,14 @@ import (
 // Writer is the type for a pack file writer.
 // They should only be constructed by NewWriter.
 type Writer struct {
-	types   *types
-	id      uint64
-	buf     *proto.Buffer
-	sizebuf *proto.Buffer
-	to      io.Writer
+	types      *types
+	id         uint64
+	buf        *proto.Buffer
+	sizebuf    *proto.Buffer
+	to         io.Writer
+	offset     int64
+	index      *index
+	compressor io.WriteCloser
 }
 
 // NewWriter constructs and returns a new Writer that writes to the supplied
@@ -46,9 +50,96 @@ func NewWriter(to io.Writer) (*Writer, error) {
 	if _, err := w.to.Write(header); err != nil {
 		return nil, err
 	}
+	w.offset = int64(len(header))
 	return w, nil
 }
 
+// NewIndexedWriter constructs and returns a new Writer that writes to the
+// supplied output stream, and records the offsets of every chunk written.
+// The index is appended to the stream when Close is called, and can be used
+// by IndexedReader to access objects without scanning the whole file.
+func NewIndexedWriter(to io.Writer) (*Writer, error) {
+	w, err := NewWriter(to)
+	if err != nil {
+		return nil, err
+	}
+	w.index = &index{}
+	return w, nil
+}
+
+// NewCompressedWriter constructs and returns a new Writer that writes to the
+// supplied output stream, compressing all the chunks with the given codec.
+// Compressed files have a newer major version than uncompressed files, so
+// older readers will report them as unsupported. Close must be called to
+// flush the compressed stream.
+func NewCompressedWriter(to io.Writer, c Compression) (*Writer, error) {
+	if c == NoCompression {
+		return NewWriter(to)
+	}
+	compressor, err := c.compress(to)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := to.Write(compressedHeader); err != nil {
+		return nil, err
+	}
+	if _, err := to.Write([]byte{byte(c)}); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		types:      newTypes(false),
+		buf:        proto.NewBuffer(make([]byte, 0, initalBufferSize)),
+		sizebuf:    proto.NewBuffer(make([]byte, 0, maxVarintSize)),
+		to:         compressor,
+		compressor: compressor,
+	}, nil
+}
+
+// Close finishes the pack file. If the Writer was constructed with
+// NewIndexedWriter then the index is written to the end of the stream.
+// If the Writer was constructed with NewCompressedWriter then the compressed
+// stream is flushed.
+// Close does not close the underlying stream.
+func (w *Writer) Close() error {
+	if w.index != nil {
+		idx := w.index
+		w.index = nil
+		if err := w.writeIndex(idx); err != nil {
+			return err
+		}
+	}
+	if w.compressor != nil {
+		compressor := w.compressor
+		w.compressor = nil
+		return compressor.Close()
+	}
+	return nil
+}
+
+func (w *Writer) writeIndex(idx *index) error {
+	// A zero sized chunk marks the end of the chunks, so that sequential
+	// readers stop before reaching the index.
+	if err := w.buf.EncodeVarint(0); err != nil {
+		return err
+	}
+	start := w.offset + int64(len(w.buf.Bytes()))
+	if err := idx.encode(w.buf); err != nil {
+		return err
+	}
+	n, err := w.to.Write(w.buf.Bytes())
+	w.offset += int64(n)
+	w.buf.Reset()
+	if err != nil {
+		return err
+	}
+	footer := make([]byte, indexFooterSize)
+	binary.LittleEndian.PutUint64(footer, uint64(start))
+	copy(footer[8:], indexMagic)
+	n, err = w.to.Write(footer)
+	w.offset += int64(n)
+	return err
+}
+
 // BeginGroup is called to start a new root group.
 func (w *Writer) BeginGroup(ctx context.Context, msg proto.Message) (id uint64, err error) {
 	return w.writeMessage(ctx, msg, true, nil)
@@ -102,6 +193,9 @@ func (w *Writer) writeMessage(ctx context.Context, msg proto.Message, isGroup bo
 	}
 
 	typeIndex := ty.index
+	if w.index != nil {
+		w.index.addObject(typeIndex, w.offset)
+	}
 	if isGroup {
 		// Negate type index if it may have children
 		typeIndex = uint64(-int64(typeIndex))
@@ -126,6 +220,9 @@ func (w *Writer) writeParentID(id uint64) error {
 }
 
 func (w *Writer) writeType(t *ty) error {
+	if w.index != nil {
+		w.index.addType(w.offset)
+	}
 	if err := w.buf.EncodeStringBytes(t.name); err != nil {
 		return err
 	}
@@ -143,12 +240,14 @@ func (w *Writer) flushChunk(isTypeDef bool) error {
 	if err := w.sizebuf.EncodeZigzag64(uint64(size)); err != nil {
 		return err
 	}
-	_, err := w.to.Write(w.sizebuf.Bytes())
+	n, err := w.to.Write(w.sizebuf.Bytes())
+	w.offset += int64(n)
 	w.sizebuf.Reset()
 	if err != nil {
 		return err
 	}
-	_, err = w.to.Write(w.buf.Bytes())
+	n, err = w.to.Write(w.buf.Bytes())
+	w.offset += int64(n)
 	w.buf.Reset()
 	w.id++
 	return err
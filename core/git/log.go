@@ -37,6 +37,16 @@ func (g Git) LogFrom(ctx context.Context, at string, count int) ([]ChangeList, e
 	return parseLog(str)
 }
 
+// LogRange returns the ChangeLists that are ancestors of to but not of from,
+// most recent first.
+func (g Git) LogRange(ctx context.Context, from, to string) ([]ChangeList, error) {
+	str, _, err := g.run(ctx, "log", fmt.Sprintf("%v..%v", from, to), "--pretty=format:ǁ%Hǀ%an <%ae>ǀ%sǀ%b", g.wd)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(str)
+}
+
 // Parent returns the parent ChangeList for cl.
 func (g Git) Parent(ctx context.Context, cl ChangeList) (ChangeList, error) {
 	str, _, err := g.run(ctx, "log", "--pretty=format:ǁ%Hǀ%an <%ae>ǀ%sǀ%b", fmt.Sprintf("%v^", cl.SHA))
@@ -0,0 +1,65 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Clone clones the repository at url into the directory dir, and returns a Git
+// instance targeting the clone.
+// If depth is greater than zero then the clone is shallow, holding only the
+// depth most recent commits of each branch. Shallow clones can be extended
+// with Deepen.
+func Clone(ctx context.Context, url, dir string, depth int) (Git, error) {
+	g, err := New("")
+	if err != nil {
+		return Git{}, err
+	}
+	args := []interface{}{"clone"}
+	if depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", depth), "--no-single-branch")
+	}
+	args = append(args, url, dir)
+	if _, _, err := g.run(ctx, args...); err != nil {
+		return Git{}, err
+	}
+	return New(dir)
+}
+
+// IsShallow returns true if the repository only holds part of its history.
+func (g Git) IsShallow(ctx context.Context) (bool, error) {
+	str, _, err := g.run(ctx, "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(str) == "true", nil
+}
+
+// Deepen fetches count more commits of history from the remote for each
+// branch of a shallow repository.
+func (g Git) Deepen(ctx context.Context, count int) error {
+	_, _, err := g.run(ctx, "fetch", fmt.Sprintf("--deepen=%d", count))
+	return err
+}
+
+// HasCommit returns true if the commit at the given SHA, tag or branch is held
+// by the repository.
+func (g Git) HasCommit(ctx context.Context, at string) bool {
+	_, _, err := g.run(ctx, "cat-file", "-e", at+"^{commit}")
+	return err == nil
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+)
+
+// newTestRepo creates a repository in dir holding count commits, and returns
+// their SHAs from the oldest to the newest.
+func newTestRepo(ctx context.Context, dir string, count int) ([]string, error) {
+	g, err := New(dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := g.run(ctx, "init", "-q"); err != nil {
+		return nil, err
+	}
+	shas := []string{}
+	for i := 0; i < count; i++ {
+		msg := fmt.Sprintf("Commit %d", i)
+		if _, _, err := g.run(ctx, "-c", "user.name=test", "-c", "user.email=test@example.com",
+			"commit", "-q", "--allow-empty", "-m", msg); err != nil {
+			return nil, err
+		}
+		sha, _, err := g.run(ctx, "rev-parse", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		shas = append(shas, sha[:len(sha)-1])
+	}
+	return shas, nil
+}
+
+func TestShallowClone(t *testing.T) {
+	ctx := log.Testing(t)
+	if _, err := New(""); err != nil {
+		t.Skip(err)
+	}
+	tmp, err := ioutil.TempDir("", "git_shallow_test")
+	if !assert.For(ctx, "TempDir").ThatError(err).Succeeded() {
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	origin := filepath.Join(tmp, "origin")
+	if !assert.For(ctx, "Mkdir").ThatError(os.Mkdir(origin, 0755)).Succeeded() {
+		return
+	}
+	shas, err := newTestRepo(ctx, origin, 4)
+	if !assert.For(ctx, "newTestRepo").ThatError(err).Succeeded() {
+		return
+	}
+
+	// Local clones ignore the depth unless the origin is given as a URL.
+	url := "file://" + filepath.ToSlash(origin)
+	clone, err := Clone(ctx, url, filepath.Join(tmp, "clone"), 2)
+	if !assert.For(ctx, "Clone").ThatError(err).Succeeded() {
+		return
+	}
+	shallow, err := clone.IsShallow(ctx)
+	assert.For(ctx, "IsShallow").ThatError(err).Succeeded()
+	assert.For(ctx, "shallow").That(shallow).Equals(true)
+	for i, expected := range []bool{false, false, true, true} {
+		assert.For(ctx, "HasCommit(%d)", i).That(clone.HasCommit(ctx, shas[i])).Equals(expected)
+	}
+
+	err = clone.Deepen(ctx, 1)
+	assert.For(ctx, "Deepen").ThatError(err).Succeeded()
+	for i, expected := range []bool{false, true, true, true} {
+		assert.For(ctx, "HasCommit(%d) deepened", i).That(clone.HasCommit(ctx, shas[i])).Equals(expected)
+	}
+
+	err = clone.Deepen(ctx, 10)
+	assert.For(ctx, "Deepen").ThatError(err).Succeeded()
+	shallow, err = clone.IsShallow(ctx)
+	assert.For(ctx, "IsShallow").ThatError(err).Succeeded()
+	assert.For(ctx, "shallow after deepening").That(shallow).Equals(false)
+
+	full, err := Clone(ctx, url, filepath.Join(tmp, "full"), 0)
+	if !assert.For(ctx, "Clone").ThatError(err).Succeeded() {
+		return
+	}
+	shallow, err = full.IsShallow(ctx)
+	assert.For(ctx, "IsShallow").ThatError(err).Succeeded()
+	assert.For(ctx, "full shallow").That(shallow).Equals(false)
+	assert.For(ctx, "HasCommit(0) full").That(full.HasCommit(ctx, shas[0])).Equals(true)
+	assert.For(ctx, "HasCommit(missing)").That(full.HasCommit(ctx, "no-such-branch")).Equals(false)
+}
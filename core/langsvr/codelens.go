@@ -25,3 +25,10 @@ type CodeLens struct {
 	// Resolve returns the Command for this CodeLens
 	Resolve func(context.Context) Command
 }
+
+// codeLensData is the data of a protocol code lens, used to find the CodeLens
+// to resolve.
+type codeLensData struct {
+	URI   string `json:"uri"`
+	Index int    `json:"index"`
+}
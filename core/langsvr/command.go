@@ -28,7 +28,7 @@ type Command struct {
 	Command string
 
 	// Arguments that the command handler should be invoked with.
-	Arguments map[string]interface{}
+	Arguments []interface{}
 }
 
 func (c Command) toProtocol() protocol.Command {
@@ -38,3 +38,37 @@ func (c Command) toProtocol() protocol.Command {
 		Arguments: c.Arguments,
 	}
 }
+
+// ApplyEditCommand is the identifier of the command returned by EditCommand.
+// The client is expected to handle it by applying the workspace edit passed as
+// the single argument.
+const ApplyEditCommand = "langsvr.applyEdit"
+
+// ShowReferencesCommand is the identifier of the command returned by
+// ReferencesCommand. The client is expected to handle it by showing the
+// references passed as the arguments.
+const ShowReferencesCommand = "langsvr.showReferences"
+
+// EditCommand returns a Command with the given title that applies edit to the
+// workspace when invoked.
+func EditCommand(title string, edit WorkspaceEdit) Command {
+	return Command{
+		Title:     title,
+		Command:   ApplyEditCommand,
+		Arguments: []interface{}{edit.toProtocol()},
+	}
+}
+
+// ReferencesCommand returns a Command with the given title that shows the
+// references to the symbol at loc when invoked.
+func ReferencesCommand(title string, loc Location, references []Location) Command {
+	refs := make([]protocol.Location, len(references))
+	for i, r := range references {
+		refs[i] = r.toProtocol()
+	}
+	return Command{
+		Title:     title,
+		Command:   ShowReferencesCommand,
+		Arguments: []interface{}{loc.URI, loc.Range.Start.toProtocol(), refs},
+	}
+}
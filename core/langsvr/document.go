@@ -27,9 +27,10 @@ type Document struct {
 	language string // The language of the document.
 	version  int    // The incremental version of the document.
 	server   *langsvr
-	body     Body // The immutable document body.
-	open     bool // true if the document is currently open (visible)
-	watched  bool // true if the document is watched
+	body     Body       // The immutable document body.
+	open     bool       // true if the document is currently open (visible)
+	watched  bool       // true if the document is watched
+	lenses   []CodeLens // The last code lenses returned for the document.
 }
 
 // newDocument returns a new document initialized with the uri, language,
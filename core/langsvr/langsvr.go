@@ -16,6 +16,7 @@ package langsvr
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -43,6 +44,29 @@ type InitConfig struct {
 	// Documents is a list of all the document paths that should be watched from
 	// initialization.
 	WorkspaceDocuments []string
+
+	// SyncKind is how the client should send document changes to the server.
+	SyncKind SyncKind
+}
+
+// SyncKind is an enumerator of the ways document changes can be synchronized
+// from the client.
+type SyncKind int
+
+const (
+	// SyncIncremental sends only the changed ranges of the document.
+	SyncIncremental SyncKind = iota
+	// SyncFull sends the full content of the document on each change.
+	SyncFull
+)
+
+func (k SyncKind) toProtocol() protocol.TextDocumentSyncKind {
+	switch k {
+	case SyncFull:
+		return protocol.SyncFull
+	default:
+		return protocol.SyncIncremental
+	}
 }
 
 // Server is the interface implemented by language servers.
@@ -262,7 +286,7 @@ func (s *langsvr) Initialize(ctx context.Context, processID int, rootPath string
 	s.server.OnDocumentsAdded(ctx, added)
 
 	caps := protocol.ServerCapabilities{
-		TextDocumentSync: protocol.SyncIncremental,
+		TextDocumentSync: cfg.SyncKind.toProtocol(),
 	}
 	_, caps.HoverProvider = s.server.(HoverProvider)
 	_, caps.DefinitionProvider = s.server.(DefinitionProvider)
@@ -492,11 +516,12 @@ func (s langsvr) CodeLens(ctx context.Context, docID protocol.TextDocumentIdenti
 	if err != nil {
 		return nil, err
 	}
+	doc.lenses = cls
 	out := make([]protocol.CodeLens, len(cls))
 	for i, cl := range cls {
 		out[i] = protocol.CodeLens{
 			Range: cl.Range.toProtocol(),
-			Data:  i,
+			Data:  codeLensData{URI: docID.URI, Index: i},
 		}
 	}
 	return out, nil
@@ -504,6 +529,25 @@ func (s langsvr) CodeLens(ctx context.Context, docID protocol.TextDocumentIdenti
 
 func (s langsvr) CodeLensResolve(ctx context.Context, codelens protocol.CodeLens) (protocol.CodeLens, error) {
 	ctx = log.Enter(ctx, "CodeLensResolve")
+	data, err := json.Marshal(codelens.Data)
+	if err != nil {
+		return codelens, err
+	}
+	id := codeLensData{}
+	if err := json.Unmarshal(data, &id); err != nil {
+		return codelens, err
+	}
+	doc, err := s.getDoc(id.URI)
+	if err != nil {
+		return codelens, err
+	}
+	if id.Index < 0 || id.Index >= len(doc.lenses) {
+		return codelens, protocol.Error{Code: protocol.InvalidRequest, Message: "Unknown code lens"}
+	}
+	if resolve := doc.lenses[id.Index].Resolve; resolve != nil {
+		cmd := resolve(ctx).toProtocol()
+		codelens.Command = &cmd
+	}
 	return codelens, nil
 }
 
@@ -616,6 +660,11 @@ func (s langsvr) OnChangeTextDocument(ctx context.Context, item protocol.Version
 	}
 	body := doc.Body()
 	for _, change := range changes {
+		if change.Range == nil {
+			// The change holds the full content of the document.
+			body = NewBody(change.Text)
+			continue
+		}
 		start := body.offset(change.Range.Start)
 		end := body.offset(change.Range.End)
 		change := []rune(change.Text)
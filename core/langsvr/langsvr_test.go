@@ -0,0 +1,110 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package langsvr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/langsvr/protocol"
+	"github.com/google/gapid/core/log"
+)
+
+type fakeServer struct {
+	syncKind SyncKind
+	changed  []*Document
+}
+
+func (s *fakeServer) Initialize(ctx context.Context, rootPath string) (InitConfig, error) {
+	return InitConfig{SyncKind: s.syncKind}, nil
+}
+func (s *fakeServer) Shutdown(context.Context) error                               { return nil }
+func (s *fakeServer) OnConfigChange(context.Context, map[string]interface{}) error { return nil }
+func (s *fakeServer) OnDocumentsAdded(context.Context, []*Document) error          { return nil }
+func (s *fakeServer) OnDocumentsRemoved(context.Context, []*Document) error        { return nil }
+func (s *fakeServer) OnDocumentSaved(context.Context, *Document) error             { return nil }
+func (s *fakeServer) OnDocumentsChanged(ctx context.Context, docs []*Document) error {
+	s.changed = append(s.changed, docs...)
+	return nil
+}
+
+func TestInitializeSyncKind(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		kind     SyncKind
+		expected protocol.TextDocumentSyncKind
+	}{
+		{SyncIncremental, protocol.SyncIncremental},
+		{SyncFull, protocol.SyncFull},
+	} {
+		s := &langsvr{server: &fakeServer{syncKind: test.kind}, documents: map[string]*Document{}}
+		caps, err := s.Initialize(ctx, 0, "")
+		if assert.For(ctx, "Initialize").ThatError(err).Succeeded() {
+			assert.For(ctx, "sync kind %v", test.kind).That(caps.TextDocumentSync).Equals(test.expected)
+		}
+	}
+	// Servers that do not choose get incremental sync.
+	assert.For(ctx, "default").That(InitConfig{}.SyncKind).Equals(SyncIncremental)
+}
+
+func TestChangeTextDocument(t *testing.T) {
+	ctx := log.Testing(t)
+	const uri = "file:///doc.api"
+	server := &fakeServer{}
+	s := &langsvr{server: server, documents: map[string]*Document{}}
+	original := s.newDocument(uri, "api", 1, NewBody("The quick\nbrown fox"))
+
+	rng := func(startLine, startCol, endLine, endCol int) *protocol.Range {
+		return &protocol.Range{
+			Start: protocol.Position{Line: startLine, Column: startCol},
+			End:   protocol.Position{Line: endLine, Column: endCol},
+		}
+	}
+	for _, test := range []struct {
+		name     string
+		changes  []protocol.TextDocumentContentChangeEvent
+		expected string
+	}{
+		{
+			name:     "incremental",
+			changes:  []protocol.TextDocumentContentChangeEvent{{Range: rng(1, 0, 1, 5), Text: "red"}},
+			expected: "The quick\nred fox",
+		},
+		{
+			name:     "full",
+			changes:  []protocol.TextDocumentContentChangeEvent{{Text: "the lazy dog"}},
+			expected: "the lazy dog",
+		},
+		{
+			name: "full then incremental",
+			changes: []protocol.TextDocumentContentChangeEvent{
+				{Text: "jumps over"},
+				{Range: rng(0, 0, 0, 5), Text: "leaps"},
+			},
+			expected: "leaps over",
+		},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		version := len(server.changed) + 2
+		s.OnChangeTextDocument(ctx, protocol.VersionedTextDocumentIdentifier{URI: uri, Version: version}, test.changes)
+		doc := s.documents[uri]
+		assert.For(ctx, "body").ThatString(doc.Body().Text()).Equals(test.expected)
+		assert.For(ctx, "version").That(doc.Version()).Equals(version)
+		assert.For(ctx, "notified").That(server.changed[len(server.changed)-1]).Equals(doc)
+	}
+	// Document bodies are immutable.
+	assert.For(ctx, "original").ThatString(original.Body().Text()).Equals("The quick\nbrown fox")
+}
@@ -47,6 +47,19 @@ func (f Number) Float32() float32 {
 	return f32
 }
 
+// ToFloat32s expands the 16-bit floating point numbers in src to float32s in
+// dst, like Number.Float32. It converts the minimum of len(src) and len(dst)
+// values, and returns the number of values converted.
+func ToFloat32s(dst []float32, src []uint16) int {
+	if len(src) < len(dst) {
+		dst = dst[:len(src)]
+	}
+	for i := range dst {
+		dst[i] = Number(src[i]).Float32()
+	}
+	return len(dst)
+}
+
 // IsNaN reports whether f is an “not-a-number” value.
 func (f Number) IsNaN() bool { return (f&float16ExpMask == float16ExpMask) && (f&float16FracMask != 0) }
 
@@ -97,3 +97,56 @@ func TestFloat32InfTo16(t *testing.T) {
 		t.Errorf("5e-8 did not encode to zero, but %04x.", v)
 	}
 }
+
+func TestFloat16RoundTrip(t *testing.T) {
+	for i := 0; i <= 0xffff; i++ {
+		f := f16.Number(i)
+		f32 := f.Float32()
+		got := f16.From(f32)
+		switch {
+		case f.IsNaN():
+			if !math.IsNaN(float64(f32)) || !got.IsNaN() {
+				t.Errorf("NaN float16(0x%04x) did not round-trip to NaN, but %v and %04x.", i, f32, got)
+			}
+		case got != f:
+			t.Errorf("float16(0x%04x) did not round-trip, but expanded to %g and encoded to %04x.", i, f32, got)
+		}
+	}
+}
+
+func TestFloat16Subnormals(t *testing.T) {
+	for _, c := range []struct {
+		f16 f16.Number
+		f32 float32
+	}{
+		{0x0001, 1.0 / (1 << 24)},    // Smallest subnormal.
+		{0x03ff, 1023.0 / (1 << 24)}, // Largest subnormal.
+		{0x0400, 1.0 / (1 << 14)},    // Smallest normal.
+		{0x8001, -1.0 / (1 << 24)},
+		{0x8000, float32(math.Copysign(0, -1))},
+	} {
+		if got := c.f16.Float32(); got != c.f32 || math.Signbit(float64(got)) != math.Signbit(float64(c.f32)) {
+			t.Errorf("Expansion of float16(0x%04x) gave unexpected value. Expected: %g, got: %g", c.f16, c.f32, got)
+		}
+		if got := f16.From(c.f32); got != c.f16 {
+			t.Errorf("Encoding of float32 %g gave unexpected value. Expected: %04x, got: %04x", c.f32, c.f16, got)
+		}
+	}
+}
+
+func TestToFloat32s(t *testing.T) {
+	src := []uint16{0x3c00, 0xc000, 0x0001, 0x7c00, 0xfc00, 0x7e00}
+	dst := make([]float32, len(src)+1)
+	if n := f16.ToFloat32s(dst, src); n != len(src) {
+		t.Errorf("ToFloat32s converted %d values, expected %d.", n, len(src))
+	}
+	for i, v := range src {
+		expected, got := f16.Number(v).Float32(), dst[i]
+		if got != expected && !(math.IsNaN(float64(got)) && math.IsNaN(float64(expected))) {
+			t.Errorf("ToFloat32s value %d: expected %g, got %g", i, expected, got)
+		}
+	}
+	if n := f16.ToFloat32s(dst[:2], src); n != 2 {
+		t.Errorf("ToFloat32s converted %d values into a slice of 2.", n)
+	}
+}
@@ -33,12 +33,16 @@ List of devices attached
 adb server version (36) doesn't match this client (35); killing...
 * daemon not running. starting it now on port 5037 *
 * daemon started successfully *
+192.168.0.10:5555           device
+192.168.0.11:5555           device
+abi_device                  device
 debug_device                unknown
 debug_device2               unknown
 dumpsys_device              offline
 error_device                device
 install_device              unauthorized
 invalid_device              unknown
+layers_device               device
 logcat_device               unauthorized
 no_pgrep_no_ps_device       unknown
 no_pgrep_ok_ps_device       offline
@@ -47,12 +51,14 @@ ok_pgrep_ok_ps_device       unauthorized
 production_device           unknown
 pull_device                 offline
 push_device                 device
+reverse_device              device
 rooted_device               unauthorized
 run_device                  unknown
 screen_off_locked_device    offline
 screen_off_unlocked_device  offline
 screen_on_locked_device     offline
 screen_on_unlocked_device   device
+secure_settings_device      device
 `)
 	emptyDevices = stub.RespondTo(adbPath.System()+` devices`, `
 List of devices attached
@@ -193,11 +199,53 @@ untagSocket(48) failed with errno -22
 [1] PackageVerificationReceiver.onReceive: Verification requested, id = 331
 `),
 
+		stub.RespondTo(adbPath.System()+` -s dumpsys_device shell getprop ro.debuggable`, `0`),
+		stub.RespondTo(adbPath.System()+` -s dumpsys_device shell pm path com.google.foo`, `package:/data/app/com.google.foo-1/base.apk`),
+		stub.RespondTo(adbPath.System()+` -s dumpsys_device shell pm path com.google.qux`, `
+package:/data/app/com.google.qux-1/split_config.arm64_v8a.apk
+package:/data/app/com.google.qux-1/base.apk
+package:/data/app/com.google.qux-1/split_config.xxhdpi.apk
+`),
+
+		stub.RespondTo(adbPath.System()+` -s reverse_device reverse tcp:0 tcp:8080`, `38417`),
+		stub.RespondTo(adbPath.System()+` -s reverse_device reverse tcp:5000 tcp:8080`, ``),
+		stub.Regex(`adb -s reverse_device reverse --remove tcp:\d+`, stub.Respond("")),
+
+		stub.RespondTo(adbPath.System()+` connect 192.168.0.10:5555`, `connected to 192.168.0.10:5555`),
+		stub.RespondTo(adbPath.System()+` connect 192.168.0.11:5555`, `already connected to 192.168.0.11:5555`),
+		stub.RespondTo(adbPath.System()+` connect 192.168.0.12:5555`, `failed to connect to 192.168.0.12:5555`),
+		stub.Match(adbPath.System()+` connect 192.168.0.13:5555`, &stub.Response{
+			Stderr:  `failed to resolve host: '192.168.0.13'`,
+			WaitErr: fmt.Errorf(`exit status 1`),
+		}),
+		stub.RespondTo(adbPath.System()+` disconnect 192.168.0.10:5555`, `disconnected 192.168.0.10:5555`),
+		stub.Match(adbPath.System()+` disconnect 192.168.0.12:5555`, &stub.Response{
+			Stderr:  `error: no such device '192.168.0.12:5555'`,
+			WaitErr: fmt.Errorf(`exit status 1`),
+		}),
+
+		// GPU debug layer settings responses
+		stub.Regex(`adb -s layers_device shell settings (put|delete) global \S+.*`, stub.Respond("")),
+		stub.Regex(`adb -s secure_settings_device shell settings delete global \S+`, stub.Respond("")),
+		stub.Regex(`adb -s secure_settings_device shell settings put global \S+.*`, &stub.Response{
+			Stdout:  `Security exception: Permission denial: writing to settings requires:android.permission.WRITE_SECURE_SETTINGS`,
+			WaitErr: fmt.Errorf(`exit status 1`),
+		}),
+
+		// ABI responses for a device that fails to report its ABI list
+		stub.Match(adbPath.System()+` -s abi_device shell getprop ro.product.cpu.abilist`, &stub.Response{
+			WaitErr: fmt.Errorf(`exit status 1`),
+		}),
+		stub.RespondTo(adbPath.System()+` -s abi_device shell getprop ro.product.cpu.abi`, `armeabi-v7a`),
+		stub.RespondTo(adbPath.System()+` -s abi_device shell getprop ro.product.cpu.abi2`, `armeabi`),
+
 		// Common responses to all devices
 		stub.Regex(`adb -s .* shell getprop ro\.build\.product`, stub.Respond("flame")),
 		stub.Regex(`adb -s .* shell getprop ro\.build\.version\.release`, stub.Respond("10")),
 		stub.Regex(`adb -s .* shell getprop ro\.build\.description`, stub.Respond("flame-user 10 QQ1A.191003.005 5926727 release-keys")),
 		stub.Regex(`adb -s .* shell getprop ro\.product\.cpu\.abi`, stub.Respond("arm64-v8a")),
+		stub.Regex(`adb -s .* shell getprop ro\.product\.model`, stub.Respond("Pixel 4")),
+		stub.Regex(`adb -s .* shell getprop ro\.product\.manufacturer`, stub.Respond("Google")),
 		stub.Regex(`adb -s .* shell getprop ro\.build\.version\.sdk`, stub.Respond("29")),
 		stub.Regex(`adb -s .* shell setprop persist\.traced\.enable 1`, stub.Respond("")),
 
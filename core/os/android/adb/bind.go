@@ -16,7 +16,9 @@ package adb
 
 import (
 	"context"
+	"sync"
 
+	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/os/android"
 	"github.com/google/gapid/core/os/device/bind"
 	"github.com/google/gapid/core/os/shell"
@@ -36,8 +38,24 @@ type Device interface {
 	Forward(ctx context.Context, local, device Port) error
 	// RemoveForward removes a port forward made by Forward.
 	RemoveForward(ctx context.Context, local Port) error
+	// ReverseForward will forward the specified local Port to the specified
+	// device Port, returning the device Port and a cleanup that removes the
+	// reverse forward. If device is TCPPort(0) then a free port is allocated
+	// on the device.
+	ReverseForward(ctx context.Context, device, local Port) (Port, app.Cleanup, error)
+	// RemoveReverseForward removes a reverse port forward made by ReverseForward.
+	RemoveReverseForward(ctx context.Context, device Port) error
 	// GraphicsDriver queries and returns info on the preview graphics driver.
 	GraphicsDriver(ctx context.Context) (Driver, error)
+	// SetGPUDebugLayers sets up the global settings so that the application
+	// with the package pkg loads the Vulkan, or GLES, layers from the layer
+	// packages layerPkgs. The returned cleanup removes the settings it set.
+	SetGPUDebugLayers(ctx context.Context, pkg string, layerPkgs, layers []string, vulkan bool) (app.Cleanup, error)
+	// ClearGPUDebugLayers removes all the settings used by SetGPUDebugLayers.
+	ClearGPUDebugLayers(ctx context.Context) error
+	// Properties returns the common system properties of the device, such as
+	// its model and supported ABIs.
+	Properties(ctx context.Context) (Properties, error)
 }
 
 // Driver contains the information about a graphics driver.
@@ -63,6 +81,9 @@ func (l DeviceList) FindBySerial(serial string) Device {
 // binding represents an attached Android device.
 type binding struct {
 	bind.Simple
+
+	props      *Properties // Cached result of Properties.
+	propsMutex sync.Mutex  // Guards props.
 }
 
 // verify that binding implements Device
@@ -41,6 +41,11 @@ const (
 	// production build as is not 'rooted'.
 	ErrDeviceNotRooted = fault.Const("Device is not a userdebug build")
 	ErrRootFailed      = fault.Const("Device failed to switch to root")
+	// ErrWriteSecureSettingsDenied is returned when the device does not allow
+	// adb to change the system settings. Some vendor ROMs block the
+	// WRITE_SECURE_SETTINGS permission unless an additional developer option
+	// is enabled.
+	ErrWriteSecureSettingsDenied = fault.Const("Device does not allow changing the secure settings (WRITE_SECURE_SETTINGS)")
 
 	maxRootAttempts                         = 5
 	gpuRenderStagesDataSourceDescriptorName = "gpu.renderstages"
@@ -222,6 +227,9 @@ func (b *binding) SystemSetting(ctx context.Context, namespace, key string) (str
 // to value.
 func (b *binding) SetSystemSetting(ctx context.Context, namespace, key, value string) error {
 	res, err := b.Shell("settings", "put", namespace, key, value).Call(ctx)
+	if isSecurityException(res) {
+		return log.Errf(ctx, ErrWriteSecureSettingsDenied, "settings put %v %v: \n%s", namespace, key, res)
+	}
 	if err != nil {
 		return log.Errf(ctx, nil, "settings put returned error: \n%s", res)
 	}
@@ -231,12 +239,24 @@ func (b *binding) SetSystemSetting(ctx context.Context, namespace, key, value st
 // DeleteSystemSetting removes the system setting with with the given namespaced key.
 func (b *binding) DeleteSystemSetting(ctx context.Context, namespace, key string) error {
 	res, err := b.Shell("settings", "delete", namespace, key).Call(ctx)
+	if isSecurityException(res) {
+		return log.Errf(ctx, ErrWriteSecureSettingsDenied, "settings delete %v %v: \n%s", namespace, key, res)
+	}
 	if err != nil {
 		return log.Errf(ctx, nil, "settings delete returned error: \n%s", res)
 	}
 	return nil
 }
 
+// isSecurityException returns true if the output of the settings command
+// reports that the change was refused by the device. Older devices do not
+// return a failing exit code in this case.
+func isSecurityException(output string) bool {
+	return strings.Contains(output, "SecurityException") ||
+		strings.Contains(output, "Security exception") ||
+		strings.Contains(output, "Permission denial")
+}
+
 // TempFile creates a temporary file on the given Device. It returns the
 // path to the file, and a function that can be called to clean it up.
 func (b *binding) TempFile(ctx context.Context) (string, func(ctx context.Context), error) {
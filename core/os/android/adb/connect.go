@@ -0,0 +1,103 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/google/gapid/core/fault"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/shell"
+)
+
+const (
+	// ErrConnectedDeviceNotFound May be returned if a device connected to over
+	// the network is not listed by adb.
+	ErrConnectedDeviceNotFound = fault.Const("Connected device not found")
+	// The port adbd listens on after 'adb tcpip' if none is given.
+	defaultTCPIPPort = 5555
+)
+
+// Connect asks adb to connect to the device listening on the network address,
+// in the form host[:port], and returns the connected device. The port defaults
+// to 5555 if omitted. Connecting to an already connected device succeeds.
+func Connect(ctx context.Context, address string) (Device, error) {
+	address = tcpipSerial(address)
+	out, err := runNetworkCommand(ctx, "connect", address)
+	if err != nil {
+		return nil, log.Errf(ctx, err, "Failed to connect to %v", address)
+	}
+	if !strings.HasPrefix(out, "connected to") && !strings.HasPrefix(out, "already connected to") {
+		// Older versions of adb exit successfully when failing to connect.
+		return nil, log.Errf(ctx, nil, "Failed to connect to %v: %v", address, out)
+	}
+
+	devices, err := Devices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if d := devices.FindBySerial(address); d != nil {
+		// The device may have changed since it was last connected to.
+		if b, ok := d.(*binding); ok {
+			b.invalidateProperties()
+		}
+		return d, nil
+	}
+	return nil, log.Errf(ctx, ErrConnectedDeviceNotFound, "serial: %v", address)
+}
+
+// Disconnect asks adb to disconnect from the device listening on the network
+// address, in the form host[:port], that was connected to with Connect. The
+// port defaults to 5555 if omitted.
+func Disconnect(ctx context.Context, address string) error {
+	address = tcpipSerial(address)
+	if _, err := runNetworkCommand(ctx, "disconnect", address); err != nil {
+		return log.Errf(ctx, err, "Failed to disconnect from %v", address)
+	}
+	return scanDevices(ctx)
+}
+
+// tcpipSerial returns the serial adb uses for the device at the network
+// address, adding the default port if address has none.
+func tcpipSerial(address string) string {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return net.JoinHostPort(address, strconv.Itoa(defaultTCPIPPort))
+	}
+	return address
+}
+
+// runNetworkCommand runs 'adb <verb> <address>' and returns its trimmed
+// standard output. If adb fails, the returned error holds the standard error
+// of adb, or its standard output if nothing was written to the standard error.
+func runNetworkCommand(ctx context.Context, verb, address string) (string, error) {
+	exe, err := adb()
+	if err != nil {
+		return "", log.Err(ctx, err, "")
+	}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err = shell.Command(exe.System(), verb, address).Capture(stdout, stderr).Run(ctx)
+	out := strings.TrimSpace(stdout.String())
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			out = msg
+		}
+		return "", log.Err(ctx, err, out)
+	}
+	return out, nil
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/adb"
+)
+
+func TestConnect(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	d, err := adb.Connect(ctx, "192.168.0.10")
+	assert.For(ctx, "Connect").ThatError(err).Succeeded()
+	assert.For(ctx, "Connect").ThatString(d.Instance().Serial).Equals("192.168.0.10:5555")
+
+	d, err = adb.Connect(ctx, "192.168.0.11:5555")
+	assert.For(ctx, "Already connected").ThatError(err).Succeeded()
+	assert.For(ctx, "Already connected").ThatString(d.Instance().Serial).Equals("192.168.0.11:5555")
+}
+
+func TestConnectFailed(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	_, err := adb.Connect(ctx, "192.168.0.12")
+	assert.For(ctx, "err").ThatError(err).HasMessage(`Failed to connect to 192.168.0.12:5555: failed to connect to 192.168.0.12:5555`)
+
+	_, err = adb.Connect(ctx, "192.168.0.13")
+	assert.For(ctx, "err").ThatError(err).Failed()
+	assert.For(ctx, "err").ThatString(err).Contains(`failed to resolve host: '192.168.0.13'`)
+}
+
+func TestDisconnect(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	err := adb.Disconnect(ctx, "192.168.0.10")
+	assert.For(ctx, "Disconnect").ThatError(err).Succeeded()
+
+	err = adb.Disconnect(ctx, "192.168.0.12:5555")
+	assert.For(ctx, "err").ThatError(err).Failed()
+	assert.For(ctx, "err").ThatString(err).Contains(`error: no such device '192.168.0.12:5555'`)
+}
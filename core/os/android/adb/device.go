@@ -74,12 +74,27 @@ func RegisterDeviceInfoProvider(f DeviceInfoProvider) {
 
 // Monitor updates the registry with devices that are added and removed at the
 // specified interval. Monitor returns once the context is cancelled.
-func Monitor(ctx context.Context, r *bind.Registry, interval time.Duration) error {
-	unlisten := registry.Listen(bind.NewDeviceListener(r.AddDevice, r.RemoveDevice))
+// Each of the listeners is notified of the Android devices as they connect and
+// disconnect, after the registry has been updated. Devices that were already
+// connected when Monitor was called are reported as connecting.
+func Monitor(ctx context.Context, r *bind.Registry, interval time.Duration, listeners ...bind.DeviceListener) error {
+	onDeviceAdded := func(ctx context.Context, d bind.Device) {
+		r.AddDevice(ctx, d)
+		for _, l := range listeners {
+			l.OnDeviceAdded(ctx, d)
+		}
+	}
+	onDeviceRemoved := func(ctx context.Context, d bind.Device) {
+		r.RemoveDevice(ctx, d)
+		for _, l := range listeners {
+			l.OnDeviceRemoved(ctx, d)
+		}
+	}
+	unlisten := registry.Listen(bind.NewDeviceListener(onDeviceAdded, onDeviceRemoved))
 	defer unlisten()
 
 	for _, d := range registry.Devices() {
-		r.AddDevice(ctx, d)
+		onDeviceAdded(ctx, d)
 	}
 
 	var lastErrorPrinted time.Time
@@ -175,26 +190,7 @@ func newDevice(ctx context.Context, serial string, status bind.Status) (*binding
 	}
 
 	// Check which abis the device says it supports
-	d.To.Configuration.ABIs = d.To.Configuration.ABIs[:0]
-
-	seen := map[string]bool{}
-	for _, prop := range []string{
-		"ro.product.cpu.abilist",
-		"ro.product.cpu.abi",
-		"ro.product.cpu.abi2",
-	} {
-		abis, _ := d.SystemProperty(ctx, prop)
-		if strings.TrimSpace(abis) == "" {
-			continue
-		}
-		for _, abi := range strings.Split(abis, ",") {
-			if seen[abi] {
-				continue
-			}
-			d.To.Configuration.ABIs = append(d.To.Configuration.ABIs, device.ABIByName(abi))
-			seen[abi] = true
-		}
-	}
+	d.To.Configuration.ABIs, _ = d.supportedABIs(ctx)
 
 	// Make sure Perfetto daemons are running.
 	if err := d.EnsurePerfettoPersistent(ctx); err != nil {
@@ -15,13 +15,17 @@
 package adb_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/os/android/adb"
 	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/device/bind"
 	"github.com/google/gapid/core/os/shell/stub"
 )
 
@@ -74,3 +78,69 @@ func TestParseDevices(t_ *testing.T) {
 	assert.For(ctx, "not connected").ThatError(err).HasMessage(`Process returned error
    Cause: Not connected`)
 }
+
+func TestProperties(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	d := mustConnect(ctx, "production_device")
+	expected := adb.Properties{
+		Model:        "Pixel 4",
+		Manufacturer: "Google",
+		SDK:          29,
+		ABIs:         []*device.ABI{device.AndroidARM64v8a},
+	}
+	got, err := d.Properties(ctx)
+	assert.For(ctx, "Properties").ThatError(err).Succeeded()
+	assert.For(ctx, "Properties").That(got).DeepEquals(expected)
+
+	got, err = d.Properties(ctx)
+	assert.For(ctx, "Cached properties").ThatError(err).Succeeded()
+	assert.For(ctx, "Cached properties").That(got).DeepEquals(expected)
+}
+
+func TestSupportedABIs(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	d := mustConnect(ctx, "abi_device")
+	// The ABI list fails to read, but the remaining ABI properties are kept.
+	expected := []*device.ABI{device.AndroidARMv7a, device.AndroidARM}
+	assert.For(ctx, "Instance ABIs").That(d.Instance().Configuration.ABIs).DeepEquals(expected)
+	got, err := d.Properties(ctx)
+	if assert.For(ctx, "Properties").ThatError(err).Succeeded() {
+		assert.For(ctx, "Properties ABIs").That(got.ABIs).DeepEquals(expected)
+	}
+}
+
+func TestMonitor(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	ctx, cancel := task.WithCancel(ctx)
+	defer cancel()
+
+	added := make(chan string)
+	listener := bind.NewDeviceListener(func(ctx context.Context, d bind.Device) {
+		select {
+		case added <- d.Instance().Serial:
+		case <-task.ShouldStop(ctx):
+		}
+	}, nil)
+
+	r := bind.NewRegistry()
+	done := make(chan error, 1)
+	go func() { done <- adb.Monitor(ctx, r, time.Hour, listener) }()
+
+	timeout := time.After(10 * time.Second)
+	for serial := ""; serial != "production_device"; {
+		select {
+		case serial = <-added:
+			registered := false
+			for _, d := range r.Devices() {
+				registered = registered || d.Instance().Serial == serial
+			}
+			assert.For(ctx, "%v registered", serial).That(registered).Equals(true)
+		case <-timeout:
+			assert.For(ctx, "production_device").Error("Device not reported as connected")
+			return
+		}
+	}
+
+	cancel()
+	assert.For(ctx, "Monitor").ThatError(<-done).Succeeded()
+}
@@ -18,8 +18,11 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strconv"
 
+	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/context/keys"
+	"github.com/google/gapid/core/log"
 )
 
 // Port is the interface for sockets ports that can be forwarded from an Android
@@ -93,6 +96,37 @@ func (b *binding) RemoveForward(ctx context.Context, local Port) error {
 	return b.Command("forward", "--remove", local.adbForwardString()).Run(ctx)
 }
 
+// ReverseForward will forward the specified local Port to the specified device
+// Port, so that the device can connect back to the local machine.
+// If device is TCPPort(0) then adb allocates a free port on the device, which
+// is returned. The returned cleanup removes the reverse forward.
+func (b *binding) ReverseForward(ctx context.Context, device, local Port) (Port, app.Cleanup, error) {
+	out, err := b.Command("reverse", device.adbForwardString(), local.adbForwardString()).Call(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if device == TCPPort(0) {
+		port, err := strconv.Atoi(out)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unexpected output: '%s'", out)
+		}
+		device = TCPPort(port)
+	}
+	cleanup := app.Cleanup(func(ctx context.Context) {
+		if err := b.RemoveReverseForward(ctx, device); err != nil {
+			log.W(ctx, "Failed to remove the reverse forward of %v: %v", device.adbForwardString(), err)
+		}
+	})
+	return device, cleanup, nil
+}
+
+// RemoveReverseForward removes a reverse port forward made by ReverseForward.
+func (b *binding) RemoveReverseForward(ctx context.Context, device Port) error {
+	// Clone context to ignore cancellation.
+	ctx = keys.Clone(context.Background(), ctx)
+	return b.Command("reverse", "--remove", device.adbForwardString()).Run(ctx)
+}
+
 // SetupLocalPort makes sure that the given port can be accessed on localhost
 // It returns a new port number to connect to on localhost
 func (b *binding) SetupLocalPort(ctx context.Context, port int) (int, error) {
@@ -0,0 +1,41 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/adb"
+)
+
+func TestReverseForward(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	d := mustConnect(ctx, "reverse_device")
+
+	port, cleanup, err := d.ReverseForward(ctx, adb.TCPPort(5000), adb.TCPPort(8080))
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "port").That(port).Equals(adb.TCPPort(5000))
+	cleanup.Invoke(ctx)
+
+	port, cleanup, err = d.ReverseForward(ctx, adb.TCPPort(0), adb.TCPPort(8080))
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "allocated port").That(port).Equals(adb.TCPPort(38417))
+	cleanup.Invoke(ctx)
+
+	err = d.RemoveReverseForward(ctx, port)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+}
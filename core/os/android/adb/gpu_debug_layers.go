@@ -0,0 +1,91 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+)
+
+// gpuDebugLayerSettings are the global settings used to load GPU debug layers
+// into an application.
+var gpuDebugLayerSettings = []string{
+	"enable_gpu_debug_layers",
+	"gpu_debug_app",
+	"gpu_debug_layer_app",
+	"gpu_debug_layers",
+	"gpu_debug_layers_gles",
+}
+
+// SetGPUDebugLayers sets up the global settings so that the application with
+// the package pkg loads the Vulkan, or GLES, layers from the layer packages
+// layerPkgs. If layers is empty, the application loads the layers it enables
+// itself. The returned cleanup removes the settings that were set, and must be
+// invoked once tracing is done, whether it succeeded or not. If the device
+// does not allow changing the settings then the returned error has the cause
+// ErrWriteSecureSettingsDenied.
+func (b *binding) SetGPUDebugLayers(ctx context.Context, pkg string, layerPkgs, layers []string, vulkan bool) (app.Cleanup, error) {
+	var cleanup app.Cleanup
+	set := func(key, value string) error {
+		if err := b.SetSystemSetting(ctx, "global", key, value); err != nil {
+			return err
+		}
+		cleanup = cleanup.Then(func(ctx context.Context) {
+			log.D(ctx, "Removing setting %v", key)
+			if err := b.DeleteSystemSetting(ctx, "global", key); err != nil {
+				log.W(ctx, "Failed to remove the %v setting: %v", key, err)
+			}
+		})
+		return nil
+	}
+
+	layersSetting := "gpu_debug_layers_gles"
+	if vulkan {
+		layersSetting = "gpu_debug_layers"
+	}
+	if err := set("enable_gpu_debug_layers", "1"); err != nil {
+		return cleanup.Invoke(ctx), err
+	}
+	if err := set("gpu_debug_app", pkg); err != nil {
+		return cleanup.Invoke(ctx), err
+	}
+	if err := set("gpu_debug_layer_app", `"`+strings.Join(layerPkgs, ":")+`"`); err != nil {
+		return cleanup.Invoke(ctx), err
+	}
+	if len(layers) > 0 {
+		if err := set(layersSetting, `"`+strings.Join(layers, ":")+`"`); err != nil {
+			return cleanup.Invoke(ctx), err
+		}
+	} else if err := b.DeleteSystemSetting(ctx, "global", layersSetting); err != nil {
+		return cleanup.Invoke(ctx), err
+	}
+	return cleanup, nil
+}
+
+// ClearGPUDebugLayers removes all the global settings used to load GPU debug
+// layers into an application, such as those left behind by a trace that did
+// not clean up.
+func (b *binding) ClearGPUDebugLayers(ctx context.Context) error {
+	var firstErr error
+	for _, key := range gpuDebugLayerSettings {
+		if err := b.DeleteSystemSetting(ctx, "global", key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,43 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/adb"
+)
+
+func TestSetGPUDebugLayers(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	d := mustConnect(ctx, "layers_device")
+	cleanup, err := d.SetGPUDebugLayers(ctx, "com.google.foo", []string{"com.google.layers"}, []string{"VkLayer_foo"}, true)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "cleanup").That(cleanup != nil).Equals(true)
+	cleanup.Invoke(ctx)
+
+	err = d.ClearGPUDebugLayers(ctx)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+}
+
+func TestSetGPUDebugLayersDenied(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	d := mustConnect(ctx, "secure_settings_device")
+	cleanup, err := d.SetGPUDebugLayers(ctx, "com.google.foo", []string{"com.google.layers"}, nil, false)
+	assert.For(ctx, "err").ThatError(err).HasCause(adb.ErrWriteSecureSettingsDenied)
+	assert.For(ctx, "cleanup").That(cleanup == nil).Equals(true)
+}
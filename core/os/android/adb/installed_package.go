@@ -34,7 +34,12 @@ func (b *binding) InstalledPackages(ctx context.Context) (android.InstalledPacka
 	if err != nil {
 		return nil, log.Errf(ctx, err, "Failed to get installed packages")
 	}
-	return b.parsePackages(str)
+	packages, err := b.parsePackages(str)
+	if err != nil {
+		return nil, err
+	}
+	b.setLayerSupport(ctx, packages)
+	return packages, nil
 }
 
 // InstalledPackage returns information about a single installed package on the
@@ -48,6 +53,7 @@ func (b *binding) InstalledPackage(ctx context.Context, name string) (*android.I
 	if err != nil {
 		return nil, err
 	}
+	b.setLayerSupport(ctx, packages)
 	switch len(packages) {
 	case 0:
 		return nil, fmt.Errorf("Package '%v' not found", name)
@@ -58,6 +64,23 @@ func (b *binding) InstalledPackage(ctx context.Context, name string) (*android.I
 	}
 }
 
+// setLayerSupport sets whether Vulkan and GLES layers can be loaded into each
+// of the packages using the system settings. The device only loads layers into
+// debuggable packages, unless it is running a debuggable build.
+func (b *binding) setLayerSupport(ctx context.Context, packages android.InstalledPackages) {
+	debuggableBuild, err := b.IsDebuggableBuild(ctx)
+	if err != nil {
+		log.W(ctx, "Failed to query whether the device runs a debuggable build: %v", err)
+	}
+	vulkan := android.SupportsVulkanLayersViaSystemSettings(b)
+	gles := android.SupportsGLESLayersViaSystemSettings(b)
+	for _, p := range packages {
+		canLoad := p.Debuggable || debuggableBuild
+		p.VulkanLayers = vulkan && canLoad
+		p.GLESLayers = gles && canLoad
+	}
+}
+
 // The minSdk field was added more recently [see https://goo.gl/UN7oFv]
 var reVersionCodeMinSDKTargetSDK = regexp.MustCompile("^(?:versionCode=([0-9]+))(?: minSdk=([0-9]+))? (?:targetSdk=([0-9]+))?.*$")
 
@@ -57,6 +57,7 @@ func TestParsePackages(t_ *testing.T) {
 		ABI:            device.AndroidARMv7a,
 		Device:         d,
 		Debuggable:     true,
+		VulkanLayers:   true,
 		VersionCode:    123456,
 		MinSDK:         0,
 		TargetSdk:      15,
@@ -101,3 +102,28 @@ func TestPid(t_ *testing.T) {
 	pid, err = get("no_pgrep_ok_ps_device", "com.google.foo")
 	assert.For(ctx, "err").ThatError(err).Equals(android.ErrProcessNotFound)
 }
+
+func TestAPKPaths(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	d := mustConnect(ctx, "dumpsys_device")
+
+	single := &android.InstalledPackage{Name: "com.google.foo", Device: d}
+	paths, err := single.APKPaths(ctx)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "paths").ThatSlice(paths).Equals([]string{"/data/app/com.google.foo-1/base.apk"})
+	path, err := single.Path(ctx)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "path").ThatString(path).Equals("/data/app/com.google.foo-1/base.apk")
+
+	split := &android.InstalledPackage{Name: "com.google.qux", Device: d}
+	paths, err = split.APKPaths(ctx)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "paths").ThatSlice(paths).Equals([]string{
+		"/data/app/com.google.qux-1/split_config.arm64_v8a.apk",
+		"/data/app/com.google.qux-1/base.apk",
+		"/data/app/com.google.qux-1/split_config.xxhdpi.apk",
+	})
+	path, err = split.Path(ctx)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "path").ThatString(path).Equals("/data/app/com.google.qux-1/base.apk")
+}
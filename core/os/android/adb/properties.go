@@ -0,0 +1,119 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+)
+
+// Properties holds the commonly used system properties of a device.
+type Properties struct {
+	// Model is the end-user visible name of the device (ro.product.model).
+	Model string
+	// Manufacturer is the manufacturer of the device (ro.product.manufacturer).
+	Manufacturer string
+	// SDK is the API level of the device's Android build (ro.build.version.sdk).
+	SDK int
+	// ABIs is the list of ABIs supported by the device, in order of preference.
+	ABIs []*device.ABI
+}
+
+// Properties returns the common system properties of the device.
+// The properties are queried on first use and then cached until the device
+// reconnects.
+func (b *binding) Properties(ctx context.Context) (Properties, error) {
+	b.propsMutex.Lock()
+	defer b.propsMutex.Unlock()
+	if b.props != nil {
+		return *b.props, nil
+	}
+
+	model, err := b.SystemProperty(ctx, "ro.product.model")
+	if err != nil {
+		return Properties{}, err
+	}
+	manufacturer, err := b.SystemProperty(ctx, "ro.product.manufacturer")
+	if err != nil {
+		return Properties{}, err
+	}
+	sdk, err := b.SystemProperty(ctx, "ro.build.version.sdk")
+	if err != nil {
+		return Properties{}, err
+	}
+	abis, err := b.supportedABIs(ctx)
+	if err != nil {
+		return Properties{}, err
+	}
+
+	v, _ := strconv.Atoi(strings.TrimSpace(sdk))
+	b.props = &Properties{
+		Model:        strings.TrimSpace(model),
+		Manufacturer: strings.TrimSpace(manufacturer),
+		SDK:          v,
+		ABIs:         abis,
+	}
+	return *b.props, nil
+}
+
+// invalidateProperties drops the cached properties, so that they are queried
+// again on the next call to Properties.
+func (b *binding) invalidateProperties() {
+	b.propsMutex.Lock()
+	defer b.propsMutex.Unlock()
+	b.props = nil
+}
+
+// supportedABIs returns the ABIs the device says it supports, in order of
+// preference. Properties that cannot be read are skipped, so that older
+// devices missing some of them still report the others. An error is only
+// returned if none of the properties could be read.
+func (b *binding) supportedABIs(ctx context.Context) ([]*device.ABI, error) {
+	out := []*device.ABI{}
+	seen := map[string]bool{}
+	props := []string{
+		"ro.product.cpu.abilist",
+		"ro.product.cpu.abi",
+		"ro.product.cpu.abi2",
+	}
+	failed := 0
+	var lastErr error
+	for _, prop := range props {
+		abis, err := b.SystemProperty(ctx, prop)
+		if err != nil {
+			log.W(ctx, "Failed to read %v. Error: %v", prop, err)
+			failed, lastErr = failed+1, err
+			continue
+		}
+		if strings.TrimSpace(abis) == "" {
+			continue
+		}
+		for _, abi := range strings.Split(abis, ",") {
+			if seen[abi] {
+				continue
+			}
+			out = append(out, device.ABIByName(abi))
+			seen[abi] = true
+		}
+	}
+	if failed == len(props) {
+		return nil, lastErr
+	}
+	return out, nil
+}
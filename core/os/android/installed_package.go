@@ -17,6 +17,8 @@ package android
 import (
 	"context"
 	"fmt"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -36,6 +38,8 @@ type InstalledPackage struct {
 	ServiceActions  ServiceActions  // The service actions this package supports.
 	ABI             *device.ABI     // The ABI of the package or empty
 	Debuggable      bool            // Whether the package is debuggable or not
+	VulkanLayers    bool            // Whether Vulkan layers can be loaded into the package
+	GLESLayers      bool            // Whether GLES layers can be loaded into the package
 	VersionCode     int             // The version code as reported by the manifest.
 	VersionName     string          // The version name as reported by the manifest.
 	MinSDK          int             // The minimum SDK reported by the manifest.
@@ -127,18 +131,34 @@ func (p *InstalledPackage) Stop(ctx context.Context) error {
 	return p.Device.Shell("am", "force-stop", p.Name).Run(ctx)
 }
 
-// Path returns the absolute path of the installed package on the device.
+// Path returns the absolute path of the installed package's base APK on the
+// device.
 func (p *InstalledPackage) Path(ctx context.Context) (string, error) {
-	out, err := p.Device.Shell("pm", "path", p.Name).Call(ctx)
+	apks, err := p.APKPaths(ctx)
 	if err != nil {
 		return "", err
 	}
+	return baseAPK(apks), nil
+}
+
+// APKPaths returns the absolute paths of all the APKs of the installed package
+// on the device. Packages installed from an app bundle have a base APK and a
+// number of split APKs, otherwise there is a single APK.
+func (p *InstalledPackage) APKPaths(ctx context.Context) ([]string, error) {
+	out, err := p.Device.Shell("pm", "path", p.Name).Call(ctx)
+	if err != nil {
+		return nil, err
+	}
 	prefix := "package:"
-	if !strings.HasPrefix(out, prefix) {
-		return "", fmt.Errorf("Unexpected output: '%s'", out)
+	paths := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, prefix) {
+			return nil, fmt.Errorf("Unexpected output: '%s'", out)
+		}
+		paths = append(paths, line[len(prefix):])
 	}
-	path := out[len(prefix):]
-	return path, err
+	return paths, nil
 }
 
 func (p *InstalledPackage) obbStoragePath(ctx context.Context) (string, error) {
@@ -266,6 +286,43 @@ func (p *InstalledPackage) Pull(ctx context.Context, target string) error {
 	return p.Device.Pull(ctx, path, target)
 }
 
+// PullAll pulls the installed package's base APK to target, and any split APKs
+// to the directory of target, naming each <package>-<split>.apk. PullAll
+// returns the local paths of the pulled APKs, base APK first.
+func (p *InstalledPackage) PullAll(ctx context.Context, target string) ([]string, error) {
+	apks, err := p.APKPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	base := baseAPK(apks)
+	if err := p.Device.Pull(ctx, base, target); err != nil {
+		return nil, err
+	}
+	pulled := []string{target}
+	for _, apk := range apks {
+		if apk == base {
+			continue
+		}
+		split := strings.TrimSuffix(strings.TrimPrefix(path.Base(apk), "split_"), ".apk")
+		out := filepath.Join(filepath.Dir(target), fmt.Sprintf("%s-%s.apk", p.Name, split))
+		if err := p.Device.Pull(ctx, apk, out); err != nil {
+			return nil, err
+		}
+		pulled = append(pulled, out)
+	}
+	return pulled, nil
+}
+
+// baseAPK returns the base APK of the APK paths returned by APKPaths.
+func baseAPK(apks []string) string {
+	for _, apk := range apks {
+		if path.Base(apk) == "base.apk" {
+			return apk
+		}
+	}
+	return apks[0]
+}
+
 // Uninstall uninstalls the package from the device.
 func (p *InstalledPackage) Uninstall(ctx context.Context) error {
 	return p.Device.Shell("pm", "uninstall", p.Name).Run(ctx)
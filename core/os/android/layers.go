@@ -14,14 +14,6 @@
 
 package android
 
-import (
-	"context"
-	"strings"
-
-	"github.com/google/gapid/core/app"
-	"github.com/google/gapid/core/log"
-)
-
 const eglLayersExt = "EGL_ANDROID_GLES_layers"
 
 // SupportsGLESLayersViaSystemSettings returns whether the given device supports
@@ -43,47 +35,3 @@ func SupportsVulkanLayersViaSystemSettings(d Device) bool {
 	apiVersion := d.Instance().GetConfiguration().GetOS().GetAPIVersion()
 	return apiVersion >= 28
 }
-
-// SetupLayer initializes d to use either a Vulkan or GLES layer from layerPkgs
-// limited to the app with package appPkg using the system settings and returns
-// a cleanup to remove the layer settings.
-func SetupLayers(ctx context.Context, d Device, appPkg string, layerPkgs []string, layers []string, vulkan bool) (app.Cleanup, error) {
-	var cleanup app.Cleanup
-	// pushSetting changes a device property for the duration of the trace.
-	pushSetting := func(ns, key, val string) error {
-		cleanup = cleanup.Then(func(ctx context.Context) {
-			log.D(ctx, "Removing setting %v", key)
-			d.DeleteSystemSetting(ctx, ns, key)
-		})
-		return d.SetSystemSetting(ctx, ns, key, val)
-	}
-
-	if err := pushSetting("global", "enable_gpu_debug_layers", "1"); err != nil {
-		return cleanup.Invoke(ctx), err
-	}
-	if err := pushSetting("global", "gpu_debug_app", appPkg); err != nil {
-		return cleanup.Invoke(ctx), err
-	}
-	if err := pushSetting("global", "gpu_debug_layer_app", "\""+strings.Join(layerPkgs, ":")+"\""); err != nil {
-		return cleanup.Invoke(ctx), err
-	}
-	if len(layers) > 0 {
-		if vulkan {
-			if err := pushSetting("global", "gpu_debug_layers", "\""+strings.Join(layers, ":")+"\""); err != nil {
-				return cleanup.Invoke(ctx), err
-			}
-		} else {
-			if err := pushSetting("global", "gpu_debug_layers_gles", "\""+strings.Join(layers, ":")+"\""); err != nil {
-				return cleanup.Invoke(ctx), err
-			}
-		}
-	} else {
-		if vulkan {
-			d.DeleteSystemSetting(ctx, "global", "gpu_debug_layers")
-		} else {
-			d.DeleteSystemSetting(ctx, "global", "gpu_debug_layers_gles")
-		}
-	}
-
-	return cleanup, nil
-}
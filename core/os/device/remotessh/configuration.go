@@ -41,6 +41,20 @@ type Configuration struct {
 	KnownHosts string `json:"knownHostsPath"`
 	// Environment variables to set on the connection
 	Env []string
+	// A comma separated list of [user@]host[:port] jump hosts to tunnel the
+	// connection through, in the same form as the ssh ProxyJump option.
+	ProxyJump string `json:"proxyJump"`
+	// A local command whose standard input and output is used for the
+	// connection, in the same form as the ssh ProxyCommand option. The %h, %p
+	// and %r tokens are replaced with the host, port and user.
+	ProxyCommand string `json:"proxyCommand"`
+	// The interval in seconds at which keepalive requests are sent over the
+	// connection, like the ssh ServerAliveInterval option. 0 disables them.
+	ServerAliveInterval uint32 `json:"serverAliveInterval"`
+	// The number of keepalive requests in a row that can go unanswered before
+	// the connection is dropped, like the ssh ServerAliveCountMax option.
+	// Defaults to 3.
+	ServerAliveCountMax uint32 `json:"serverAliveCountMax"`
 }
 
 // ReadConfigurations reads a set of configurations from then
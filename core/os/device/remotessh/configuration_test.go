@@ -49,6 +49,16 @@ func TestReadConfiguration(t *testing.T) {
 		"keyPath": "id_dsa",
 		"knownHostsPath": "someFile",
 		"user": "me"
+	},
+	{
+		"Name": "BehindBastion",
+		"host": "10.0.0.5",
+		"user": "me",
+		"keyPath": "id_dsa",
+		"knownHostsPath": "someFile",
+		"proxyJump": "admin@bastion.example.com:2222",
+		"proxyCommand": "nc -X connect %h %p",
+		"serverAliveInterval": 30
 	}
 ]
 `
@@ -82,6 +92,17 @@ func TestReadConfiguration(t *testing.T) {
 			Keyfile:    "id_dsa",
 			KnownHosts: "someFile",
 		},
+		remotessh.Configuration{
+			Name:                "BehindBastion",
+			User:                "me",
+			Host:                "10.0.0.5",
+			Port:                22,
+			Keyfile:             "id_dsa",
+			KnownHosts:          "someFile",
+			ProxyJump:           "admin@bastion.example.com:2222",
+			ProxyCommand:        "nc -X connect %h %p",
+			ServerAliveInterval: 30,
+		},
 	} {
 		assert.For(ctx, "configs[%v]", i).That(configs[i]).DeepEquals(test)
 	}
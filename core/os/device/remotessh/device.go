@@ -66,6 +66,7 @@ type binding struct {
 
 	connection    *ssh.Client
 	configuration *Configuration
+	lost          *connectionLost
 	env           *shell.Env
 	// We duplicate OS here because we need to use it
 	// before we get the rest of the information
@@ -102,6 +103,7 @@ func newBinding(conn *ssh.Client, conf *Configuration, env *shell.Env) *binding
 	b := &binding{
 		connection:    conn,
 		configuration: conf,
+		lost:          &connectionLost{},
 		env:           env,
 		ch:            make(chan int, MaxNumberOfSSHConnections),
 		Simple: bind.Simple{
@@ -120,6 +122,9 @@ func (b *binding) newPooledSession() (*pooledSession, error) {
 	session, err := b.connection.NewSession()
 	if err != nil {
 		<-b.ch
+		if b.lost.get() != nil {
+			return nil, b.connectionError(err)
+		}
 		err = fmt.Errorf("New SSH Session Error: %v, Current maximum number of ssh connections GAPID can issue to each remote device is: %v", err, MaxNumberOfSSHConnections)
 		return nil, err
 	}
@@ -307,7 +312,7 @@ func GetConnectedDevice(ctx context.Context, c Configuration) (Device, error) {
 		HostKeyCallback: hosts,
 	}
 
-	connection, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", c.Host, c.Port), sshConfig)
+	connection, err := dial(ctx, c, sshConfig)
 	if err != nil {
 		return nil, log.Errf(ctx, err, "Dial tcp: %s:%d with sshConfig: %v failed", c.Host, c.Port, sshConfig)
 	}
@@ -319,6 +324,7 @@ func GetConnectedDevice(ctx context.Context, c Configuration) (Device, error) {
 	}
 
 	b := newBinding(connection, &c, env)
+	watchConnection(ctx, connection, b.configuration, b.lost)
 
 	kind := device.UnknownOS
 
@@ -376,7 +382,7 @@ func GetConnectedDevice(ctx context.Context, c Configuration) (Device, error) {
 	var device device.Instance
 
 	if err := jsonpb.Unmarshal(bytes.NewReader([]byte(devInfo)), &device); err != nil {
-		panic(err)
+		return nil, log.Errf(ctx, err, "Could not parse device info")
 	}
 
 	device.Name = c.Name
@@ -52,7 +52,7 @@ func (b binding) doTunnel(ctx context.Context, local net.Conn, remotePort Port)
 	remote, err := b.connection.Dial("tcp", fmt.Sprintf("localhost:%d", remotePort))
 	if err != nil {
 		local.Close()
-		return err
+		return b.connectionError(err)
 	}
 
 	wg := sync.WaitGroup{}
@@ -0,0 +1,140 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotessh
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/log"
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultServerAliveCountMax is the number of unanswered keepalive requests
+// after which the connection is dropped, if not set by the configuration.
+const defaultServerAliveCountMax = 3
+
+// connectionLost records the reason the SSH connection of a binding was lost.
+type connectionLost struct {
+	mutex sync.Mutex
+	err   error
+}
+
+// set records err as the reason the connection was lost, unless a reason has
+// already been recorded.
+func (l *connectionLost) set(err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// get returns the reason the connection was lost, or nil if it is still open.
+func (l *connectionLost) get() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.err
+}
+
+// keepaliveConn is the part of an SSH connection used to keep it alive.
+type keepaliveConn interface {
+	SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error)
+	Wait() error
+	Close() error
+}
+
+// watchConnection records the reason conn is lost in lost once it closes.
+// If c has a ServerAliveInterval, a keepalive request is sent over conn at
+// that interval, and conn is closed once ServerAliveCountMax intervals in a
+// row pass without a reply.
+func watchConnection(ctx context.Context, conn *ssh.Client, c *Configuration, lost *connectionLost) {
+	countMax := int(c.ServerAliveCountMax)
+	if countMax == 0 {
+		countMax = defaultServerAliveCountMax
+	}
+	interval := time.Duration(c.ServerAliveInterval) * time.Second
+	keepAlive(ctx, conn, c.Name, interval, countMax, lost)
+}
+
+// keepAlive records the reason conn is lost in lost once it closes, and
+// closes conn once countMax intervals pass without a reply to a keepalive
+// request. No keepalive requests are sent if interval is 0.
+func keepAlive(ctx context.Context, conn keepaliveConn, name string, interval time.Duration, countMax int, lost *connectionLost) {
+	closed := make(chan struct{})
+	crash.Go(func() {
+		err := conn.Wait()
+		if err == nil {
+			err = fmt.Errorf("Connection closed")
+		}
+		lost.set(err)
+		close(closed)
+	})
+
+	if interval == 0 {
+		return
+	}
+
+	crash.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		// Only one request is sent at a time. Closing the connection makes a
+		// request waiting for its reply return, so the sender never leaks.
+		reply := make(chan error, 1)
+		waiting, missed := false, 0
+		for {
+			select {
+			case <-closed:
+				return
+			case err := <-reply:
+				if err != nil {
+					return // Connection closed.
+				}
+				waiting, missed = false, 0
+			case <-ticker.C:
+				if !waiting {
+					waiting = true
+					crash.Go(func() {
+						// Servers reply to unknown requests with a failure,
+						// which is as good as a success for keeping the
+						// connection alive.
+						_, _, err := conn.SendRequest("keepalive@openssh.com", true, nil)
+						reply <- err
+					})
+					continue
+				}
+				if missed++; missed >= countMax {
+					err := fmt.Errorf("No reply to a keepalive request for %v", time.Duration(missed)*interval)
+					log.W(ctx, "Dropping SSH connection to %s: %v", name, err)
+					lost.set(err)
+					conn.Close()
+					return
+				}
+			}
+		}
+	})
+}
+
+// connectionError returns an error describing why the SSH connection of b was
+// lost if it has been, otherwise err.
+func (b *binding) connectionError(err error) error {
+	if lost := b.lost.get(); lost != nil {
+		return fmt.Errorf("SSH connection to %s lost: %v", b.configuration.Name, lost)
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotessh
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+)
+
+const testInterval = 10 * time.Millisecond
+
+// fakeConn is a keepaliveConn whose server either replies to every request,
+// or stops replying while it is closed when silent is set.
+type fakeConn struct {
+	silent   bool
+	requests int32
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func newFakeConn(silent bool) *fakeConn {
+	return &fakeConn{silent: silent, closed: make(chan struct{})}
+}
+
+func (c *fakeConn) SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+	atomic.AddInt32(&c.requests, 1)
+	if c.silent {
+		<-c.closed
+		return false, nil, io.EOF
+	}
+	return false, nil, nil
+}
+
+func (c *fakeConn) Wait() error {
+	<-c.closed
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+// waitLost waits for lost to be set, returning nil if it is not set in time.
+func waitLost(lost *connectionLost) error {
+	for end := time.Now().Add(5 * time.Second); time.Now().Before(end); time.Sleep(time.Millisecond) {
+		if err := lost.get(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestKeepAliveDropsSilentConnection(t *testing.T) {
+	ctx := log.Testing(t)
+	conn, lost := newFakeConn(true), &connectionLost{}
+	start := time.Now()
+	keepAlive(ctx, conn, "test", testInterval, 3, lost)
+
+	err := waitLost(lost)
+	if !assert.For(ctx, "err").ThatError(err).Failed() {
+		return
+	}
+	assert.For(ctx, "err").ThatString(err.Error()).HasPrefix("No reply")
+	assert.For(ctx, "dropped after").That(time.Since(start) >= 4*testInterval).Equals(true)
+	select {
+	case <-conn.closed:
+	default:
+		t.Error("The connection was not closed")
+	}
+	// Requests are not sent while one is waiting for its reply.
+	assert.For(ctx, "requests").That(atomic.LoadInt32(&conn.requests)).Equals(int32(1))
+}
+
+func TestKeepAliveAnswered(t *testing.T) {
+	ctx := log.Testing(t)
+	conn, lost := newFakeConn(false), &connectionLost{}
+	keepAlive(ctx, conn, "test", testInterval, 2, lost)
+
+	time.Sleep(10 * testInterval)
+	assert.For(ctx, "lost").ThatError(lost.get()).Succeeded()
+	assert.For(ctx, "requests").That(atomic.LoadInt32(&conn.requests) > 2).Equals(true)
+
+	conn.Close()
+	assert.For(ctx, "lost").ThatError(waitLost(lost)).HasMessage("Connection closed")
+}
+
+func TestKeepAliveDisabled(t *testing.T) {
+	ctx := log.Testing(t)
+	conn, lost := newFakeConn(false), &connectionLost{}
+	keepAlive(ctx, conn, "test", 0, 3, lost)
+
+	time.Sleep(5 * testInterval)
+	assert.For(ctx, "requests").That(atomic.LoadInt32(&conn.requests)).Equals(int32(0))
+	conn.Close()
+	assert.For(ctx, "lost").ThatError(waitLost(lost)).Failed()
+}
+
+func TestConnectionError(t *testing.T) {
+	ctx := log.Testing(t)
+	b := &binding{configuration: &Configuration{Name: "device"}, lost: &connectionLost{}}
+	cause := errors.New("command failed")
+
+	assert.For(ctx, "open").ThatError(b.connectionError(cause)).Equals(cause)
+
+	b.lost.set(errors.New("reset"))
+	b.lost.set(errors.New("later"))
+	assert.For(ctx, "lost").ThatError(b.connectionError(cause)).HasMessage(
+		"SSH connection to device lost: reset")
+}
@@ -0,0 +1,238 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotessh
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/shell"
+	"golang.org/x/crypto/ssh"
+)
+
+// jumpHost is a single host of a ProxyJump list.
+type jumpHost struct {
+	user string
+	host string
+	port uint16
+}
+
+func (j jumpHost) addr() string {
+	return net.JoinHostPort(j.host, strconv.Itoa(int(j.port)))
+}
+
+// parseProxyJump parses the comma separated list of [user@]host[:port] jump
+// hosts of spec. Hosts without a user or port use the given defaults.
+func parseProxyJump(spec, user string) ([]jumpHost, error) {
+	out := []jumpHost{}
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		j := jumpHost{user: user, host: s, port: 22}
+		if i := strings.LastIndex(s, "@"); i >= 0 {
+			j.user, j.host = s[:i], s[i+1:]
+		}
+		if host, port, err := net.SplitHostPort(j.host); err == nil {
+			p, err := strconv.ParseUint(port, 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid port in jump host '%s'", s)
+			}
+			j.host, j.port = host, uint16(p)
+		}
+		if j.host == "" || j.user == "" {
+			return nil, fmt.Errorf("Invalid jump host '%s'", s)
+		}
+		out = append(out, j)
+	}
+	return out, nil
+}
+
+// expandProxyCommand returns the ProxyCommand of c with the %h, %p, %r and %%
+// tokens replaced with the host, port, user and a literal '%'.
+func expandProxyCommand(c Configuration) (string, error) {
+	out := strings.Builder{}
+	for i := 0; i < len(c.ProxyCommand); i++ {
+		if c.ProxyCommand[i] != '%' {
+			out.WriteByte(c.ProxyCommand[i])
+			continue
+		}
+		if i++; i == len(c.ProxyCommand) {
+			return "", fmt.Errorf("Incomplete token at end of ProxyCommand '%s'", c.ProxyCommand)
+		}
+		switch c.ProxyCommand[i] {
+		case 'h':
+			out.WriteString(c.Host)
+		case 'p':
+			out.WriteString(strconv.Itoa(int(c.Port)))
+		case 'r':
+			out.WriteString(c.User)
+		case '%':
+			out.WriteByte('%')
+		default:
+			return "", fmt.Errorf("Unknown token %%%c in ProxyCommand '%s'", c.ProxyCommand[i], c.ProxyCommand)
+		}
+	}
+	return out.String(), nil
+}
+
+// dial opens an SSH connection to the host of c, using sshConfig.
+// If c has a ProxyCommand, the connection is made over the standard input and
+// output of that command. If c has a ProxyJump list, the connection is
+// tunneled through each of the jump hosts in turn, each of which is
+// authenticated with sshConfig.
+func dial(ctx context.Context, c Configuration, sshConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(int(c.Port)))
+	jump, command := c.ProxyJump != "" && c.ProxyJump != "none", c.ProxyCommand != "" && c.ProxyCommand != "none"
+
+	switch {
+	case jump && command:
+		return nil, log.Errf(ctx, nil, "Only one of ProxyJump and ProxyCommand can be used for SSH connection %s", c.Name)
+
+	case command:
+		conn, err := startProxyCommand(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		return newClient(conn, addr, sshConfig)
+
+	case jump:
+		hosts, err := parseProxyJump(c.ProxyJump, c.User)
+		if err != nil {
+			return nil, log.Err(ctx, err, "Invalid ProxyJump")
+		}
+		var client *ssh.Client
+		jumpClients := []*ssh.Client{}
+		closeAll := func() {
+			for _, j := range jumpClients {
+				j.Close()
+			}
+		}
+		for _, h := range hosts {
+			cfg := *sshConfig
+			cfg.User = h.user
+			if client, err = dialVia(client, h.addr(), &cfg); err != nil {
+				closeAll()
+				return nil, log.Errf(ctx, err, "Dial jump host %s failed", h.addr())
+			}
+			jumpClients = append(jumpClients, client)
+		}
+		if client, err = dialVia(client, addr, sshConfig); err != nil {
+			closeAll()
+			return nil, err
+		}
+		// Closing a client does not close the clients it was tunneled through.
+		crash.Go(func() {
+			client.Wait()
+			closeAll()
+		})
+		return client, nil
+
+	default:
+		return ssh.Dial("tcp", addr, sshConfig)
+	}
+}
+
+// dialVia opens an SSH connection to addr, tunneled through via if not nil.
+func dialVia(via *ssh.Client, addr string, sshConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	if via == nil {
+		return ssh.Dial("tcp", addr, sshConfig)
+	}
+	conn, err := via.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn, addr, sshConfig)
+}
+
+// newClient starts an SSH client connection to addr over conn.
+func newClient(conn net.Conn, addr string, sshConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// startProxyCommand starts the ProxyCommand of c on the local machine and
+// returns a connection over its standard input and output.
+func startProxyCommand(ctx context.Context, c Configuration) (net.Conn, error) {
+	command, err := expandProxyCommand(c)
+	if err != nil {
+		return nil, log.Err(ctx, err, "Invalid ProxyCommand")
+	}
+	cmd := shell.Command("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = shell.Command("cmd", "/C", command)
+	}
+
+	// OS pipes are handed directly to the process, so that reads see the end
+	// of the stream as soon as the process exits.
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		stdinR.Close()
+		stdinW.Close()
+		return nil, err
+	}
+	process, err := cmd.Read(stdinR).Capture(stdoutW, nil).Start(ctx)
+	stdinR.Close()
+	stdoutW.Close()
+	if err != nil {
+		stdinW.Close()
+		stdoutR.Close()
+		return nil, log.Errf(ctx, err, "Could not start ProxyCommand '%s'", command)
+	}
+	crash.Go(func() { process.Wait(ctx) })
+	return &commandConn{stdout: stdoutR, stdin: stdinW, process: process}, nil
+}
+
+// commandConn is a net.Conn over the standard input and output of a process.
+type commandConn struct {
+	stdout  *os.File
+	stdin   *os.File
+	process shell.Process
+}
+
+func (c *commandConn) Read(b []byte) (int, error)  { return c.stdout.Read(b) }
+func (c *commandConn) Write(b []byte) (int, error) { return c.stdin.Write(b) }
+
+func (c *commandConn) Close() error {
+	c.stdin.Close()
+	c.stdout.Close()
+	return c.process.Kill()
+}
+
+func (c *commandConn) LocalAddr() net.Addr                { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr               { return commandAddr{} }
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// commandAddr is the net.Addr of both ends of a commandConn.
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "proxycommand" }
+func (commandAddr) String() string  { return "proxycommand" }
@@ -0,0 +1,70 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotessh
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+)
+
+func TestParseProxyJump(t *testing.T) {
+	ctx := log.Testing(t)
+
+	for _, test := range []struct {
+		spec     string
+		expected []jumpHost
+	}{
+		{"bastion", []jumpHost{{"me", "bastion", 22}}},
+		{"admin@bastion:2222", []jumpHost{{"admin", "bastion", 2222}}},
+		{"a@one, two:23", []jumpHost{{"a", "one", 22}, {"me", "two", 23}}},
+		{"[::1]:24", []jumpHost{{"me", "::1", 24}}},
+	} {
+		got, err := parseProxyJump(test.spec, "me")
+		assert.For(ctx, "err %v", test.spec).ThatError(err).Succeeded()
+		assert.For(ctx, "hosts %v", test.spec).That(got).DeepEquals(test.expected)
+	}
+
+	for _, spec := range []string{"", "a,,b", "me@", "host:port"} {
+		_, err := parseProxyJump(spec, "me")
+		assert.For(ctx, "err %v", spec).ThatError(err).Failed()
+	}
+}
+
+func TestExpandProxyCommand(t *testing.T) {
+	ctx := log.Testing(t)
+
+	c := Configuration{Host: "device", Port: 22, User: "me"}
+	for _, test := range []struct {
+		command  string
+		expected string
+	}{
+		{"nc %h %p", "nc device 22"},
+		{"ssh -W %h:%p %r@bastion", "ssh -W device:22 me@bastion"},
+		{"echo 100%%", "echo 100%"},
+	} {
+		c.ProxyCommand = test.command
+		got, err := expandProxyCommand(c)
+		assert.For(ctx, "err %v", test.command).ThatError(err).Succeeded()
+		assert.For(ctx, "command %v", test.command).That(got).Equals(test.expected)
+	}
+
+	for _, command := range []string{"nc %h %", "nc %x"} {
+		c.ProxyCommand = command
+		_, err := expandProxyCommand(c)
+		assert.For(ctx, "err %v", command).ThatError(err).Failed()
+	}
+}
@@ -24,7 +24,9 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/google/gapid/core/fault"
 	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/text"
 )
 
 // Cmd holds the configuration to run an external command.
@@ -51,8 +53,22 @@ type Cmd struct {
 	Stdin io.Reader
 	// Environment is the processes environment, if set.
 	Environment *Env
+	// ProcessGroup starts the command in a new process group on the local
+	// machine, so that killing the command, or cancelling the context it is
+	// waited on with, also kills all the processes it has started.
+	ProcessGroup bool
+	// OnStdout is called with each line of the command's standard output as it
+	// is written, if set. The output is still written to Stdout.
+	OnStdout func(line string)
+	// OnStderr is called with each line of the command's standard error as it
+	// is written, if set. The output is still written to Stderr.
+	OnStderr func(line string)
 }
 
+// ErrTimeout is returned when waiting on a local process whose context
+// deadline was exceeded. The process is killed.
+const ErrTimeout = fault.Const("Process timed out")
+
 // Command returns a Cmd with the specified command and arguments set.
 func Command(name string, args ...string) Cmd {
 	return Cmd{Name: name, Args: args}
@@ -83,6 +99,20 @@ func (cmd Cmd) Capture(stdout, stderr io.Writer) Cmd {
 	return cmd
 }
 
+// Group returns a copy of the Cmd with the ProcessGroup flag set to true.
+func (cmd Cmd) Group() Cmd {
+	cmd.ProcessGroup = true
+	return cmd
+}
+
+// Stream returns a copy of the Cmd with OnStdout and OnStderr set.
+// The two functions may be called concurrently.
+func (cmd Cmd) Stream(onStdout, onStderr func(line string)) Cmd {
+	cmd.OnStdout = onStdout
+	cmd.OnStderr = onStderr
+	return cmd
+}
+
 // Read returns a copy of the Cmd with Stdin set.
 func (cmd Cmd) Read(stdin io.Reader) Cmd {
 	cmd.Stdin = stdin
@@ -135,6 +165,24 @@ func (cmd Cmd) Start(ctx context.Context) (Process, error) {
 			cmd.Stderr = logStderr
 		}
 	}
+	// Split the output into lines for the streaming callbacks
+	streams := []io.Closer{}
+	stream := func(w io.Writer, f func(string)) io.Writer {
+		if f == nil {
+			return w
+		}
+		lines := text.Writer(func(line string) error {
+			f(line)
+			return nil
+		})
+		streams = append(streams, lines)
+		if w == nil {
+			return lines
+		}
+		return io.MultiWriter(w, lines)
+	}
+	cmd.Stdout = stream(cmd.Stdout, cmd.OnStdout)
+	cmd.Stderr = stream(cmd.Stderr, cmd.OnStderr)
 	// Ready to start
 	if cmd.Verbosity {
 		extra := ""
@@ -143,7 +191,38 @@ func (cmd Cmd) Start(ctx context.Context) (Process, error) {
 		}
 		log.I(ctx, "Exec: %v%s", cmd, extra)
 	}
-	return cmd.Target.Start(cmd)
+	process, err := cmd.Target.Start(cmd)
+	if err != nil || len(streams) == 0 {
+		return process, err
+	}
+	return &streamingProcess{Process: process, streams: streams}, nil
+}
+
+// streamingProcess is a Process that flushes the partial last lines of output
+// to the streaming callbacks once the process has completed.
+type streamingProcess struct {
+	Process
+	streams []io.Closer
+	flush   sync.Once
+}
+
+func (p *streamingProcess) Wait(ctx context.Context) error {
+	err := p.Process.Wait(ctx)
+	p.flush.Do(func() {
+		for _, s := range p.streams {
+			s.Close()
+		}
+	})
+	return err
+}
+
+// PID returns the operating system identifier of the process, or 0 if the
+// process does not have one.
+func (p *streamingProcess) PID() int {
+	if pid, ok := p.Process.(interface{ PID() int }); ok {
+		return pid.PID()
+	}
+	return 0
 }
 
 // Run executes the command, and blocks until it completes or the context is cancelled.
@@ -19,6 +19,7 @@ package shell_test
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/google/gapid/core/assert"
 	"github.com/google/gapid/core/event/task"
@@ -121,3 +122,37 @@ func TestCommandOn(t *testing.T) {
 	assert.For(ctx, "err").ThatError(err).HasMessage(`Failed to start process
    Cause: AlwaysFail`)
 }
+
+func TestCommandStream(t *testing.T) {
+	ctx := log.Testing(t)
+	lines := []string{}
+	output, err := shell.Command("printf", `one\ntwo\nthree`).Stream(func(line string) {
+		lines = append(lines, line)
+	}, nil).Call(ctx)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "lines").ThatSlice(lines).Equals([]string{"one", "two", "three"})
+	assert.For(ctx, "output").ThatString(output).Equals("one\ntwo\nthree")
+}
+
+func TestCommandTimeout(t *testing.T) {
+	ctx := log.Testing(t)
+	child, cancel := task.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	err := shell.Command("sleep", "10").Run(child)
+	assert.For(ctx, "err").ThatError(err).HasCause(shell.ErrTimeout)
+}
+
+func TestCommandGroupKill(t *testing.T) {
+	ctx := log.Testing(t)
+	// The shell forks sleep, which inherits the output pipe. Wait only returns
+	// once the pipe is closed, so sleep has to be killed along with the shell.
+	buf := &bytes.Buffer{}
+	p, err := shell.Command("sh", "-c", "sleep 10; echo done").Capture(buf, nil).Group().Start(ctx)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	p.Kill()
+	p.Wait(ctx)
+	assert.For(ctx, "killed quickly").That(time.Since(start) < 5*time.Second).Equals(true)
+	assert.For(ctx, "buf").ThatString(buf).Equals("")
+}
@@ -30,7 +30,8 @@ var (
 type localTarget struct{}
 
 type localProcess struct {
-	exec *exec.Cmd
+	exec  *exec.Cmd
+	group *processGroup
 }
 
 func (localTarget) Start(cmd Cmd) (Process, error) {
@@ -42,22 +43,56 @@ func (localTarget) Start(cmd Cmd) (Process, error) {
 	p.exec.Stderr = cmd.Stderr
 	p.exec.Stdin = cmd.Stdin
 	p.exec.Env = cmd.Environment.Vars()
-	return p, p.exec.Start()
+	if cmd.ProcessGroup {
+		p.group = newProcessGroup(p.exec)
+	}
+	if err := p.exec.Start(); err != nil {
+		return p, err
+	}
+	if p.group != nil {
+		if err := p.group.add(p.exec.Process); err != nil {
+			// Only the process itself can be killed.
+			p.group = nil
+		}
+	}
+	return p, nil
 }
 
 func (p *localProcess) Wait(ctx context.Context) error {
 	res := make(chan error, 1)
-	go func() { res <- p.exec.Wait() }()
+	go func() {
+		err := p.exec.Wait()
+		if p.group != nil {
+			p.group.release()
+		}
+		res <- err
+	}()
 	select {
 	case err := <-res:
 		return err
 	case <-task.ShouldStop(ctx):
-		log.W(ctx, "Killing %v (context cancelled)", p.exec.Path)
-		p.exec.Process.Kill()
-		return task.StopReason(ctx)
+		err := task.StopReason(ctx)
+		if err == context.DeadlineExceeded {
+			log.W(ctx, "Killing %v (context deadline exceeded)", p.exec.Path)
+			err = ErrTimeout
+		} else {
+			log.W(ctx, "Killing %v (context cancelled)", p.exec.Path)
+		}
+		p.Kill()
+		return err
 	}
 }
 
 func (p *localProcess) Kill() error {
+	if p.group != nil {
+		if err := p.group.kill(p.exec.Process); err == nil {
+			return nil
+		}
+	}
 	return p.exec.Process.Kill()
 }
+
+// PID returns the operating system identifier of the process.
+func (p *localProcess) PID() int {
+	return p.exec.Process.Pid
+}
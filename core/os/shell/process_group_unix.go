@@ -0,0 +1,45 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux darwin
+
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// processGroup is the process group of a command started with ProcessGroup
+// set.
+type processGroup struct{}
+
+// newProcessGroup makes cmd start in a new process group.
+func newProcessGroup(cmd *exec.Cmd) *processGroup {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return &processGroup{}
+}
+
+// add adds the started process p to the group. The process is the leader of
+// its own group, so there is nothing to do.
+func (g *processGroup) add(p *os.Process) error { return nil }
+
+// kill kills all the processes in the process group led by p.
+func (g *processGroup) kill(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
+
+// release releases the resources of the group once p has exited.
+func (g *processGroup) release() {}
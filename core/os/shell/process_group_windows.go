@@ -0,0 +1,149 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	createJobObject          = kernel32.NewProc("CreateJobObjectW")
+	setInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	assignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	terminateJobObject       = kernel32.NewProc("TerminateJobObject")
+)
+
+const (
+	jobObjectExtendedLimitInformation = 9
+	jobObjectLimitKillOnJobClose      = 0x00002000
+	processSetQuota                   = 0x0100
+	processTerminate                  = 0x0001
+)
+
+// jobObjectBasicLimitInformation is JOBOBJECT_BASIC_LIMIT_INFORMATION.
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+// jobObjectExtendedLimitInfo is JOBOBJECT_EXTENDED_LIMIT_INFORMATION.
+type jobObjectExtendedLimitInfo struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                [6]uint64
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// processGroup is the job object holding a command started with
+// ProcessGroup set, and all the processes it starts.
+// The job is killed when its handle is closed, so the processes do not
+// outlive this process if it exits without killing them.
+type processGroup struct {
+	mutex sync.Mutex
+	job   syscall.Handle
+}
+
+// newProcessGroup returns a new job object for cmd.
+// The job is only created once the process has started, as it cannot be
+// created for a process that does not exist.
+func newProcessGroup(cmd *exec.Cmd) *processGroup {
+	return &processGroup{}
+}
+
+// add creates the job object and assigns the started process p to it.
+// Processes that p starts before it is assigned are not in the job.
+func (g *processGroup) add(p *os.Process) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	job, _, err := createJobObject.Call(0, 0)
+	if job == 0 {
+		return err
+	}
+	g.job = syscall.Handle(job)
+	if err := g.setKillOnClose(true); err != nil {
+		g.close()
+		return err
+	}
+	process, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(p.Pid))
+	if err != nil {
+		g.close()
+		return err
+	}
+	defer syscall.CloseHandle(process)
+	if ok, _, err := assignProcessToJobObject.Call(uintptr(g.job), uintptr(process)); ok == 0 {
+		g.close()
+		return err
+	}
+	return nil
+}
+
+// kill terminates all the processes in the job.
+func (g *processGroup) kill(p *os.Process) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.job == 0 {
+		return syscall.EINVAL
+	}
+	if ok, _, err := terminateJobObject.Call(uintptr(g.job), 1); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// release closes the job once p has exited. The processes still running in
+// the job are left running, as they are on the other platforms.
+func (g *processGroup) release() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.job != 0 {
+		g.setKillOnClose(false)
+		g.close()
+	}
+}
+
+func (g *processGroup) close() {
+	syscall.CloseHandle(g.job)
+	g.job = 0
+}
+
+func (g *processGroup) setKillOnClose(kill bool) error {
+	info := jobObjectExtendedLimitInfo{}
+	if kill {
+		info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+	}
+	ok, _, err := setInformationJobObject.Call(
+		uintptr(g.job),
+		jobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)),
+		unsafe.Sizeof(info))
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
@@ -43,6 +43,18 @@ func Parse(filename, data string, skip Skip, parse RootParser) []Error {
 	return p.Errors
 }
 
+// ParseWithRecovery is like Parse, but parses in error-recovery mode, and also
+// returns the CST built, which is partial if errors were found.
+// In error-recovery mode, only the first error of a syntax error is reported,
+// and the errors that follow from it are dropped until the root parser calls
+// Recover to skip to the next synchronization token.
+func ParseWithRecovery(filename, data string, skip Skip, parse RootParser) (*cst.Branch, []Error) {
+	p := &Parser{skip: skip, recovery: true}
+	p.setData(filename, data)
+	root := p.parse(parse)
+	return root, p.Errors
+}
+
 // Parser contains all the context needed while parsing.
 // They are built for you by the Parse function.
 type Parser struct {
@@ -52,18 +64,24 @@ type Parser struct {
 	prefix cst.Separator // The currently skipped prefix separator.
 	suffix cst.Separator // The currently skipped suffix separator.
 	last   cst.Node      // The last node fully parsed, potential suffix target
+
+	recovery   bool // True if parsing in error-recovery mode.
+	recovering bool // True if errors are dropped until the next Recover.
+	stuckAt    int  // The cursor at the last dropped error.
+	stuck      int  // The number of errors dropped at stuckAt.
 }
 
-func (p *Parser) parse(root RootParser) {
+func (p *Parser) parse(root RootParser) *cst.Branch {
+	anchor := &cst.Branch{}
 	defer func() {
 		err := recover()
 		if err != nil && err != AbortParse {
 			panic(err)
 		}
 	}()
-	anchor := &cst.Branch{}
 	anchor.AddPrefix(p.skip(p, SkipPrefix))
 	root(p, anchor)
+	p.recovering = false
 	if len(p.suffix) > 0 {
 		anchor.AddSuffix(p.suffix)
 	}
@@ -75,6 +93,7 @@ func (p *Parser) parse(root RootParser) {
 	if !p.IsEOF() {
 		p.Error("Unexpected input at end of parse")
 	}
+	return anchor
 }
 
 func (p *Parser) addChild(in *cst.Branch, child cst.Node) {
@@ -165,13 +184,13 @@ func (p *Parser) Error(message string, args ...interface{}) {
 	if p.IsEOF() {
 		at = p.last
 	}
-	p.Errors.Add(&p.Reader, at, message, args...)
+	p.addError(at, message, args...)
 }
 
 // ErrorAt is like Error, except because it is handed a fragment, it will not
 // try to consume anything itself.
 func (p *Parser) ErrorAt(loc cst.Fragment, message string, args ...interface{}) {
-	p.Errors.Add(&p.Reader, loc, message, args...)
+	p.addError(loc, message, args...)
 }
 
 // Expected is a wrapper around p.ErrorAt for the very common case of an unexpected
@@ -179,5 +198,5 @@ func (p *Parser) ErrorAt(loc cst.Fragment, message string, args ...interface{})
 // for the unexpected actual input.
 func (p *Parser) Expected(value string) {
 	invalid := p.GuessNextToken()
-	p.Errors.Add(&p.Reader, invalid, "Expected \"%s\" got \"%s\"", value, invalid.String())
+	p.addError(invalid, "Expected \"%s\" got \"%s\"", value, invalid.String())
 }
@@ -157,6 +157,63 @@ func TestErrorLimit(t *testing.T) {
 	assert.For(ctx, "errs").ThatSlice(errs).IsLength(parse.ParseErrorLimit)
 }
 
+func TestRecovery(t *testing.T) {
+	ctx := log.Testing(t)
+	content := "a; [,] ; b; [ c d; e"
+	sync := parse.Sync{Terminators: []string{";"}}
+	lists := []*test.ListNode{}
+	root, errs := parse.ParseWithRecovery("parser_test.api", content, parse.NewSkip("//", "/*", "*/"), func(p *parse.Parser, b *cst.Branch) {
+		for !p.IsEOF() {
+			l := List()
+			p.ParseBranch(b, l.Parser(p))
+			lists = append(lists, l)
+			if !p.Recover(b, sync) && !p.IsEOF() {
+				p.ParseLeaf(b, func(*cst.Leaf) {
+					if !p.String(";") {
+						p.Expected(";")
+					}
+				})
+			}
+		}
+	})
+	assert.For(ctx, "root").That(root).IsNotNil()
+	if assert.For(ctx, "errs").ThatSlice(errs).IsLength(2) {
+		assert.For(ctx, "first").ThatString(errs[0].Message).Equals(`Expected "]" got ",]"`)
+		assert.For(ctx, "second").ThatString(errs[1].Message).Equals(`Expected "]" got "d"`)
+	}
+	assert.For(ctx, "lists").That(lists).DeepEquals([]*test.ListNode{
+		List("a"), List(Array()), List("b"), List(Array("c")), List("e"),
+	})
+}
+
+func TestRecoveryNested(t *testing.T) {
+	ctx := log.Testing(t)
+	content := "a; [ c d [x; y]; e"
+	sync := parse.Sync{Terminators: []string{";"}}
+	lists := []*test.ListNode{}
+	_, errs := parse.ParseWithRecovery("parser_test.api", content, parse.NewSkip("//", "/*", "*/"), func(p *parse.Parser, b *cst.Branch) {
+		for !p.IsEOF() {
+			l := List()
+			p.ParseBranch(b, l.Parser(p))
+			lists = append(lists, l)
+			if !p.Recover(b, sync) && !p.IsEOF() {
+				p.ParseLeaf(b, func(*cst.Leaf) {
+					if !p.String(";") {
+						p.Expected(";")
+					}
+				})
+			}
+		}
+	})
+	// The terminator inside the nested brackets does not end the skip.
+	if assert.For(ctx, "errs").ThatSlice(errs).IsLength(1) {
+		assert.For(ctx, "error").ThatString(errs[0].Message).Equals(`Expected "]" got "d"`)
+	}
+	assert.For(ctx, "lists").That(lists).DeepEquals([]*test.ListNode{
+		List("a"), List(Array("c")), List("e"),
+	})
+}
+
 func TestCursor(t *testing.T) {
 	ctx := log.Testing(t)
 	root := List()
@@ -0,0 +1,114 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parse
+
+import (
+	"github.com/google/gapid/core/text/parse/cst"
+)
+
+// Sync describes the synchronization tokens that Recover skips the input to
+// after a syntax error.
+type Sync struct {
+	// Terminators are the tokens that end an element, like a statement
+	// terminator. They are skipped along with the input that precedes them.
+	Terminators []string
+	// Closers are the tokens that end the enclosing element, like a closing
+	// brace. The input is skipped up to them, so that the enclosing element can
+	// still parse them.
+	Closers []string
+}
+
+// Recover resynchronizes the parser after a syntax error in error-recovery
+// mode. It is intended to be called by the root parser after each element of
+// a sequence, like the statements of a block.
+// If an error was reported since the last call to Recover, the input up to the
+// next of the sync tokens is added to b as a leaf, and the errors reported
+// afterwards are kept again.
+// Recover returns true if it had to recover from an error.
+func (p *Parser) Recover(b *cst.Branch, sync Sync) bool {
+	if !p.recovering {
+		return false
+	}
+	p.recovering = false
+	p.skipTo(sync)
+	if p.offset != p.cursor {
+		p.ParseLeaf(b, nil)
+	}
+	return true
+}
+
+// skipTo advances the cursor to the next of the sync tokens that is not
+// nested in brackets opened while skipping, or to the end of the input.
+func (p *Parser) skipTo(sync Sync) {
+	depth := 0
+	for !p.IsEOF() {
+		if depth == 0 {
+			for _, c := range sync.Closers {
+				if p.lookingAt(c) {
+					return
+				}
+			}
+			for _, t := range sync.Terminators {
+				if p.String(t) {
+					return
+				}
+			}
+		}
+		switch p.Peek() {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+		p.Advance()
+		p.Consume()
+	}
+}
+
+// lookingAt returns true if value occurs at the cursor, without advancing it.
+func (p *Parser) lookingAt(value string) bool {
+	cursor := p.cursor
+	found := p.String(value)
+	p.cursor = cursor
+	return found
+}
+
+// addError adds a new error to the parser error list, unless the parser is
+// recovering from an earlier error.
+// A parser that keeps dropping errors without advancing is stuck in a loop
+// of the grammar that relies on the ParseErrorLimit to end, so a rune of the
+// input is dropped to force progress, and the parse is aborted at the end of
+// the input.
+func (p *Parser) addError(at cst.Fragment, message string, args ...interface{}) {
+	if !p.recovering {
+		p.Errors.Add(&p.Reader, at, message, args...)
+		p.recovering = p.recovery
+		p.stuckAt, p.stuck = p.cursor, 0
+		return
+	}
+	if p.cursor != p.stuckAt {
+		p.stuckAt, p.stuck = p.cursor, 0
+	}
+	p.stuck++
+	if p.stuck >= ParseErrorLimit {
+		if p.IsEOF() {
+			panic(AbortParse)
+		}
+		p.Advance()
+		p.Consume()
+	}
+}
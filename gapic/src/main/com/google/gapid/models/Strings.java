@@ -17,9 +17,11 @@ package com.google.gapid.models;
 
 import com.google.gapid.proto.stringtable.Stringtable;
 import com.google.gapid.util.Paths;
+import com.google.gapid.util.Pods;
 import com.google.gapid.views.Formatter;
 
 import java.util.Collections;
+import java.util.Locale;
 import java.util.Map;
 import java.util.concurrent.atomic.AtomicReference;
 
@@ -85,6 +87,8 @@ public class Strings {
           getString(n, sb.append("• "), arguments).append('\n');
         }
         return sb;
+      case PLURAL:
+        return getString(selectCase(node.getPlural(), arguments), sb, arguments);
       case PARAMETER:
         Stringtable.Value argument = arguments.get(node.getParameter().getKey());
         if (argument == null) {
@@ -100,6 +104,57 @@ public class Strings {
     }
   }
 
+  /**
+   * Returns the case of the plural for the count in the arguments, using the same rules as
+   * gapis/stringtable/plural.go.
+   */
+  private static Stringtable.Node selectCase(
+      Stringtable.Plural plural, Map<String, Stringtable.Value> arguments) {
+    Map<String, Stringtable.Node> cases = plural.getCasesMap();
+    Stringtable.Value count = arguments.get(plural.getKey());
+    if (count == null || !count.hasBox() || !count.getBox().hasPod() ||
+        !Pods.mayBeConstant(count.getBox().getPod())) {
+      return cases.getOrDefault("other", Stringtable.Node.getDefaultInstance());
+    }
+    long n = Pods.getConstant(count.getBox().getPod());
+    Stringtable.Node exact = cases.get("=" + n);
+    if (exact != null) {
+      return exact;
+    }
+    Stringtable.Node categorized = cases.get(pluralCategory(Math.abs(n)));
+    if (categorized != null) {
+      return categorized;
+    }
+    return cases.getOrDefault("other", Stringtable.Node.getDefaultInstance());
+  }
+
+  private static String pluralCategory(long n) {
+    Stringtable.StringTable table = current.get();
+    String culture = (table == null) ? "" : table.getInfo().getCultureCode();
+    switch (culture.split("-", 2)[0].toLowerCase(Locale.ROOT)) {
+      case "fr":
+        return (n == 0 || n == 1) ? "one" : "other";
+      case "ja":
+      case "ko":
+      case "zh":
+        return "other";
+      case "cs":
+      case "sk":
+        return (n == 1) ? "one" : (n >= 2 && n <= 4) ? "few" : "other";
+      case "pl":
+        return (n == 1) ? "one" : isFew(n) ? "few" : "many";
+      case "ru":
+      case "uk":
+        return (n % 10 == 1 && n % 100 != 11) ? "one" : isFew(n) ? "few" : "many";
+      default:
+        return (n == 1) ? "one" : "other";
+    }
+  }
+
+  private static boolean isFew(long n) {
+    return n % 10 >= 2 && n % 10 <= 4 && (n % 100 < 12 || n % 100 > 14);
+  }
+
   private static StringBuilder append(StringBuilder sb, Stringtable.Value value) {
     switch (value.getValueCase()) {
       case VALUE_NOT_SET: return sb.append("[null]");
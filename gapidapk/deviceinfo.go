@@ -29,7 +29,6 @@ import (
 	"github.com/google/gapid/core/app/crash"
 	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/log"
-	"github.com/google/gapid/core/os/android"
 	"github.com/google/gapid/core/os/android/adb"
 	"github.com/google/gapid/core/os/device"
 	"github.com/google/gapid/core/os/flock"
@@ -124,7 +123,7 @@ func fetchDeviceInfo(ctx context.Context, d adb.Device) error {
 	}
 
 	// Set driver package
-	nextCleanup, err = android.SetupLayers(ctx, d, apk.Name, []string{driver.Package}, []string{}, true)
+	nextCleanup, err = d.SetGPUDebugLayers(ctx, apk.Name, []string{driver.Package}, nil, true)
 	cleanup = cleanup.Then(nextCleanup)
 	if err != nil {
 		cleanup.Invoke(ctx)
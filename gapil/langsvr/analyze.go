@@ -40,6 +40,10 @@ import (
 const (
 	// Expected duration of analysis. If it does over this, warn.
 	analysisWarnDuration = 5 * time.Second
+
+	// Delay before analysis starts, so that rapid edits coalesce into a single
+	// analysis.
+	analysisDebounce = 250 * time.Millisecond
 )
 
 type fullAnalysis struct {
@@ -83,6 +87,42 @@ func (da *docAnalysis) walkUp(offset int) []nodes {
 	return out
 }
 
+// reportedUnused returns true if local was reported as unused by one of the
+// diagnostics.
+func (da *docAnalysis) reportedUnused(local *semantic.Local, diags []ls.Diagnostic) bool {
+	for _, issue := range da.issues {
+		if u, ok := issue.Problem.(validate.UnusedLocal); !ok || u.Local != local {
+			continue
+		}
+		rng := fragRange(da.doc, issue.At)
+		for _, d := range diags {
+			if d.Range == rng {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// statementRange returns the range of the statement n. If the statement is
+// the only thing on its lines, then the range spans the whole lines so that
+// removing it leaves no blank line behind.
+func (da *docAnalysis) statementRange(n ast.Node) ls.Range {
+	tok := da.full.mappings.AST.CST(n).Tok()
+	runes := da.doc.Body().Runes()
+	start, end := tok.Start, tok.End
+	for start > 0 && (runes[start-1] == ' ' || runes[start-1] == '\t') {
+		start--
+	}
+	for end < len(runes) && (runes[end] == ' ' || runes[end] == '\t' || runes[end] == '\r') {
+		end++
+	}
+	if (start == 0 || runes[start-1] == '\n') && end < len(runes) && runes[end] == '\n' {
+		return da.doc.Body().Range(start, end+1)
+	}
+	return tokRange(da.doc, tok)
+}
+
 func (da *docAnalysis) contains(n ast.Node) bool {
 	return da.doc.Path() == da.full.mappings.AST.CST(n).Tok().Source.Filename
 }
@@ -142,13 +182,19 @@ func (a *analyzer) results(ctx context.Context, s *server) *fullAnalysis {
 	if a.lastResults != nil {
 		return a.lastResults
 	}
-	a.begin(ctx, s)
+	a.start(ctx, s, 0)
 	a.done.Wait(ctx)
 	return a.lastResults
 }
 
-// begin starts a new analysis of the API documents.
+// begin starts a new analysis of the API documents, once no other analysis
+// has been started for analysisDebounce.
 func (a *analyzer) begin(ctx context.Context, s *server) error {
+	return a.start(ctx, s, analysisDebounce)
+}
+
+// start starts a new analysis of the API documents after delay.
+func (a *analyzer) start(ctx context.Context, s *server, delay time.Duration) error {
 	if s.config == nil {
 		// We're still waiting for the configuration. Don't do anything yet,
 		// we'll restart analysis when this comes through.
@@ -191,7 +237,14 @@ func (a *analyzer) begin(ctx context.Context, s *server) error {
 	}
 
 	// Start the go-routine to perform the analysis.
-	crash.Go(func() { a.doAnalysis(ctx, docs, va, done) })
+	crash.Go(func() {
+		select {
+		case <-task.ShouldStop(ctx):
+			done(ctx) // Superseded by a newer analysis.
+		case <-time.After(delay):
+			a.doAnalysis(ctx, docs, va, done)
+		}
+	})
 
 	return nil
 }
@@ -207,7 +260,7 @@ func (l docsLoader) Load(path file.Path) ([]byte, error) {
 }
 
 // doAnalysis is the internal analysis function.
-// Must only be called from analyzer.begin().
+// Must only be called from analyzer.start().
 func (a *analyzer) doAnalysis(
 	ctx context.Context,
 	docs map[string]*ls.Document,
@@ -0,0 +1,54 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/assert"
+	ls "github.com/google/gapid/core/langsvr"
+	"github.com/google/gapid/core/log"
+)
+
+func TestAnalysisDebounce(t *testing.T) {
+	ctx := log.Testing(t)
+	s := &server{
+		docs:     map[string]*ls.Document{},
+		config:   &Config{},
+		analyzer: newAnalyzer(),
+	}
+	a := s.analyzer
+
+	// Each edit during the delay restarts it, so the analysis is still pending
+	// after the delay has passed since the first edit.
+	start := time.Now()
+	a.begin(ctx, s)
+	time.Sleep(analysisDebounce * 3 / 5)
+	a.begin(ctx, s)
+	time.Sleep(analysisDebounce * 3 / 5)
+	assert.For(ctx, "pending").That(a.done.Fired()).Equals(false)
+	assert.For(ctx, "finished").That(a.done.TryWait(ctx, 10*time.Second)).Equals(true)
+	assert.For(ctx, "results").That(a.lastResults != nil).Equals(true)
+	assert.For(ctx, "debounced").That(time.Since(start) >= analysisDebounce*8/5).Equals(true)
+
+	// Requests that need results cancel any pending analysis and analyze
+	// straight away.
+	a.begin(ctx, s)
+	start = time.Now()
+	results := a.results(ctx, s)
+	assert.For(ctx, "results").That(results != nil).Equals(true)
+	assert.For(ctx, "immediate").That(time.Since(start) < analysisDebounce).Equals(true)
+}
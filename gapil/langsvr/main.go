@@ -27,6 +27,7 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"unicode"
 
 	ls "github.com/google/gapid/core/langsvr"
 	"github.com/google/gapid/core/log"
@@ -162,6 +163,7 @@ func (s *server) Initialize(ctx context.Context, rootPath string) (ls.InitConfig
 		CompletionTriggerCharacters: []rune{'.'},
 		SignatureTriggerCharacters:  []rune{'('},
 		WorkspaceDocuments:          findAPIs(rootPath),
+		SyncKind:                    ls.SyncIncremental,
 	}, nil
 }
 
@@ -224,8 +226,45 @@ func (s *server) OnDocumentSaved(ctx context.Context, doc *ls.Document) error {
 }
 
 // CodeLenses returns a list of CodeLens for the specified document.
+// A CodeLens showing the number of references is placed above each command,
+// subroutine and class.
 func (s *server) CodeLenses(ctx context.Context, doc *ls.Document) ([]ls.CodeLens, error) {
-	return nil, nil
+	da, err := s.docAnalysis(ctx, doc)
+	if da == nil || err != nil {
+		return nil, err
+	}
+	names := []*ast.Identifier{}
+	for _, f := range da.ast.Commands {
+		names = append(names, f.Generic.Name)
+	}
+	for _, f := range da.ast.Subroutines {
+		names = append(names, f.Generic.Name)
+	}
+	for _, c := range da.ast.Classes {
+		names = append(names, c.Name)
+	}
+	lenses := make([]ls.CodeLens, 0, len(names))
+	for _, name := range names {
+		name := name
+		loc := da.full.nodeLocation(doc, name)
+		lenses = append(lenses, ls.CodeLens{
+			Range: loc.Range,
+			Resolve: func(context.Context) ls.Command {
+				refs := []ls.Location{}
+				for _, n := range da.full.references(name) {
+					if n != name {
+						refs = append(refs, s.nodeLocation(da.full, n))
+					}
+				}
+				title := fmt.Sprintf("%d references", len(refs))
+				if len(refs) == 1 {
+					title = "1 reference"
+				}
+				return ls.ReferencesCommand(title, loc, refs)
+			},
+		})
+	}
+	return lenses, nil
 }
 
 // Completions returns completion items at a given cursor position.
@@ -347,14 +386,13 @@ func (s *server) References(ctx context.Context, doc *ls.Document, pos ls.Positi
 		return nil, err
 	}
 	for _, n := range da.walkUp(doc.Body().Offset(pos)) {
-		if _, isIdent := n.ast.(*ast.Identifier); !isIdent {
+		ident, isIdent := n.ast.(*ast.Identifier)
+		if !isIdent {
 			continue
 		}
 		locations := []ls.Location{}
-		for _, n := range da.full.mappings.SemanticToAST[n.sem] {
-			if _, isIdent := n.(*ast.Identifier); isIdent {
-				locations = append(locations, s.nodeLocation(da.full, n))
-			}
+		for _, n := range da.full.references(ident) {
+			locations = append(locations, s.nodeLocation(da.full, n))
 		}
 		return locations, nil
 	}
@@ -384,19 +422,78 @@ func (s *server) Highlights(ctx context.Context, doc *ls.Document, pos ls.Positi
 }
 
 // Format returns a list of edits required to format the the entire document.
+// If the document has parse errors, only the declarations before the first
+// error are reformatted, and the rest of the document is left untouched.
 func (s *server) Format(ctx context.Context, doc *ls.Document, opts ls.FormattingOptions) (ls.TextEditList, error) {
+	return formatEdits(doc.Body()), nil
+}
+
+// formatEdits returns the edits that format body. See server.Format.
+func formatEdits(body ls.Body) ls.TextEditList {
 	m := &ast.Mappings{}
-	ast, err := parser.Parse("", doc.Body().Text(), m)
-	if err != nil {
-		// Reformatting ASTs with parse errors?
-		// You're going to have a bad time.
-		return ls.TextEditList{}, nil
-	}
-	formatted := &bytes.Buffer{}
-	format.Format(ast, m, formatted)
-	edits := ls.TextEditList{}
-	edits.Add(doc.Body().FullRange(), formatted.String())
-	return edits, nil
+	api, errs := parser.Parse("", body.Text(), m)
+	if len(errs) == 0 {
+		formatted := &bytes.Buffer{}
+		format.Format(api, m, formatted)
+		edits := ls.TextEditList{}
+		edits.Add(body.FullRange(), formatted.String())
+		return edits
+	}
+
+	// Reformatting ASTs with parse errors?
+	// You're going to have a bad time.
+	// Instead look for the longest run of declarations before the first error
+	// that parses on its own.
+	first := -1
+	for _, err := range errs {
+		if err.At == nil {
+			return ls.TextEditList{}
+		}
+		if start := err.At.Tok().Start; first < 0 || start < first {
+			first = start
+		}
+	}
+	root, ok := m.CST(api).(*cst.Branch)
+	if !ok {
+		return ls.TextEditList{}
+	}
+	runes := body.Runes()
+	for i := len(root.Children) - 1; i >= 0; i-- {
+		end := lineStart(runes, root.Children[i].Tok().Start)
+		if end == 0 || root.Children[i].Tok().Start > first {
+			continue
+		}
+		prefix := string(runes[:end])
+		m := &ast.Mappings{}
+		api, errs := parser.Parse("", prefix, m)
+		if len(errs) > 0 {
+			continue
+		}
+		formatted := &bytes.Buffer{}
+		format.Format(api, m, formatted)
+		// Keep the whitespace that separated the prefix from the rest.
+		trimmed := strings.TrimRightFunc(prefix, unicode.IsSpace)
+		text := strings.TrimRightFunc(formatted.String(), unicode.IsSpace) + prefix[len(trimmed):]
+		edits := ls.TextEditList{}
+		edits.Add(body.Range(0, end), text)
+		return edits
+	}
+	return ls.TextEditList{}
+}
+
+// lineStart returns the offset of the start of the line holding offset, if
+// only whitespace precedes offset on that line. Otherwise offset is returned.
+func lineStart(runes []rune, offset int) int {
+	for i := offset; i > 0; i-- {
+		switch runes[i-1] {
+		case '\n':
+			return i
+		case ' ', '\t', '\r':
+		default:
+			return offset
+		}
+	}
+	return 0
 }
 
 func (s *server) FormatRange(ctx context.Context, doc *ls.Document, rng ls.Range, opts ls.FormattingOptions) (ls.TextEditList, error) {
@@ -564,8 +661,33 @@ func (s *server) WorkspaceSymbols(ctx context.Context) (ls.SymbolList, error) {
 // CodeActions compute commands for a given document and range.
 // The request is triggered when the user moves the cursor into an problem
 // marker in the editor or presses the lightbulb associated with a marker.
-func (s *server) CodeActions(context.Context, *ls.Document, ls.Range, []ls.Diagnostic) ([]ls.Command, error) {
-	return []ls.Command{}, nil
+func (s *server) CodeActions(ctx context.Context, doc *ls.Document, rng ls.Range, diags []ls.Diagnostic) ([]ls.Command, error) {
+	da, err := s.docAnalysis(ctx, doc)
+	if da == nil || err != nil {
+		return []ls.Command{}, err
+	}
+	cmds := []ls.Command{}
+	for _, n := range da.walkUp(doc.Body().Offset(rng.Start)) {
+		local, ok := n.sem.(*semantic.Local)
+		if !ok || !da.reportedUnused(local, diags) {
+			continue
+		}
+		decl := local.Declaration.AST
+
+		remove := ls.WorkspaceEdit{}
+		remove.Add(ls.Location{URI: doc.URI(), Range: da.statementRange(decl)}, "")
+		cmds = append(cmds, ls.EditCommand("Remove declaration", remove))
+
+		prefix := ls.WorkspaceEdit{}
+		for _, n := range da.full.mappings.SemanticToAST[local] {
+			if n, ok := n.(*ast.Identifier); ok {
+				prefix.Add(s.nodeLocation(da.full, n), "_"+n.Value)
+			}
+		}
+		cmds = append(cmds, ls.EditCommand("Prefix with underscore", prefix))
+		break
+	}
+	return cmds, nil
 }
 
 func findAPIs(root string) []string {
@@ -687,6 +809,22 @@ func typename(t semantic.Type) string {
 	return printer.New().WriteType(t).String()
 }
 
+// references returns all the identifiers that refer to the same semantic
+// nodes as ident, including ident itself.
+func (fa *fullAnalysis) references(ident *ast.Identifier) []*ast.Identifier {
+	out := []*ast.Identifier{}
+	seen := map[*ast.Identifier]bool{}
+	for _, sem := range fa.mappings.ASTToSemantic[ident] {
+		for _, n := range fa.mappings.SemanticToAST[sem] {
+			if n, ok := n.(*ast.Identifier); ok && !seen[n] {
+				seen[n] = true
+				out = append(out, n)
+			}
+		}
+	}
+	return out
+}
+
 func (fa *fullAnalysis) nodeLocation(doc *ls.Document, n ast.Node) ls.Location {
 	return ls.Location{URI: doc.URI(), Range: fa.nodeRange(doc, n)}
 }
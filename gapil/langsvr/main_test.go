@@ -0,0 +1,80 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	ls "github.com/google/gapid/core/langsvr"
+	"github.com/google/gapid/core/log"
+)
+
+// apply returns the text of body after applying edits.
+func apply(body ls.Body, edits ls.TextEditList) string {
+	runes := body.Runes()
+	for i := len(edits) - 1; i >= 0; i-- {
+		start := body.Offset(edits[i].Range.Start)
+		end := body.Offset(edits[i].Range.End)
+		runes = append(append(append([]rune{}, runes[:start]...), []rune(edits[i].NewText)...), runes[end:]...)
+	}
+	return string(runes)
+}
+
+func TestFormat(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "valid",
+			body:     "enum  E {\n A=1\n}\nclass C {\nu32 x\n}\n",
+			expected: "enum E {\n  A = 1\n}\nclass C {\n  u32 x\n}\n",
+		},
+		{
+			name:     "error after declarations",
+			body:     "enum  E {\n A=1\n}\nclass C {\nu32 x\n}\n\ncmd void f( {\n",
+			expected: "enum E {\n  A = 1\n}\nclass C {\n  u32 x\n}\n\ncmd void f( {\n",
+		},
+		{
+			name:     "error in first declaration",
+			body:     "cmd void f( {\nenum  E {\n A=1\n}\n",
+			expected: "cmd void f( {\nenum  E {\n A=1\n}\n",
+		},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		body := ls.NewBody(test.body)
+		got := apply(body, formatEdits(body))
+		assert.For(ctx, "formatted").ThatString(got).Equals(test.expected)
+	}
+}
+
+func TestLineStart(t *testing.T) {
+	ctx := log.Testing(t)
+	runes := []rune("a\n  b c\n")
+	for _, test := range []struct {
+		offset   int
+		expected int
+	}{
+		{0, 0},
+		{4, 2}, // b is only preceded by indentation.
+		{6, 6}, // c follows b on the same line.
+		{8, 8},
+	} {
+		assert.For(ctx, "lineStart(%d)", test.offset).That(lineStart(runes, test.offset)).Equals(test.expected)
+	}
+}
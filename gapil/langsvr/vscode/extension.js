@@ -50,6 +50,38 @@ function activate(context) {
 	// Push the disposable to the context's subscriptions so that the
 	// client can be deactivated on extension deactivation
 	context.subscriptions.push(disposable);
+
+	// Apply the workspace edits of the code actions returned by the server.
+	context.subscriptions.push(vscode.commands.registerCommand('langsvr.applyEdit', applyEdit));
+
+	// Show the references of the code lenses returned by the server.
+	context.subscriptions.push(vscode.commands.registerCommand('langsvr.showReferences', showReferences));
+}
+
+// asRange returns the language server protocol Range r as a vscode.Range.
+function asRange(r) {
+	return new vscode.Range(r.start.line, r.start.character, r.end.line, r.end.character);
+}
+
+// showReferences shows the language server protocol Locations refs in a peek
+// view at the Position pos of the document uri.
+function showReferences(uri, pos, refs) {
+	let locations = refs.map(function(l) {
+		return new vscode.Location(vscode.Uri.parse(l.uri), asRange(l.range));
+	});
+	return vscode.commands.executeCommand('editor.action.showReferences',
+		vscode.Uri.parse(uri), new vscode.Position(pos.line, pos.character), locations);
+}
+
+// applyEdit applies the language server protocol WorkspaceEdit edit.
+function applyEdit(edit) {
+	let out = new vscode.WorkspaceEdit();
+	for (let uri in edit.changes) {
+		out.set(vscode.Uri.parse(uri), edit.changes[uri].map(function(e) {
+			return new vscode.TextEdit(asRange(e.range), e.newText);
+		}));
+	}
+	return vscode.workspace.applyEdit(out);
 }
 exports.activate = activate;
 
@@ -56,6 +56,7 @@ func (p *parser) requireAPI(b *cst.Branch) *ast.API {
 			cst := p.mappings.CST((*annotations)[0])
 			p.ErrorAt(cst, "Annotation not consumed")
 		}
+		p.Recover(b, declarationSync)
 	}
 	return api
 }
@@ -27,14 +27,25 @@ type parser struct {
 	mappings *ast.Mappings
 }
 
+var (
+	// declarationSync skips the rest of a declaration with a syntax error,
+	// up to the end of its body.
+	declarationSync = parse.Sync{Terminators: []string{ast.OpBlockEnd}}
+	// statementSync skips the rest of a block from a statement with a syntax
+	// error, leaving the end of the block to be parsed.
+	statementSync = parse.Sync{Closers: []string{ast.OpBlockEnd}}
+)
+
 // Parse takes a string containing a complete api description and
 // returns the abstract syntax tree representation of it.
 // If the string is not syntactically valid, it will also return the
 // errors encountered. If errors are returned, the ast returned will be
 // the incomplete tree so far, and may not be structurally valid.
+// After a syntax error, parsing resumes at the end of the enclosing block or
+// declaration, so that an error can be reported for each of them.
 func Parse(filename, data string, m *ast.Mappings) (*ast.API, parse.ErrorList) {
 	var api *ast.API
-	errors := parse.Parse(filename, data, parse.NewSkip("//", "/*", "*/"), func(p *parse.Parser, b *cst.Branch) {
+	_, errors := parse.ParseWithRecovery(filename, data, parse.NewSkip("//", "/*", "*/"), func(p *parse.Parser, b *cst.Branch) {
 		apiParser := parser{p, m}
 		api = apiParser.requireAPI(b)
 	})
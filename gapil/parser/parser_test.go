@@ -76,3 +76,30 @@ func TestParsedCST(t *testing.T) {
 		assert.For(test.name).That(m.CST(api)).DeepEquals(test.expected)
 	}
 }
+
+func TestErrorRecovery(t *testing.T) {
+	assert := assert.To(t)
+	source := `
+cmd void f() {
+  a := 1 +
+}
+cmd void g() {
+  x := [
+  return
+}
+cmd void h() {
+  b := 2
+}
+`
+	m := &ast.Mappings{}
+	api, errs := parser.Parse("parser_test.api", source, m)
+	got := []string{}
+	for _, err := range errs {
+		got = append(got, err.Message)
+	}
+	assert.For("errors").ThatSlice(got).Equals([]string{
+		`Expected "expression" got ""`,
+		`Expected "expression" got "["`,
+	})
+	assert.For("commands").ThatSlice(api.Commands).IsLength(3)
+}
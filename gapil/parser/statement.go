@@ -31,6 +31,7 @@ func (p *parser) requireBlock(b *cst.Branch) *ast.Block {
 					break
 				}
 				block.Statements = append(block.Statements, p.requireStatement(b))
+				p.Recover(b, statementSync)
 			}
 		} else {
 			block.Statements = append(block.Statements, p.requireStatement(b))
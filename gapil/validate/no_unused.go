@@ -15,6 +15,9 @@
 package validate
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/google/gapid/core/text/parse/cst"
 	"github.com/google/gapid/gapil/semantic"
 )
@@ -23,10 +26,22 @@ type fieldUsage struct{ read, written bool }
 
 const annoUnused = "unused"
 
-// noUnused verifies that all declared types and fields are used.
+// UnusedLocal is the problem of an Issue reporting a local that is declared
+// but never used. Locals whose names start with an underscore are not
+// reported.
+type UnusedLocal struct {
+	Local *semantic.Local
+}
+
+func (u UnusedLocal) Error() string {
+	return fmt.Sprintf("Local %s declared but never used", u.Local.Name())
+}
+
+// noUnused verifies that all declared types, fields and locals are used.
 func noUnused(api *semantic.API, mappings *semantic.Mappings) Issues {
 	types := map[semantic.Type]bool{}
 	fields := map[*semantic.Field]fieldUsage{}
+	locals := map[*semantic.Local]bool{}
 	tokens := map[semantic.Node]cst.Token{}
 
 	// Gather all declared types
@@ -142,6 +157,17 @@ func noUnused(api *semantic.API, mappings *semantic.Mappings) Issues {
 			markFieldUsed(n, true, false)
 		case *semantic.FieldInitializer:
 			markFieldUsed(n.Field, false, true)
+		case *semantic.DeclareLocal:
+			if _, ok := locals[n.Local]; !ok {
+				locals[n.Local] = false
+			}
+			markTypeUsed(n.Local.Type)
+			if n.Local.Value != nil {
+				traverse(n.Local.Value)
+			}
+			return // Don't mark the declared local as used.
+		case *semantic.Local:
+			locals[n] = true
 		case *semantic.Parameter:
 			if !n.Function.Subroutine && !n.Function.Extern {
 				markClassFieldsUsed(n.Type, true, true)
@@ -216,5 +242,12 @@ func noUnused(api *semantic.API, mappings *semantic.Mappings) Issues {
 			issues.addf(mappings.AST.CST(fiu.AST), "Redundant annotation")
 		}
 	}
+	for l, used := range locals {
+		decl := l.Declaration
+		if used || decl == nil || decl.AST == nil || strings.HasPrefix(l.Name(), "_") {
+			continue
+		}
+		issues.add(mappings.AST.CST(decl.AST.Name), UnusedLocal{l})
+	}
 	return issues
 }
@@ -0,0 +1,66 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil/validate"
+)
+
+func TestUnusedLocals(t *testing.T) {
+	ctx := log.Testing(t)
+
+	for _, test := range []struct {
+		source   string
+		expected []string
+	}{
+		{`cmd void f() {
+        a := 1
+      }`, []string{"Local a declared but never used"}},
+
+		{`cmd s32 f() {
+        a := 1
+        return a
+      }`, []string{}},
+
+		{`cmd s32 f() {
+        a := 1
+        b := a
+        return 2
+      }`, []string{"Local b declared but never used"}},
+
+		{`cmd void f() {
+        _a := 1
+      }`, []string{}},
+	} {
+		api, mappings, err := compile(ctx, test.source)
+		ok := true
+		ok = assert.For(ctx, "err").ThatError(err).Succeeded() && ok
+		ok = assert.For(ctx, "api").Critical().That(api).IsNotNil() && ok
+		got := []string{}
+		for _, issue := range validate.Validate(api, mappings, &validate.Options{CheckUnused: true}) {
+			if u, ok := issue.Problem.(validate.UnusedLocal); ok {
+				got = append(got, u.Error())
+			}
+		}
+		ok = assert.For(ctx, "got").ThatSlice(got).Equals(test.expected) && ok
+		if !ok {
+			log.E(ctx, "test failed.\n  source: %v\n  got:  %v", test.source, got)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/app/crash/reporting"
@@ -45,9 +46,20 @@ type ReplayExecutor interface {
 }
 
 type backgroundConnection struct {
-	conn     gapir.Connection
-	OS       *device.OS
+	conn gapir.Connection
+	OS   *device.OS
+
+	// The executor and prewarm are set by the replay callers and read by the
+	// replay communication handler, so they are guarded by mutex.
+	mutex    sync.Mutex
 	executor ReplayExecutor
+	prewarm  *prewarmRequest // The last replay prewarmed on conn.
+}
+
+// prewarmRequest holds the arguments of a PrewarmReplay call.
+type prewarmRequest struct {
+	payload string
+	cleanup string
 }
 
 func (bgc *backgroundConnection) BeginReplay(ctx context.Context, payload string, dependent string) error {
@@ -55,38 +67,75 @@ func (bgc *backgroundConnection) BeginReplay(ctx context.Context, payload string
 }
 
 func (bgc *backgroundConnection) PrewarmReplay(ctx context.Context, payload string, cleanup string) error {
-	return bgc.conn.PrewarmReplay(ctx, payload, cleanup)
+	if err := bgc.conn.PrewarmReplay(ctx, payload, cleanup); err != nil {
+		return err
+	}
+	bgc.mutex.Lock()
+	defer bgc.mutex.Unlock()
+	bgc.prewarm = &prewarmRequest{payload, cleanup}
+	return nil
+}
+
+// lastPrewarm returns the last replay prewarmed on the connection, or nil if
+// there is none.
+func (bgc *backgroundConnection) lastPrewarm() *prewarmRequest {
+	bgc.mutex.Lock()
+	defer bgc.mutex.Unlock()
+	return bgc.prewarm
 }
 
 func (bgc *backgroundConnection) SetReplayExecutor(ctx context.Context, executor ReplayExecutor) (func(), error) {
+	bgc.mutex.Lock()
+	defer bgc.mutex.Unlock()
 	if bgc.executor != nil {
 		return nil, log.Err(ctx, nil, "Cannot set an active replay while one is running")
 	}
 	bgc.executor = executor
-	return func() { bgc.executor = nil }, nil
+	return func() { bgc.clearExecutor(executor) }, nil
+}
+
+// currentExecutor returns the executor of the active replay, or nil if there
+// is none.
+func (bgc *backgroundConnection) currentExecutor() ReplayExecutor {
+	bgc.mutex.Lock()
+	defer bgc.mutex.Unlock()
+	return bgc.executor
+}
+
+// clearExecutor removes executor from the connection, if it is the executor of
+// the active replay.
+func (bgc *backgroundConnection) clearExecutor(executor ReplayExecutor) {
+	bgc.mutex.Lock()
+	defer bgc.mutex.Unlock()
+	if bgc.executor == executor {
+		bgc.executor = nil
+	}
 }
 
 func (bgc *backgroundConnection) HandleFinished(ctx context.Context, err error) error {
-	if bgc.executor == nil {
+	executor := bgc.currentExecutor()
+	if executor == nil {
 		return log.Err(ctx, nil, "No active replay connection for this returned data")
 	}
-	return bgc.executor.HandleFinished(ctx, err)
+	return executor.HandleFinished(ctx, err)
 }
 
 // HandlePostData handles the given post data message.
 func (bgc *backgroundConnection) HandlePostData(ctx context.Context, postData *gapir.PostData) error {
-	if bgc.executor == nil {
+	executor := bgc.currentExecutor()
+	if executor == nil {
 		return log.Err(ctx, nil, "No active replay connection for this returned data")
 	}
-	return bgc.executor.HandlePostData(ctx, postData)
+	return executor.HandlePostData(ctx, postData)
 }
 
 // HandleNotification handles the given notification message.
 func (bgc *backgroundConnection) HandleNotification(ctx context.Context, notification *gapir.Notification) error {
-	if bgc.executor == nil {
+	executor := bgc.currentExecutor()
+	if executor == nil {
 		return log.Err(ctx, nil, "No active replay connection for this returned data")
 	}
-	return bgc.executor.HandleNotification(ctx, notification)
+	return executor.HandleNotification(ctx, notification)
 }
 
 // HandlePayloadRequest implements gapir.ReplayResponseHandler interface.
@@ -110,11 +159,12 @@ func (bgc *backgroundConnection) HandlePayloadRequest(ctx context.Context, paylo
 
 // HandleFenceReadyRequest implements gapir.ReplayResponseHandler interface.
 func (bgc *backgroundConnection) HandleFenceReadyRequest(ctx context.Context, req *gapir.FenceReadyRequest) error {
-	if bgc.executor == nil {
+	executor := bgc.currentExecutor()
+	if executor == nil {
 		return log.Err(ctx, nil, "No active replay connection for this returned data")
 	}
 
-	err := bgc.executor.HandleFenceReadyRequest(ctx, req)
+	err := executor.HandleFenceReadyRequest(ctx, req)
 	if err != nil {
 		return err
 	}
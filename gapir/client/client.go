@@ -36,9 +36,12 @@ type tyLaunchArgsKey string
 const (
 	// LaunchArgsKey is the bind device property key used to control the command
 	// line arguments when launching GAPIR. The property must be of type []string.
-	LaunchArgsKey     tyLaunchArgsKey = "gapir-launch-args"
-	connectTimeout                    = time.Second * 10
-	heartbeatInterval                 = time.Millisecond * 500
+	LaunchArgsKey           tyLaunchArgsKey = "gapir-launch-args"
+	connectTimeout                          = time.Second * 10
+	heartbeatInterval                       = time.Millisecond * 500
+	pingTimeout                             = time.Second * 5
+	defaultMaxReconnects                    = 2
+	defaultReconnectBackoff                 = time.Second
 )
 
 type clientInfo struct {
@@ -61,6 +64,16 @@ type ConnectionKey deviceArch
 // Client handles connections to GAPIR instances on devices.
 // A single Client can handle multiple connections.
 type Client struct {
+	// MaxReconnects is the number of times a GAPIR instance that dies during a
+	// replay is relaunched to retry the replay, before the error is reported.
+	MaxReconnects int
+	// ReconnectBackoff is the time to wait before the first relaunch of a GAPIR
+	// instance. The wait is doubled for each further attempt.
+	ReconnectBackoff time.Duration
+
+	// relaunch relaunches the GAPIR instance for a key, see reconnect.
+	relaunch func(ctx context.Context, key ConnectionKey, abi *device.ABI, stale gapir.Connection) (*backgroundConnection, error)
+
 	// Mutex is needed due to the risk that reconnect may happen in another thread
 	mutex       sync.Mutex
 	clientInfos map[ConnectionKey]clientInfo
@@ -68,7 +81,12 @@ type Client struct {
 
 // New returns a newly construct Client.
 func New(ctx context.Context) *Client {
-	client := &Client{clientInfos: map[ConnectionKey]clientInfo{}}
+	client := &Client{
+		MaxReconnects:    defaultMaxReconnects,
+		ReconnectBackoff: defaultReconnectBackoff,
+		clientInfos:      map[ConnectionKey]clientInfo{},
+	}
+	client.relaunch = client.reconnect
 	app.AddCleanup(ctx, func() {
 		client.shutdown(ctx)
 	})
@@ -107,11 +125,11 @@ func (client *Client) Connect(ctx context.Context, device bind.Device, abi *devi
 		return nil, log.Err(ctx, err, "Timeout waiting for connection")
 	}
 
-	crash.Go(func() { client.heartbeat(ctx, heartbeatInterval, key) })
+	crash.Go(func() { client.heartbeat(ctx, heartbeatInterval, key, abi, connection) })
 
 	log.I(ctx, "Heartbeat connection setup done")
 
-	bgConnection, err := client.makeBackgroundConnection(ctx, device, connection)
+	bgConnection, err := client.makeBackgroundConnection(ctx, key, connection)
 	if err != nil {
 		return nil, log.Err(ctx, err, "Background connection error")
 	}
@@ -126,8 +144,8 @@ func (client *Client) Connect(ctx context.Context, device bind.Device, abi *devi
 	return &key, nil
 }
 
-func (client *Client) makeBackgroundConnection(ctx context.Context, device bind.Device, conn gapir.Connection) (*backgroundConnection, error) {
-	bgc := &backgroundConnection{conn: conn, OS: device.Instance().GetConfiguration().GetOS()}
+func (client *Client) makeBackgroundConnection(ctx context.Context, key ConnectionKey, conn gapir.Connection) (*backgroundConnection, error) {
+	bgc := &backgroundConnection{conn: conn, OS: key.device.Instance().GetConfiguration().GetOS()}
 
 	connected := make(chan error)
 	cctx := keys.Clone(context.Background(), ctx)
@@ -143,7 +161,7 @@ func (client *Client) makeBackgroundConnection(ctx context.Context, device bind.
 			log.E(cctx, "Error communication with gapir: %v", err)
 		}
 
-		bgc.HandleFinished(ctx, err)
+		client.connectionLost(ctx, key, bgc, err)
 	})
 	err := <-connected
 	if err != nil {
@@ -182,13 +200,26 @@ func (client *Client) shutdown(ctx context.Context) {
 	client.clientInfos = nil
 }
 
-func (client *Client) reconnect(ctx context.Context, key ConnectionKey) {
-	clientInfo := client.clientInfos[key]
-	device := clientInfo.device
-	abi := clientInfo.abi
+// reconnect relaunches the GAPIR instance for key if it is still served by
+// the connection stale, and returns the background connection of the new
+// instance. If the instance has already been relaunched, the background
+// connection of that instance is returned instead.
+func (client *Client) reconnect(ctx context.Context, key ConnectionKey, abi *device.ABI, stale gapir.Connection) (*backgroundConnection, error) {
+	client.mutex.Lock()
+	if clientInfo, ok := client.clientInfos[key]; ok && clientInfo.connection == stale {
+		client.closeConnection(ctx, key)
+		delete(client.clientInfos, key)
+	}
+	client.mutex.Unlock()
 
-	client.removeConnection(ctx, key)
-	client.Connect(ctx, device, abi)
+	if _, err := client.Connect(ctx, key.device, abi); err != nil {
+		return nil, err
+	}
+	clientInfo, err := client.lookup(ctx, &key)
+	if err != nil {
+		return nil, err
+	}
+	return clientInfo.bgConnection, nil
 }
 
 func (client *Client) ping(ctx context.Context, connection gapir.Connection) (time.Duration, error) {
@@ -204,30 +235,130 @@ func (client *Client) ping(ctx context.Context, connection gapir.Connection) (ti
 	return time.Since(start), nil
 }
 
-func (client *Client) heartbeat(ctx context.Context, pingInterval time.Duration, key ConnectionKey) {
+func (client *Client) heartbeat(ctx context.Context, pingInterval time.Duration, key ConnectionKey, abi *device.ABI, connection gapir.Connection) {
 	for {
 		select {
 		case <-task.ShouldStop(ctx):
 			return
 		case <-time.After(pingInterval):
-			_, err := client.ping(ctx, client.clientInfos[key].connection)
+			if !client.isConnection(key, connection) {
+				// The instance has been relaunched or closed.
+				return
+			}
+			_, err := client.ping(ctx, connection)
 			if err != nil {
 				log.E(ctx, "Error sending keep-alive ping. Error: %v", err)
-				client.reconnect(ctx, key)
+				client.reconnect(ctx, key, abi, connection)
 				return
 			}
 		}
 	}
 }
 
+// Health describes the liveness of a GAPIR instance, as returned by Ping.
+type Health struct {
+	// Key is the key of the connection to the instance.
+	Key ConnectionKey
+	// Device is the device the instance runs on.
+	Device bind.Device
+	// Arch is the architecture of the instance.
+	Arch device.Architecture
+	// Alive is true if the instance responded to the ping.
+	Alive bool
+	// Latency is the round-trip time of the ping, if the instance is alive.
+	Latency time.Duration
+	// Err is the reason the ping failed, if the instance is not alive.
+	Err error
+}
+
+// Ping sends a ping to each of the GAPIR instances handled by the client and
+// returns their health. An instance that has not responded after a few seconds
+// is reported as not alive.
+func (client *Client) Ping(ctx context.Context) []Health {
+	client.mutex.Lock()
+	out := make([]Health, 0, len(client.clientInfos))
+	connections := make([]gapir.Connection, 0, len(client.clientInfos))
+	for key, clientInfo := range client.clientInfos {
+		out = append(out, Health{Key: key, Device: key.device, Arch: key.arch})
+		connections = append(connections, clientInfo.connection)
+	}
+	client.mutex.Unlock()
+
+	wg := sync.WaitGroup{}
+	for i := range out {
+		i := i
+		wg.Add(1)
+		crash.Go(func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+			defer cancel()
+			out[i].Latency, out[i].Err = client.ping(ctx, connections[i])
+			out[i].Alive = out[i].Err == nil
+		})
+	}
+	wg.Wait()
+	return out
+}
+
+// isConnection returns true if key is currently served by connection.
+func (client *Client) isConnection(key ConnectionKey, connection gapir.Connection) bool {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	clientInfo, ok := client.clientInfos[key]
+	return ok && clientInfo.connection == connection
+}
+
+// lookup returns the clientInfo for conn, or an error if there is no open
+// connection for conn.
+func (client *Client) lookup(ctx context.Context, conn *ConnectionKey) (clientInfo, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	clientInfo, ok := client.clientInfos[*conn]
+	if !ok {
+		return clientInfo, log.Err(ctx, nil, "Connection could not be found!")
+	}
+	return clientInfo, nil
+}
+
 func (client *Client) BeginReplay(ctx context.Context, conn *ConnectionKey, payload string, dependent string) error {
-	return client.clientInfos[*conn].bgConnection.BeginReplay(ctx, payload, dependent)
+	clientInfo, err := client.lookup(ctx, conn)
+	if err != nil {
+		return err
+	}
+	bgc := clientInfo.bgConnection
+	if r, ok := bgc.currentExecutor().(*retryExecutor); ok {
+		r.begin(payload, dependent)
+	}
+	return bgc.BeginReplay(ctx, payload, dependent)
 }
 
+// SetReplayExecutor sets the executor that handles the responses of the next
+// replay on conn. If the GAPIR instance dies before the replay has finished,
+// the replay is retried on a relaunched instance up to MaxReconnects times.
+// The executor is only handed the responses it has not yet been handed.
+// The returned function must be called once the replay has finished.
 func (client *Client) SetReplayExecutor(ctx context.Context, conn *ConnectionKey, executor ReplayExecutor) (func(), error) {
-	return client.clientInfos[*conn].bgConnection.SetReplayExecutor(ctx, executor)
+	clientInfo, err := client.lookup(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	r := newRetryExecutor(executor, clientInfo.abi)
+	if _, err := clientInfo.bgConnection.SetReplayExecutor(ctx, r); err != nil {
+		return nil, err
+	}
+	key := *conn
+	return func() {
+		// The replay may have moved to a relaunched instance.
+		if clientInfo, err := client.lookup(ctx, &key); err == nil {
+			clientInfo.bgConnection.clearExecutor(r)
+		}
+	}, nil
 }
 
 func (client *Client) PrewarmReplay(ctx context.Context, conn *ConnectionKey, payload string, cleanup string) error {
-	return client.clientInfos[*conn].bgConnection.PrewarmReplay(ctx, payload, cleanup)
+	clientInfo, err := client.lookup(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return clientInfo.bgConnection.PrewarmReplay(ctx, payload, cleanup)
 }
@@ -0,0 +1,88 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapir"
+)
+
+// pingConnection is a gapir.Connection that answers pings with err, or never
+// answers them if hang is set.
+type pingConnection struct {
+	gapir.Connection
+	err  error
+	hang bool
+}
+
+func (c *pingConnection) Ping(ctx context.Context) error {
+	if c.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return c.err
+}
+
+func TestPing(t *testing.T) {
+	ctx := log.Testing(t)
+	failure := errors.New("ping failed")
+	arches := []device.Architecture{device.ARMv7a, device.ARMv8a, device.X86, device.X86_64}
+	client := &Client{clientInfos: map[ConnectionKey]clientInfo{
+		{arch: arches[0]}: {connection: &pingConnection{}},
+		{arch: arches[1]}: {connection: &pingConnection{err: failure}},
+		{arch: arches[2]}: {connection: &pingConnection{hang: true}},
+		{arch: arches[3]}: {},
+	}}
+
+	// A short deadline stands in for the ping timeout of the hung instance.
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	health := client.Ping(ctx)
+	sort.Slice(health, func(i, j int) bool { return health[i].Arch < health[j].Arch })
+	if !assert.For(ctx, "instances").That(len(health)).Equals(len(arches)) {
+		return
+	}
+	for i, h := range health {
+		assert.For(ctx, "arch").That(h.Arch).Equals(arches[i])
+		assert.For(ctx, "key").That(h.Key).Equals(ConnectionKey{arch: arches[i]})
+	}
+
+	assert.For(ctx, "alive").That(health[0].Alive).Equals(true)
+	assert.For(ctx, "alive err").ThatError(health[0].Err).Succeeded()
+
+	assert.For(ctx, "failed").That(health[1].Alive).Equals(false)
+	assert.For(ctx, "failed err").ThatError(health[1].Err).Equals(failure)
+	assert.For(ctx, "failed latency").That(health[1].Latency).Equals(time.Duration(0))
+
+	assert.For(ctx, "hung").That(health[2].Alive).Equals(false)
+	assert.For(ctx, "hung err").ThatError(health[2].Err).Equals(context.DeadlineExceeded)
+
+	assert.For(ctx, "unconnected").That(health[3].Alive).Equals(false)
+	assert.For(ctx, "unconnected err").ThatError(health[3].Err).Failed()
+}
+
+func TestPingNoInstances(t *testing.T) {
+	ctx := log.Testing(t)
+	client := &Client{clientInfos: map[ConnectionKey]clientInfo{}}
+	assert.For(ctx, "health").That(len(client.Ping(ctx))).Equals(0)
+}
@@ -0,0 +1,208 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapir"
+)
+
+// retryExecutor is the ReplayExecutor installed on a background connection
+// by Client.SetReplayExecutor. It forwards the responses of a replay to the
+// caller's executor, and holds what is needed to restart the replay on a
+// relaunched GAPIR instance should the connection be lost.
+//
+// Replays are deterministic, so a restarted replay sends back the responses
+// that were already forwarded before the connection was lost. These are
+// dropped so that the caller's executor sees each response only once.
+type retryExecutor struct {
+	executor ReplayExecutor
+	abi      *device.ABI
+
+	// The fields below are written by the replay caller, the replay
+	// communication handlers and the retry loop, so they are guarded by mutex.
+	mutex     sync.Mutex
+	payload   string
+	dependent string
+	begun     bool
+	done      bool
+	attempts  int
+
+	postData      map[uint64]bool // IDs of the post data pieces forwarded
+	fences        map[uint32]bool // IDs of the fence ready requests forwarded
+	notifications int             // Number of notifications forwarded
+	skip          int             // Number of notifications left to drop
+}
+
+func newRetryExecutor(executor ReplayExecutor, abi *device.ABI) *retryExecutor {
+	return &retryExecutor{
+		executor: executor,
+		abi:      abi,
+		postData: map[uint64]bool{},
+		fences:   map[uint32]bool{},
+	}
+}
+
+// begin records the replay that is sent to the executor's connection.
+func (r *retryExecutor) begin(payload, dependent string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.payload, r.dependent, r.begun = payload, dependent, true
+}
+
+// inFlight returns true if the replay has begun but has not finished.
+func (r *retryExecutor) inFlight() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.begun && !r.done
+}
+
+// restart prepares the executor for the replay to be sent again, and returns
+// the number of the attempt, or false if max attempts have already been made.
+func (r *retryExecutor) restart(max int) (int, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.attempts >= max {
+		return r.attempts, false
+	}
+	r.attempts++
+	r.skip = r.notifications
+	return r.attempts, true
+}
+
+// replay returns the payload and dependent of the replay.
+func (r *retryExecutor) replay() (payload, dependent string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.payload, r.dependent
+}
+
+// HandlePostData implements the ReplayExecutor interface.
+func (r *retryExecutor) HandlePostData(ctx context.Context, postData *gapir.PostData) error {
+	r.mutex.Lock()
+	pieces := make([]*gapir.PostDataPiece, 0, len(postData.GetPostDataPieces()))
+	for _, p := range postData.GetPostDataPieces() {
+		if !r.postData[p.GetID()] {
+			r.postData[p.GetID()] = true
+			pieces = append(pieces, p)
+		}
+	}
+	r.mutex.Unlock()
+	if len(pieces) == 0 {
+		return nil
+	}
+	return r.executor.HandlePostData(ctx, &gapir.PostData{PostDataPieces: pieces})
+}
+
+// HandleNotification implements the ReplayExecutor interface.
+func (r *retryExecutor) HandleNotification(ctx context.Context, notification *gapir.Notification) error {
+	// Notification IDs identify the reader, not the message, so notifications
+	// already forwarded are counted off instead.
+	r.mutex.Lock()
+	if r.skip > 0 {
+		r.skip--
+		r.mutex.Unlock()
+		return nil
+	}
+	r.notifications++
+	r.mutex.Unlock()
+	return r.executor.HandleNotification(ctx, notification)
+}
+
+// HandleFenceReadyRequest implements the ReplayExecutor interface.
+// The fence is still acknowledged by the background connection when the
+// request is dropped, as the relaunched GAPIR instance waits on it.
+func (r *retryExecutor) HandleFenceReadyRequest(ctx context.Context, req *gapir.FenceReadyRequest) error {
+	r.mutex.Lock()
+	seen := r.fences[req.GetId()]
+	r.fences[req.GetId()] = true
+	r.mutex.Unlock()
+	if seen {
+		return nil
+	}
+	return r.executor.HandleFenceReadyRequest(ctx, req)
+}
+
+// HandleFinished implements the ReplayExecutor interface.
+func (r *retryExecutor) HandleFinished(ctx context.Context, err error) error {
+	r.mutex.Lock()
+	r.done = true
+	r.mutex.Unlock()
+	return r.executor.HandleFinished(ctx, err)
+}
+
+// connectionLost is called when the replay stream of bgc ends with err.
+// If a replay is in flight on bgc, it is retried on a relaunched GAPIR
+// instance up to MaxReconnects times before err is reported to its executor.
+// The replay is not retried once ctx is stopped, as the client is then being
+// shut down.
+func (client *Client) connectionLost(ctx context.Context, key ConnectionKey, bgc *backgroundConnection, err error) {
+	r, ok := bgc.currentExecutor().(*retryExecutor)
+	if !ok || !r.inFlight() || task.Stopped(ctx) {
+		bgc.HandleFinished(ctx, err)
+		return
+	}
+
+	bgc.clearExecutor(r)
+	stale, prewarm := bgc.conn, bgc.lastPrewarm()
+	payload, dependent := r.replay()
+	for {
+		attempt, ok := r.restart(client.MaxReconnects)
+		if !ok {
+			break
+		}
+		backoff := client.ReconnectBackoff << uint(attempt-1)
+		log.W(ctx, "Lost connection to GAPIR during replay %v: %v. Retrying in %v (attempt %d of %d)",
+			payload, err, backoff, attempt, client.MaxReconnects)
+		select {
+		case <-task.ShouldStop(ctx):
+			r.HandleFinished(ctx, log.Errf(ctx, err, "Replay %v not retried: the client is shutting down", payload))
+			return
+		case <-time.After(backoff):
+		}
+
+		var next *backgroundConnection
+		if next, err = client.relaunch(ctx, key, r.abi, stale); err != nil {
+			continue
+		}
+		stale = next.conn
+		// The relaunched instance has lost the state of the prewarmed replay,
+		// which the retried replay may depend on.
+		if prewarm != nil && next.lastPrewarm() == nil {
+			if err = next.PrewarmReplay(ctx, prewarm.payload, prewarm.cleanup); err != nil {
+				continue
+			}
+		}
+		if _, err = next.SetReplayExecutor(ctx, r); err != nil {
+			continue
+		}
+		if err = next.BeginReplay(ctx, payload, dependent); err != nil {
+			next.clearExecutor(r)
+			continue
+		}
+		return
+	}
+
+	if err == nil {
+		err = log.Errf(ctx, nil, "Connection to GAPIR lost during replay %v", payload)
+	}
+	r.HandleFinished(ctx, err)
+}
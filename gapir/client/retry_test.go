@@ -0,0 +1,176 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapir"
+)
+
+// fakeConnection is a gapir.Connection that records the replays requested.
+type fakeConnection struct {
+	gapir.Connection
+	mutex     sync.Mutex
+	prewarmed []string
+	begun     []string
+}
+
+func (c *fakeConnection) PrewarmReplay(ctx context.Context, payload string, cleanup string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.prewarmed = append(c.prewarmed, payload)
+	return nil
+}
+
+func (c *fakeConnection) BeginReplay(ctx context.Context, id string, dep string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.begun = append(c.begun, id)
+	return nil
+}
+
+// fakeExecutor is a ReplayExecutor that records the notifications and the
+// result of the replay.
+type fakeExecutor struct {
+	notifications int
+	finished      chan error
+}
+
+func (e *fakeExecutor) HandlePostData(context.Context, *gapir.PostData) error { return nil }
+func (e *fakeExecutor) HandleNotification(context.Context, *gapir.Notification) error {
+	e.notifications++
+	return nil
+}
+func (e *fakeExecutor) HandleFenceReadyRequest(context.Context, *gapir.FenceReadyRequest) error {
+	return nil
+}
+func (e *fakeExecutor) HandleFinished(ctx context.Context, err error) error {
+	e.finished <- err
+	return nil
+}
+
+// fakeRelaunch relaunches GAPIR instances with fake connections, failing the
+// first failures relaunches.
+type fakeRelaunch struct {
+	failures int
+	times    []time.Time
+	bgcs     []*backgroundConnection
+}
+
+func (f *fakeRelaunch) relaunch(ctx context.Context, key ConnectionKey, abi *device.ABI, stale gapir.Connection) (*backgroundConnection, error) {
+	f.times = append(f.times, time.Now())
+	if len(f.times) <= f.failures {
+		return nil, log.Err(ctx, nil, "Relaunch failed")
+	}
+	bgc := &backgroundConnection{conn: &fakeConnection{}}
+	f.bgcs = append(f.bgcs, bgc)
+	return bgc, nil
+}
+
+// startReplay returns a background connection with a replay in flight, which
+// depends on a prewarmed replay.
+func startReplay(ctx context.Context) (*backgroundConnection, *retryExecutor, *fakeExecutor) {
+	bgc := &backgroundConnection{conn: &fakeConnection{}}
+	executor := &fakeExecutor{finished: make(chan error, 1)}
+	r := newRetryExecutor(executor, device.AndroidARM64v8a)
+	bgc.SetReplayExecutor(ctx, r)
+	bgc.PrewarmReplay(ctx, "prewarm", "cleanup")
+	bgc.BeginReplay(ctx, "payload", "prewarm")
+	r.begin("payload", "prewarm")
+	return bgc, r, executor
+}
+
+func TestRetry(t *testing.T) {
+	ctx := log.Testing(t)
+	backoff := 10 * time.Millisecond
+
+	// The replay is retried on the second relaunched instance.
+	f := &fakeRelaunch{failures: 1}
+	client := &Client{MaxReconnects: 2, ReconnectBackoff: backoff, relaunch: f.relaunch}
+	bgc, r, executor := startReplay(ctx)
+	r.HandleNotification(ctx, &gapir.Notification{})
+	start := time.Now()
+	client.connectionLost(ctx, ConnectionKey{}, bgc, log.Err(ctx, nil, "Connection lost"))
+
+	if assert.For(ctx, "relaunches").That(len(f.times)).Equals(2) {
+		assert.For(ctx, "first backoff").That(f.times[0].Sub(start) >= backoff).Equals(true)
+		assert.For(ctx, "second backoff").That(f.times[1].Sub(f.times[0]) >= 2*backoff).Equals(true)
+	}
+	if assert.For(ctx, "connections").That(len(f.bgcs)).Equals(1) {
+		next := f.bgcs[0]
+		conn := next.conn.(*fakeConnection)
+		assert.For(ctx, "prewarmed").That(conn.prewarmed).DeepEquals([]string{"prewarm"})
+		assert.For(ctx, "begun").That(conn.begun).DeepEquals([]string{"payload"})
+		assert.For(ctx, "executor").That(next.currentExecutor()).Equals(r)
+	}
+	assert.For(ctx, "old executor").That(bgc.currentExecutor()).IsNil()
+	assert.For(ctx, "finished").That(len(executor.finished)).Equals(0)
+
+	// The restarted replay sends back the notification already forwarded.
+	r.HandleNotification(ctx, &gapir.Notification{})
+	r.HandleNotification(ctx, &gapir.Notification{})
+	assert.For(ctx, "notifications").That(executor.notifications).Equals(2)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	ctx := log.Testing(t)
+	f := &fakeRelaunch{failures: 3}
+	client := &Client{MaxReconnects: 2, ReconnectBackoff: time.Millisecond, relaunch: f.relaunch}
+	bgc, _, executor := startReplay(ctx)
+	client.connectionLost(ctx, ConnectionKey{}, bgc, log.Err(ctx, nil, "Connection lost"))
+
+	assert.For(ctx, "relaunches").That(len(f.times)).Equals(2)
+	if assert.For(ctx, "finished").That(len(executor.finished)).Equals(1) {
+		assert.For(ctx, "err").ThatError(<-executor.finished).Failed()
+	}
+}
+
+func TestRetryShutdown(t *testing.T) {
+	ctx := log.Testing(t)
+
+	// The replay is not retried once the client is shutting down.
+	f := &fakeRelaunch{}
+	client := &Client{MaxReconnects: 2, ReconnectBackoff: time.Millisecond, relaunch: f.relaunch}
+	bgc, _, executor := startReplay(ctx)
+	stopped, cancel := task.WithCancel(ctx)
+	cancel()
+	client.connectionLost(stopped, ConnectionKey{}, bgc, log.Err(ctx, nil, "Connection lost"))
+	assert.For(ctx, "relaunches").That(len(f.times)).Equals(0)
+	if assert.For(ctx, "finished").That(len(executor.finished)).Equals(1) {
+		assert.For(ctx, "err").ThatError(<-executor.finished).Failed()
+	}
+
+	// The wait before a relaunch ends when the client shuts down.
+	client.ReconnectBackoff = time.Hour
+	bgc, _, executor = startReplay(ctx)
+	stopping, cancel := task.WithCancel(ctx)
+	go client.connectionLost(stopping, ConnectionKey{}, bgc, log.Err(ctx, nil, "Connection lost"))
+	cancel()
+	select {
+	case err := <-executor.finished:
+		assert.For(ctx, "err").ThatError(err).Failed()
+	case <-time.After(10 * time.Second):
+		t.Fatal("Retry did not stop on shutdown")
+	}
+	assert.For(ctx, "relaunches").That(len(f.times)).Equals(0)
+}
@@ -51,6 +51,8 @@ type Cmd interface {
 	CmdResult() *Property
 
 	// CmdFlags returns the flags of the command.
+	// If the state is nil, the flags that depend on the state are all
+	// returned, as the command may have any of them.
 	CmdFlags(context.Context, CmdID, *GlobalState) CmdFlags
 
 	// Extras returns all the Extras associated with the command.
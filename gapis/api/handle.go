@@ -0,0 +1,24 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+// Handle is the interface implemented by the types of API object handles, such
+// as buffers, textures or Vulkan objects. Handles are opaque identifiers, so a
+// capture stays valid if all of its handles of an API are offset by the same
+// amount.
+type Handle interface {
+	// IsHandle is a dummy function to make the type implement Handle.
+	IsHandle()
+}
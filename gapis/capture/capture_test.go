@@ -15,17 +15,23 @@
 package capture_test
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
+	"io"
 	"testing"
 
 	"github.com/google/gapid/core/assert"
 	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/math/interval"
 	"github.com/google/gapid/core/memory/arena"
 	"github.com/google/gapid/core/os/device"
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/api/test"
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/service"
 )
 
 func TestCaptureExportImport(t *testing.T) {
@@ -62,3 +68,250 @@ func TestCaptureExportImport(t *testing.T) {
 
 	assert.For(ctx, "got").That(ic.(*capture.GraphicsCapture).Commands).CustomDeepEquals(cmds, test.Cmds.IgnoreArena)
 }
+
+func TestCmdIterator(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{test.Cmds.A, test.Cmds.B}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if !assert.For(ctx, "Export").ThatError(c.Export(ctx, buf)).Succeeded() {
+		return
+	}
+
+	it, err := capture.NewCmdIterator(ctx, &capture.Blob{Data: buf.Bytes()})
+	if !assert.For(ctx, "capture.NewCmdIterator").ThatError(err).Succeeded() {
+		return
+	}
+	defer it.Close()
+
+	got := []api.Cmd{}
+	for {
+		id, cmd, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.For(ctx, "Next").ThatError(err).Succeeded() {
+			return
+		}
+		assert.For(ctx, "id").That(id).Equals(api.CmdID(len(got)))
+		got = append(got, cmd)
+	}
+	assert.For(ctx, "got").That(got).CustomDeepEquals(cmds, test.Cmds.IgnoreArena)
+	assert.For(ctx, "header ABI").That(it.Header().ABI).DeepEquals(header.ABI)
+}
+
+func TestReadInfo(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cb := test.CommandBuilder{Arena: test.Cmds.Arena}
+	for _, c := range []struct {
+		name   string
+		cmds   []api.Cmd
+		frames uint64
+		draws  uint64
+	}{
+		{"no flags", []api.Cmd{test.Cmds.A, test.Cmds.B}, 0, 0},
+		// The draw call flag of cmdDraw depends on the state, so every draw
+		// is counted.
+		{"frame starts", []api.Cmd{
+			cb.CmdDraw(), cb.CmdStartFrame(), cb.CmdDraw(), cb.CmdDraw(), cb.CmdStartFrame(),
+		}, 2, 3},
+		{"frame ends", []api.Cmd{
+			cb.CmdDraw(), cb.CmdEndFrame(), cb.CmdDraw(), cb.CmdEndFrame(), test.Cmds.A,
+		}, 2, 2},
+		// Each frame is counted once when it has both a start and an end.
+		{"frame starts and ends", []api.Cmd{
+			cb.CmdDraw(), cb.CmdEndFrame(), cb.CmdStartFrame(), cb.CmdDraw(), cb.CmdEndFrame(), cb.CmdStartFrame(),
+		}, 2, 2},
+	} {
+		ctx := log.Enter(ctx, c.name)
+		capt, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, c.cmds)
+		if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		if !assert.For(ctx, "Export").ThatError(capt.Export(ctx, buf)).Succeeded() {
+			continue
+		}
+
+		info, err := capture.ReadInfo(ctx, "info", &capture.Blob{Data: buf.Bytes()})
+		if !assert.For(ctx, "capture.ReadInfo").ThatError(err).Succeeded() {
+			continue
+		}
+		assert.For(ctx, "type").That(info.Type).Equals(service.TraceType_Graphics)
+		assert.For(ctx, "name").That(info.Name).Equals("info")
+		assert.For(ctx, "ABI").That(info.ABI).DeepEquals(header.ABI)
+		assert.For(ctx, "APIs").That(info.APIs).DeepEquals([]string{test.API{}.Name()})
+		assert.For(ctx, "commands").That(info.NumCommands).Equals(uint64(len(c.cmds)))
+		assert.For(ctx, "frames").That(info.NumFrames).Equals(c.frames)
+		assert.For(ctx, "draw calls").That(info.NumDrawCalls).Equals(c.draws)
+	}
+
+	_, err := capture.ReadInfo(ctx, "bad", &capture.Blob{Data: []byte("not a capture")})
+	assert.For(ctx, "bad capture").ThatError(err).Failed()
+}
+
+func TestExportJSON(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{test.Cmds.A, test.Cmds.B}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if !assert.For(ctx, "capture.ExportJSON").ThatError(capture.ExportJSON(ctx, c, buf)).Succeeded() {
+		return
+	}
+
+	type param struct {
+		Name  string
+		Value interface{}
+	}
+	type command struct {
+		ID     uint64
+		Name   string
+		Params []param
+	}
+	got := []command{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		cmd := command{}
+		if !assert.For(ctx, "json.Unmarshal").ThatError(json.Unmarshal(scanner.Bytes(), &cmd)).Succeeded() {
+			return
+		}
+		got = append(got, cmd)
+	}
+	if !assert.For(ctx, "commands").ThatSlice(got).IsLength(len(cmds)) {
+		return
+	}
+	for i, cmd := range got {
+		ctx := log.V{"id": i}.Bind(ctx)
+		assert.For(ctx, "id").That(cmd.ID).Equals(uint64(i))
+		assert.For(ctx, "name").That(cmd.Name).Equals(cmds[i].CmdName())
+		assert.For(ctx, "params").ThatSlice(cmd.Params).IsLength(len(cmds[i].CmdParams()))
+	}
+	assert.For(ctx, "U8").That(got[0].Params[1]).DeepEquals(param{"U8", float64(10)})
+}
+
+func TestMerge(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64, Device: &device.Instance{Name: "a"}}
+	otherDevice := &capture.Header{ABI: device.WindowsX86_64, Device: &device.Instance{Name: "b"}}
+	otherABI := &capture.Header{ABI: device.LinuxX86_64, Device: &device.Instance{Name: "a"}}
+
+	newCapture := func(name string, header *capture.Header, cmds ...api.Cmd) *capture.GraphicsCapture {
+		c, err := capture.NewGraphicsCapture(ctx, arena.New(), name, header, nil, cmds)
+		assert.For(ctx, "capture.New").ThatError(err).Succeeded()
+		return c
+	}
+	first := newCapture("first", header, test.Cmds.A)
+	second := newCapture("second", header, test.Cmds.B, test.Cmds.A)
+
+	merged, err := capture.Merge(ctx, "merged", first, second, capture.MergeOptions{})
+	if assert.For(ctx, "capture.Merge").ThatError(err).Succeeded() {
+		expected := []api.Cmd{test.Cmds.A, test.Cmds.B, test.Cmds.A}
+		assert.For(ctx, "commands").That(merged.Commands).CustomDeepEquals(expected, test.Cmds.IgnoreArena)
+		assert.For(ctx, "linear").ThatBoolean(merged.IsLinear()).Equals(true)
+	}
+
+	_, err = capture.Merge(ctx, "merged", first, newCapture("other", otherDevice, test.Cmds.B), capture.MergeOptions{})
+	assert.For(ctx, "different devices").ThatError(err).Failed()
+
+	_, err = capture.Merge(ctx, "merged", first, newCapture("other", otherDevice, test.Cmds.B),
+		capture.MergeOptions{AllowAPIMismatch: true, AllowUnrebaseable: true})
+	assert.For(ctx, "different devices (other mismatches allowed)").ThatError(err).Failed()
+
+	_, err = capture.Merge(ctx, "merged", first, newCapture("other", otherDevice, test.Cmds.B),
+		capture.MergeOptions{AllowDeviceMismatch: true})
+	assert.For(ctx, "different devices (allowed)").ThatError(err).Succeeded()
+
+	all := capture.MergeOptions{AllowDeviceMismatch: true, AllowAPIMismatch: true, AllowUnrebaseable: true}
+	_, err = capture.Merge(ctx, "merged", first, newCapture("other", otherABI, test.Cmds.B), all)
+	assert.For(ctx, "different ABIs").ThatError(err).Failed()
+}
+
+func TestMergeRebase(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64, Device: &device.Instance{Name: "a"}}
+	layout := header.ABI.MemoryLayout
+	a := arena.New()
+	defer a.Dispose()
+	cb := test.CommandBuilder{Arena: a}
+
+	newCapture := func(name string, cmds ...api.Cmd) *capture.GraphicsCapture {
+		c, err := capture.NewGraphicsCapture(ctx, arena.New(), name, header, nil, cmds)
+		assert.For(ctx, "capture.New").ThatError(err).Succeeded()
+		return c
+	}
+	p := memory.BytePtr(0x1000)
+	write := func(p memory.Pointer, handles ...test.Remapped) api.Cmd {
+		return cb.CmdVoidOutArrayOfRemapped(p).AddWrite(memory.Store(ctx, layout, p, handles))
+	}
+
+	// Both captures use the same handles and the same memory.
+	first := newCapture("first", write(p, 1, 2, 3, 4, 5), cb.CmdVoid3Remapped(1, 2, 3))
+	second := newCapture("second", write(p, 1, 2, 3, 4, 5), cb.CmdVoid3Remapped(1, 2, 0))
+
+	merged, err := capture.Merge(ctx, "merged", first, second, capture.MergeOptions{})
+	if assert.For(ctx, "capture.Merge").ThatError(err).Succeeded() {
+		assert.For(ctx, "commands").That(len(merged.Commands)).Equals(4)
+		assert.For(ctx, "first").That(merged.Commands[:2]).DeepEquals(first.Commands)
+
+		moved := memory.BytePtr(0x2000)
+		rng, data := memory.Store(ctx, layout, moved, []test.Remapped{6, 7, 8, 9, 10})
+		out := merged.Commands[2].(*test.CmdVoidOutArrayOfRemapped)
+		assert.For(ctx, "pointer").That(out.A().Address()).Equals(moved.Address())
+		assert.For(ctx, "observations").That(out.Extras().Observations().Writes).DeepEquals(
+			[]api.CmdObservation{{Pool: memory.ApplicationPool, Range: rng, ID: data}})
+
+		handles := merged.Commands[3].(*test.CmdVoid3Remapped)
+		assert.For(ctx, "handles").That([]test.Remapped{handles.A(), handles.B(), handles.C()}).
+			DeepEquals([]test.Remapped{6, 7, 0})
+
+		assert.For(ctx, "observed").That(merged.Observed).DeepEquals(interval.U64RangeList{
+			{First: 0x1000, Count: 20},
+			{First: 0x2000, Count: 20},
+		})
+
+		// The source capture is left unchanged.
+		src := second.Commands[0].(*test.CmdVoidOutArrayOfRemapped)
+		assert.For(ctx, "source pointer").That(src.A().Address()).Equals(p.Address())
+		assert.For(ctx, "source handle").That(second.Commands[1].(*test.CmdVoid3Remapped).A()).Equals(test.Remapped(1))
+	}
+
+	structs := newCapture("structs", cb.CmdVoidReadRemappedStruct(p).AddRead(memory.Store(ctx, layout, p, []byte{1, 2, 3, 4})))
+	_, err = capture.Merge(ctx, "merged", first, structs, capture.MergeOptions{})
+	assert.For(ctx, "structures").ThatError(err).Failed()
+
+	_, err = capture.Merge(ctx, "merged", first, structs, capture.MergeOptions{AllowUnrebaseable: true})
+	assert.For(ctx, "structures (allowed)").ThatError(err).Succeeded()
+
+	// Structures in the first capture do not make the merge fail, and the
+	// handles they may hold are not reused. The RemappedStruct holds the
+	// handle 20, followed by a value so large that it is not taken as a handle.
+	s := []byte{
+		0, 0, 0, 0, 0, 0, 0, 0, // F1
+		20, 0, 0, 0, // Handle
+		0xff, 0xff, 0xff, 0xff, // F3
+	}
+	withStructs := newCapture("first", cb.CmdVoidReadRemappedStruct(p).AddRead(memory.Store(ctx, layout, p, s)))
+	merged, err = capture.Merge(ctx, "merged", withStructs, newCapture("second", cb.CmdVoid3Remapped(1, 2, 0)), capture.MergeOptions{})
+	if assert.For(ctx, "capture.Merge (structures first)").ThatError(err).Succeeded() {
+		handles := merged.Commands[1].(*test.CmdVoid3Remapped)
+		assert.For(ctx, "handles").That([]test.Remapped{handles.A(), handles.B(), handles.C()}).
+			DeepEquals([]test.Remapped{21, 22, 0})
+	}
+}
@@ -31,10 +31,22 @@ type cmdGroup struct {
 	children []api.Cmd
 }
 
+type streamedCmd struct {
+	id  api.CmdID
+	cmd api.Cmd
+}
+
 type decoder struct {
 	header  *Header
 	builder *builder
 	groups  map[uint64]interface{}
+
+	// stream, if not nil, is called with each decoded command, in command
+	// identifier order. Commands are held back in pending while any of them
+	// may still be waiting for the parent group that sets their caller.
+	stream  func(ctx context.Context, id api.CmdID, cmd api.Cmd) error
+	pending []streamedCmd
+	parents int // Number of open command groups with child commands.
 }
 
 func newDecoder(a arena.Arena) *decoder {
@@ -93,11 +105,32 @@ func (d *decoder) endGroupImpl(ctx context.Context, id uint64, terminated bool)
 		for _, c := range obj.children {
 			c.SetCaller(id)
 		}
+		if len(obj.children) > 0 {
+			d.parents--
+		}
+		if d.stream != nil {
+			d.pending = append(d.pending, streamedCmd{id, obj.cmd})
+			if d.parents == 0 {
+				return d.streamPending(ctx)
+			}
+		}
 	}
 
 	return nil
 }
 
+// streamPending passes all the pending commands to the stream callback.
+func (d *decoder) streamPending(ctx context.Context) error {
+	pending := d.pending
+	d.pending = nil
+	for _, c := range pending {
+		if err := d.stream(ctx, c.id, c.cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (d *decoder) EndGroup(ctx context.Context, id uint64) error {
 	return d.endGroupImpl(ctx, id, true)
 }
@@ -135,10 +168,10 @@ func (d *decoder) add(ctx context.Context, child, parent interface{}) error {
 
 		switch obj := child.(type) {
 		case api.Cmd:
-			parent.children = append(parent.children, obj)
+			d.addChild(parent, obj)
 
 		case *cmdGroup:
-			parent.children = append(parent.children, obj.cmd)
+			d.addChild(parent, obj.cmd)
 
 		case api.CmdObservation:
 			d.builder.addObservation(ctx, &obj)
@@ -169,6 +202,14 @@ func (d *decoder) add(ctx context.Context, child, parent interface{}) error {
 	return nil
 }
 
+// addChild adds the command child to the command group parent.
+func (d *decoder) addChild(parent *cmdGroup, child api.Cmd) {
+	if len(parent.children) == 0 {
+		d.parents++
+	}
+	parent.children = append(parent.children, child)
+}
+
 func (d *decoder) unmarshal(ctx context.Context, in proto.Message) (interface{}, error) {
 	obj, err := protoconv.ToObject(ctx, in)
 	if err != nil {
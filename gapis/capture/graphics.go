@@ -241,42 +241,7 @@ func deserializeGFXTrace(ctx context.Context, r *Record, in io.Reader) (out *Gra
 	ctx = id.PutRemapper(ctx, d)
 
 	if err := pack.Read(ctx, in, d, false); err != nil {
-		switch err := errors.Cause(err).(type) {
-		case pack.ErrUnsupportedVersion:
-			log.E(ctx, "%v", err)
-			switch {
-			case err.Version.Major > pack.MaxMajorVersion:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason:        messages.ErrFileTooNew(),
-					SuggestUpdate: true,
-				}
-			case err.Version.Major < pack.MinMajorVersion:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason: messages.ErrFileTooOld(),
-				}
-			default:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason: messages.ErrFileCannotBeRead(),
-				}
-			}
-		case ErrUnsupportedVersion:
-			switch {
-			case err.Version > CurrentCaptureVersion:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason:        messages.ErrFileTooNew(),
-					SuggestUpdate: true,
-				}
-			case err.Version < CurrentCaptureVersion:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason: messages.ErrFileTooOld(),
-				}
-			default:
-				return nil, &service.ErrUnsupportedVersion{
-					Reason: messages.ErrFileCannotBeRead(),
-				}
-			}
-		}
-		return nil, err
+		return nil, readError(ctx, err)
 	}
 	d.flush(ctx)
 	if d.header == nil {
@@ -285,11 +250,54 @@ func deserializeGFXTrace(ctx context.Context, r *Record, in io.Reader) (out *Gra
 	return d.builder.build(r.Name, d.header), nil
 }
 
+// readError translates the error err returned when reading a capture into the
+// error reported to the client.
+func readError(ctx context.Context, err error) error {
+	switch err := errors.Cause(err).(type) {
+	case pack.ErrUnsupportedVersion:
+		log.E(ctx, "%v", err)
+		switch {
+		case err.Version.Major > err.Max:
+			return &service.ErrUnsupportedVersion{
+				Reason:        messages.ErrFileTooNew(),
+				SuggestUpdate: true,
+			}
+		case err.Version.Major < err.Min:
+			return &service.ErrUnsupportedVersion{
+				Reason: messages.ErrFileTooOld(),
+			}
+		default:
+			return &service.ErrUnsupportedVersion{
+				Reason: messages.ErrFileCannotBeRead(),
+			}
+		}
+	case ErrUnsupportedVersion:
+		switch {
+		case err.Version > CurrentCaptureVersion:
+			return &service.ErrUnsupportedVersion{
+				Reason:        messages.ErrFileTooNew(),
+				SuggestUpdate: true,
+			}
+		case err.Version < CurrentCaptureVersion:
+			return &service.ErrUnsupportedVersion{
+				Reason: messages.ErrFileTooOld(),
+			}
+		default:
+			return &service.ErrUnsupportedVersion{
+				Reason: messages.ErrFileCannotBeRead(),
+			}
+		}
+	}
+	return err
+}
+
 type builder struct {
 	apis         []api.API
 	seenAPIs     map[api.ID]struct{}
 	observed     interval.U64RangeList
 	cmds         []api.Cmd
+	numCmds      api.CmdID
+	streaming    bool // If true, commands and observed ranges are not kept by the builder.
 	resIDs       []id.ID
 	initialState *InitialState
 	arena        arena.Arena
@@ -318,8 +326,11 @@ func (b *builder) addCmd(ctx context.Context, cmd api.Cmd) api.CmdID {
 			b.addObservation(ctx, &observations.Writes[i])
 		}
 	}
-	id := api.CmdID(len(b.cmds))
-	b.cmds = append(b.cmds, cmd)
+	id := b.numCmds
+	b.numCmds++
+	if !b.streaming {
+		b.cmds = append(b.cmds, cmd)
+	}
 	return id
 }
 
@@ -338,6 +349,9 @@ func (b *builder) addAPI(ctx context.Context, api api.API) {
 }
 
 func (b *builder) addObservation(ctx context.Context, o *api.CmdObservation) {
+	if b.streaming {
+		return
+	}
 	interval.Merge(&b.observed, o.Range.Span(), true)
 }
 
@@ -0,0 +1,102 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+)
+
+// ReadInfo reads the summary of the capture data read from src.
+// Graphics captures are decoded one command at a time, without building the
+// capture or its state, so the summary is available for captures that fail to
+// resolve. The frames and draw calls are counted from the command flags that
+// are known without the state.
+func ReadInfo(ctx context.Context, name string, src Source) (*service.CaptureInfo, error) {
+	in, closeSrc, err := open(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	graphics, perfetto := isGFXTraceFormat(in), isPerfettoTraceFormat(in)
+	closeSrc()
+
+	switch {
+	case graphics:
+		return readGraphicsInfo(ctx, name, src)
+	case perfetto:
+		return &service.CaptureInfo{Type: service.TraceType_Perfetto, Name: name}, nil
+	default:
+		return nil, fmt.Errorf("Not a recognized capture format")
+	}
+}
+
+func readGraphicsInfo(ctx context.Context, name string, src Source) (*service.CaptureInfo, error) {
+	it, err := NewCmdIterator(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	info := &service.CaptureInfo{Type: service.TraceType_Graphics, Name: name}
+	seen := map[api.ID]bool{}
+	starts, ends := uint64(0), uint64(0)
+	addAPI := func(a api.API) {
+		if a != nil && !seen[a.ID()] {
+			seen[a.ID()] = true
+			info.APIs = append(info.APIs, a.Name())
+		}
+	}
+	for {
+		id, cmd, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		info.NumCommands++
+		addAPI(cmd.API())
+		// Without the state, the draw commands of APIs whose draw call flag
+		// depends on the state are all counted.
+		flags := cmd.CmdFlags(ctx, id, nil)
+		if flags.IsStartOfFrame() {
+			starts++
+		}
+		if flags.IsEndOfFrame() {
+			ends++
+		}
+		if flags.IsDrawCall() {
+			info.NumDrawCalls++
+		}
+	}
+	// Some APIs mark the frames by their start and others by their end. A
+	// capture using both, such as GVR on top of GLES, would count each frame
+	// twice, so the ends are used if there are any.
+	if ends > 0 {
+		info.NumFrames = ends
+	} else {
+		info.NumFrames = starts
+	}
+	for a := range it.InitialState().APIs {
+		addAPI(a)
+	}
+	header := it.Header()
+	info.Device, info.ABI = header.Device, header.ABI
+	return info, nil
+}
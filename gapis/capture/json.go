@@ -0,0 +1,131 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+type jsonCmd struct {
+	ID         api.CmdID         `json:"id"`
+	API        string            `json:"api,omitempty"`
+	Name       string            `json:"name"`
+	Thread     uint64            `json:"thread"`
+	Caller     *api.CmdID        `json:"caller,omitempty"`
+	Terminated bool              `json:"terminated"`
+	Params     []jsonParam       `json:"params"`
+	Result     interface{}       `json:"result,omitempty"`
+	Reads      []jsonObservation `json:"reads,omitempty"`
+	Writes     []jsonObservation `json:"writes,omitempty"`
+}
+
+type jsonParam struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// jsonObservation describes an observation by the resource identifier of its
+// data, so that the data itself is never inlined.
+type jsonObservation struct {
+	Pool uint32 `json:"pool"`
+	Base uint64 `json:"base"`
+	Size uint64 `json:"size"`
+	ID   string `json:"id"`
+}
+
+// ExportJSON writes the commands of the capture c to w as newline-delimited
+// JSON, one object per command, holding the command's name, parameters,
+// result and memory observations.
+// Observations are written as the identifiers of their data in the database.
+func ExportJSON(ctx context.Context, c *GraphicsCapture, w io.Writer) error {
+	e := json.NewEncoder(w)
+	for i, cmd := range c.Commands {
+		if err := e.Encode(toJSON(api.CmdID(i), cmd)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toJSON(id api.CmdID, cmd api.Cmd) jsonCmd {
+	out := jsonCmd{
+		ID:         id,
+		Name:       cmd.CmdName(),
+		Thread:     cmd.Thread(),
+		Terminated: cmd.Terminated(),
+		Params:     []jsonParam{},
+	}
+	if a := cmd.API(); a != nil {
+		out.API = a.Name()
+	}
+	if caller := cmd.Caller(); caller != api.CmdNoID {
+		out.Caller = &caller
+	}
+	for _, p := range cmd.CmdParams() {
+		out.Params = append(out.Params, jsonParam{Name: p.Name, Value: jsonValue(p.Get())})
+	}
+	if r := cmd.CmdResult(); r != nil {
+		out.Result = jsonValue(r.Get())
+	}
+	if o := cmd.Extras().Observations(); o != nil {
+		out.Reads = jsonObservations(o.Reads)
+		out.Writes = jsonObservations(o.Writes)
+	}
+	return out
+}
+
+func jsonObservations(l []api.CmdObservation) []jsonObservation {
+	out := make([]jsonObservation, len(l))
+	for i, o := range l {
+		out[i] = jsonObservation{
+			Pool: uint32(o.Pool),
+			Base: o.Range.Base,
+			Size: o.Range.Size,
+			ID:   o.ID.String(),
+		}
+	}
+	return out
+}
+
+// jsonValue returns the value v as it is written to JSON. Booleans, numbers
+// and strings are written as they are. Everything else, like enums, pointers
+// and structures, is written in its printed form, as are the floating-point
+// values that JSON cannot represent.
+func jsonValue(v interface{}) interface{} {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v
+	case reflect.Float32, reflect.Float64:
+		if f := r.Float(); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return v
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,134 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/api"
+)
+
+// MergeOptions holds the differences between two captures that Merge accepts.
+type MergeOptions struct {
+	// AllowDeviceMismatch merges captures that were taken on different devices.
+	AllowDeviceMismatch bool
+	// AllowAPIMismatch merges captures that use different APIs.
+	AllowAPIMismatch bool
+	// AllowUnrebaseable keeps the commands of the second capture that cannot be
+	// rebased as they are, instead of failing the merge.
+	AllowUnrebaseable bool
+}
+
+// Merge returns a new graphics capture with the given name that holds all the
+// commands of first followed by all the commands of second.
+//
+// Both captures must be linear, that is they must not hold any initial state,
+// and they must share the same ABI. The captures must also use the same APIs
+// and have been taken on the same device, unless opts allows otherwise.
+//
+// The commands of second are renumbered to follow those of first, and their
+// object handles and application memory are moved past those used by first,
+// so that the objects and memory of the two captures do not clash. Commands of
+// second that pass structures through memory cannot be moved, and make the
+// merge fail unless opts.AllowUnrebaseable is set, in which case they are kept
+// as they are.
+//
+// The returned capture shares the command objects of first.
+func Merge(ctx context.Context, name string, first, second *GraphicsCapture, opts MergeOptions) (*GraphicsCapture, error) {
+	for _, c := range []*GraphicsCapture{first, second} {
+		if !c.IsLinear() {
+			return nil, log.Errf(ctx, nil, "Capture '%v' has initial state and cannot be merged. Linearize it first", c.Name())
+		}
+	}
+	if !proto.Equal(first.Header.ABI, second.Header.ABI) {
+		return nil, log.Errf(ctx, nil, "Captures have different ABIs: %v and %v", first.Header.ABI, second.Header.ABI)
+	}
+	if !proto.Equal(first.Header.Device, second.Header.Device) {
+		if !opts.AllowDeviceMismatch {
+			return nil, log.Errf(ctx, nil, "Captures were taken on different devices: '%v' and '%v'",
+				first.Header.Device.GetName(), second.Header.Device.GetName())
+		}
+		log.W(ctx, "Merging captures taken on different devices")
+	}
+	if !sameAPIs(first.APIs, second.APIs) {
+		if !opts.AllowAPIMismatch {
+			return nil, log.Errf(ctx, nil, "Captures use different APIs: %v and %v", apiNames(first.APIs), apiNames(second.APIs))
+		}
+		log.W(ctx, "Merging captures that use different APIs")
+	}
+
+	r, err := newRebaser(ctx, first, second, opts.AllowUnrebaseable)
+	if err != nil {
+		return nil, err
+	}
+	b := newBuilder(arena.New())
+	for _, cmd := range first.Commands {
+		b.addCmd(ctx, cmd)
+	}
+	for _, cmd := range second.Commands {
+		cmd, err := r.rebase(ctx, cmd, b.arena)
+		if err != nil {
+			return nil, err
+		}
+		b.addCmd(ctx, cmd)
+	}
+	if r.skipped > 0 {
+		log.W(ctx, "%v commands pass structures through memory and were merged unchanged", r.skipped)
+	}
+	for _, messages := range [][]*TraceMessage{first.Messages, second.Messages} {
+		for _, m := range messages {
+			b.addMessage(ctx, m)
+		}
+	}
+	b.initialState = nil
+
+	hdr := *first.Header
+	hdr.Version = CurrentCaptureVersion
+	return b.build(name, &hdr), nil
+}
+
+// sameAPIs returns true if a and b hold the same set of APIs.
+func sameAPIs(a, b []api.API) bool {
+	ids := map[api.ID]bool{}
+	for _, a := range a {
+		ids[a.ID()] = true
+	}
+	for _, b := range b {
+		if !ids[b.ID()] {
+			return false
+		}
+		delete(ids, b.ID())
+	}
+	return len(ids) == 0
+}
+
+// apiNames returns the names of the given APIs.
+func apiNames(apis []api.API) []string {
+	out := make([]string, len(apis))
+	for i, a := range apis {
+		out[i] = a.Name()
+	}
+	return out
+}
+
+// IsLinear returns true if the capture does not hold any initial state, and so
+// can be replayed from the first command without any setup.
+func (c *GraphicsCapture) IsLinear() bool {
+	return c.InitialState == nil ||
+		(len(c.InitialState.APIs) == 0 && len(c.InitialState.Memory) == 0)
+}
@@ -0,0 +1,421 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/math/interval"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// rebaseAlignment is the granularity of the offset added to the addresses of a
+// rebased capture, so that the alignment of its memory is unchanged.
+const rebaseAlignment = 0x1000
+
+var (
+	tyHandle  = reflect.TypeOf((*api.Handle)(nil)).Elem()
+	tyPointer = reflect.TypeOf((*memory.Pointer)(nil)).Elem()
+)
+
+// rebaser moves the object handles and the application memory used by the
+// commands of a capture, so that they do not clash with those of another
+// capture that the commands are appended to.
+//
+// Handles are offset past the largest handle used by the other capture. The
+// observations of the application pool are offset past the memory observed by
+// the other capture, along with the pointers into the observed memory.
+// Pointers to memory that was not observed, such as offsets into buffers, are
+// kept as they are.
+//
+// Only the handles and pointers held by the command parameters and results,
+// and by the arrays that the parameters point to, are rebased. As the length
+// of an array is not known, it is assumed to extend to the end of the
+// observation that holds it. Structures in memory are not decoded, so commands
+// that pass structures through memory cannot be rebased.
+//
+// As the handles held by structures in memory are not found either, the
+// handles are offset past the largest value of the size of a handle that is
+// held anywhere in the memory observed by the other capture, unless that
+// value is so large that the rebased handles would overflow.
+type rebaser struct {
+	layout    *device.MemoryLayout
+	order     binary.ByteOrder
+	handles   uint64                // Offset added to each handle.
+	addresses uint64                // Offset added to each rebased address.
+	observed  interval.U64RangeList // Memory observed by the rebased capture.
+	scanning  bool                  // If true, commands are only scanned for their handles.
+	maxHandle uint64                // Largest handle found while scanning.
+	sizes     map[uint64]bool       // Sizes of the handles found while scanning.
+	allow     bool                  // If true, commands that cannot be rebased are kept as they are.
+	skipped   int                   // Number of commands that could not be rebased.
+}
+
+// newRebaser returns a rebaser that moves the handles and memory of the
+// commands of second past those of first. If allowUnrebaseable is true, the
+// commands of second that cannot be rebased are kept as they are.
+func newRebaser(ctx context.Context, first, second *GraphicsCapture, allowUnrebaseable bool) (*rebaser, error) {
+	layout := first.Header.ABI.GetMemoryLayout()
+	var order binary.ByteOrder = binary.LittleEndian
+	if layout.GetEndian() == device.BigEndian {
+		order = binary.BigEndian
+	}
+
+	scans := [2]*rebaser{}
+	for i, c := range []*GraphicsCapture{first, second} {
+		scans[i] = &rebaser{layout: layout, order: order, scanning: true, sizes: map[uint64]bool{}}
+		for _, cmd := range c.Commands {
+			if _, err := scans[i].rebase(ctx, cmd, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for size := range scans[1].sizes {
+		scans[0].sizes[size] = true
+	}
+	handles, err := scans[0].maxObservedHandle(ctx, first, scans[1].maxHandle)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &rebaser{
+		layout:   layout,
+		order:    order,
+		handles:  handles,
+		observed: second.Observed,
+		allow:    allowUnrebaseable,
+	}
+	if len(first.Observed) > 0 && len(second.Observed) > 0 {
+		end := first.Observed[len(first.Observed)-1].Span().End
+		if start := second.Observed[0].First; start < end {
+			r.addresses = (end - start + rebaseAlignment - 1) &^ (rebaseAlignment - 1)
+		}
+		last := second.Observed[len(second.Observed)-1].Span().End
+		bits := 8 * uint(layout.GetPointer().GetSize())
+		if last+r.addresses < last || (bits < 64 && last+r.addresses > 1<<bits) {
+			return nil, log.Errf(ctx, nil, "The memory of the captures does not fit in the address space")
+		}
+	}
+	return r, nil
+}
+
+// rebase returns a copy of cmd, allocated with a, with its handles and
+// memory moved. When scanning, cmd is returned as it is.
+func (r *rebaser) rebase(ctx context.Context, cmd api.Cmd, a arena.Arena) (api.Cmd, error) {
+	if !r.scanning {
+		cmd = cmd.Clone(a)
+	}
+
+	var observations *api.CmdObservations
+	if o := cmd.Extras().Observations(); o != nil {
+		observations = &api.CmdObservations{
+			Reads:  append([]api.CmdObservation{}, o.Reads...),
+			Writes: append([]api.CmdObservation{}, o.Writes...),
+		}
+	}
+
+	params := cmd.CmdParams()
+	if res := cmd.CmdResult(); res != nil {
+		params = append(params, res)
+	}
+	for _, p := range params {
+		switch {
+		case p.Type.Implements(tyHandle):
+			r.addSize(uint64(p.Type.Size()))
+			v := reflect.ValueOf(p.Get())
+			if err := r.set(ctx, cmd, p, v, r.handle(toUint(v))); err != nil {
+				return nil, err
+			}
+		case p.Type.Implements(tyPointer):
+			ptr := p.Get().(memory.Pointer)
+			if ptr.IsNullptr() {
+				continue
+			}
+			if observations != nil {
+				for _, l := range [][]api.CmdObservation{observations.Reads, observations.Writes} {
+					if err := r.rebaseMemory(ctx, cmd, ptr, l); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if err := r.set(ctx, cmd, p, reflect.ValueOf(ptr), r.pointer(ptr.Address())); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.scanning || observations == nil {
+		return cmd, nil
+	}
+	for _, l := range [][]api.CmdObservation{observations.Reads, observations.Writes} {
+		for i := range l {
+			if l[i].Pool == memory.ApplicationPool {
+				l[i].Range.Base += r.addresses
+			}
+		}
+	}
+	// The extras are shared with the source command, so they are copied
+	// before the observations are replaced.
+	extras := cmd.Extras()
+	*extras = append(api.CmdExtras{}, extras.All()...)
+	extras.Replace(extras.Observations(), observations)
+	return cmd, nil
+}
+
+// rebaseMemory moves the handles and pointers held by the array that ptr
+// points to, in each of the observations that hold it.
+func (r *rebaser) rebaseMemory(ctx context.Context, cmd api.Cmd, ptr memory.Pointer, observations []api.CmdObservation) error {
+	var rebase func(uint64) uint64
+	switch el := ptr.ElementType(); {
+	case el.Implements(tyHandle):
+		r.addSize(ptr.ElementSize(r.layout))
+		rebase = r.handle
+	case el.Implements(tyPointer):
+		if !isPlain(reflect.Zero(el).Interface().(memory.Pointer).ElementType()) {
+			return r.unsupported(ctx, cmd)
+		}
+		rebase = r.pointer
+	case isPlain(el):
+		return nil
+	default:
+		return r.unsupported(ctx, cmd)
+	}
+
+	size := ptr.ElementSize(r.layout)
+	for i := range observations {
+		o := &observations[i]
+		if o.Pool != memory.ApplicationPool || !o.Range.Contains(ptr.Address()) {
+			continue
+		}
+		res, err := database.Resolve(ctx, o.ID)
+		if err != nil {
+			return err
+		}
+		data, ok := res.([]byte)
+		if !ok {
+			return log.Errf(ctx, nil, "Observation data %v is of unexpected type %T", o.ID, res)
+		}
+		data = append([]byte{}, data...)
+		changed := false
+		for at := ptr.Address() - o.Range.Base; at+size <= uint64(len(data)); at += size {
+			old, err := r.get(ctx, data[at:at+size])
+			if err != nil {
+				return err
+			}
+			if v := rebase(old); v != old {
+				if v < old || (size < 8 && v>>(8*size) != 0) {
+					return log.Errf(ctx, nil, "Rebased memory of command %v overflows", cmd.CmdName())
+				}
+				r.put(data[at:at+size], v)
+				changed = true
+			}
+		}
+		if !changed || r.scanning {
+			continue
+		}
+		if o.ID, err = database.Store(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSize records the size of a handle found while scanning.
+func (r *rebaser) addSize(size uint64) {
+	if r.scanning {
+		r.sizes[size] = true
+	}
+}
+
+// maxObservedHandle returns the largest handle that the commands of c may
+// use. This is the largest handle found while scanning c, or the largest value
+// of the size of a handle held by the memory observed by c, if larger. Values
+// that would make next, the largest handle of the rebased capture, overflow
+// when offset are ignored.
+func (r *rebaser) maxObservedHandle(ctx context.Context, c *GraphicsCapture, next uint64) (uint64, error) {
+	max := r.maxHandle
+	type key struct {
+		data  id.ID
+		align uint64
+	}
+	seen := map[key]bool{}
+	for _, cmd := range c.Commands {
+		o := cmd.Extras().Observations()
+		if o == nil {
+			continue
+		}
+		for _, l := range [][]api.CmdObservation{o.Reads, o.Writes} {
+			for _, o := range l {
+				k := key{o.ID, o.Range.Base % 8}
+				if o.Pool != memory.ApplicationPool || seen[k] {
+					continue
+				}
+				seen[k] = true
+				res, err := database.Resolve(ctx, o.ID)
+				if err != nil {
+					return 0, err
+				}
+				data, ok := res.([]byte)
+				if !ok {
+					return 0, log.Errf(ctx, nil, "Observation data %v is of unexpected type %T", o.ID, res)
+				}
+				for size := range r.sizes {
+					if size == 0 || size > 8 {
+						continue
+					}
+					limit := ^uint64(0) >> (64 - 8*size)
+					if next > limit {
+						continue
+					}
+					limit -= next
+					// Handles are aligned to their size in memory.
+					start := (size - o.Range.Base%size) % size
+					for at := start; at+size <= uint64(len(data)); at += size {
+						v, err := r.get(ctx, data[at:at+size])
+						if err != nil {
+							return 0, err
+						}
+						if v > max && v <= limit {
+							max = v
+						}
+					}
+				}
+			}
+		}
+	}
+	return max, nil
+}
+
+// handle returns the handle v rebased. Null handles are kept as they are.
+func (r *rebaser) handle(v uint64) uint64 {
+	if v == 0 {
+		return 0
+	}
+	if r.scanning {
+		if v > r.maxHandle {
+			r.maxHandle = v
+		}
+		return v
+	}
+	return v + r.handles
+}
+
+// pointer returns the address v rebased. Addresses outside of the memory
+// observed by the rebased capture are kept as they are.
+func (r *rebaser) pointer(v uint64) uint64 {
+	if r.scanning || !interval.Contains(r.observed, v) {
+		return v
+	}
+	return v + r.addresses
+}
+
+// set assigns v to the property p of cmd, which currently holds old.
+func (r *rebaser) set(ctx context.Context, cmd api.Cmd, p *api.Property, old reflect.Value, v uint64) error {
+	if r.scanning || v == toUint(old) {
+		return nil
+	}
+	if p.Set == nil {
+		return log.Errf(ctx, nil, "Parameter %v of command %v cannot be rebased", p.Name, cmd.CmdName())
+	}
+	out := reflect.New(old.Type()).Elem()
+	switch out.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if out.OverflowInt(int64(v)) {
+			return log.Errf(ctx, nil, "Rebased parameter %v of command %v overflows", p.Name, cmd.CmdName())
+		}
+		out.SetInt(int64(v))
+	default:
+		if out.OverflowUint(v) {
+			return log.Errf(ctx, nil, "Rebased parameter %v of command %v overflows", p.Name, cmd.CmdName())
+		}
+		out.SetUint(v)
+	}
+	p.Set(out.Interface())
+	return nil
+}
+
+// unsupported reports that cmd cannot be rebased, which is an error unless
+// the rebaser allows it. Commands are only scanned for their handles as far as
+// possible, so scanning never fails nor counts them.
+func (r *rebaser) unsupported(ctx context.Context, cmd api.Cmd) error {
+	if r.scanning {
+		return nil
+	}
+	if !r.allow {
+		return log.Errf(ctx, nil, "Command %v passes structures through memory and cannot be merged", cmd.CmdName())
+	}
+	r.skipped++
+	return nil
+}
+
+// get decodes the handle or pointer held by b.
+func (r *rebaser) get(ctx context.Context, b []byte) (uint64, error) {
+	switch len(b) {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(r.order.Uint16(b)), nil
+	case 4:
+		return uint64(r.order.Uint32(b)), nil
+	case 8:
+		return r.order.Uint64(b), nil
+	default:
+		return 0, log.Errf(ctx, nil, "Unsupported element size %v", len(b))
+	}
+}
+
+// put encodes the handle or pointer v into b.
+func (r *rebaser) put(b []byte, v uint64) {
+	switch len(b) {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		r.order.PutUint16(b, uint16(v))
+	case 4:
+		r.order.PutUint32(b, uint32(v))
+	case 8:
+		r.order.PutUint64(b, v)
+	}
+}
+
+// isPlain returns true if values of type t hold no handles nor pointers.
+func isPlain(t reflect.Type) bool {
+	switch {
+	case t.Implements(tyHandle), t.Implements(tyPointer):
+		return false
+	case t.Kind() == reflect.Array:
+		return isPlain(t.Elem())
+	default:
+		return t.Kind() != reflect.Struct
+	}
+}
+
+// toUint returns the integer value of v.
+func toUint(v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(v.Int())
+	default:
+		return v.Uint()
+	}
+}
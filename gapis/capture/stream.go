@@ -0,0 +1,152 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/data/pack"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/api"
+)
+
+// streamBufferSize is the number of decoded commands a CmdIterator holds ahead
+// of the calls to Next.
+const streamBufferSize = 256
+
+// CmdIterator iterates over the commands of a graphics capture, decoding them
+// from the capture data as they are requested. Unlike resolving the capture,
+// the commands are not collected into a list, nor are the observed memory
+// ranges merged.
+//
+// This does not bound the peak memory use of the iterator: the decoded
+// commands all allocate from a single arena that lives until the iterator is
+// closed, and the resource identifiers are kept as commands refer to them by
+// index.
+type CmdIterator struct {
+	cmds    chan streamedCmd
+	cancel  task.CancelFunc
+	decoder *decoder
+	arena   arena.Arena
+	err     error
+}
+
+// NewCmdIterator starts decoding the graphics capture data read from src,
+// returning a CmdIterator over its commands.
+// The iterator must be closed once it is no longer needed, after which the
+// commands it returned must no longer be used.
+func NewCmdIterator(ctx context.Context, src Source) (*CmdIterator, error) {
+	in, closeSrc, err := open(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	if !isGFXTraceFormat(in) {
+		closeSrc()
+		return nil, fmt.Errorf("Not a graphics capture")
+	}
+
+	a := arena.New()
+	ctx = arena.Put(ctx, a)
+	d := newDecoder(a)
+	d.builder.streaming = true
+	// The decoder implements the ID Remapper interface,
+	// which protoconv functions need to handle resources.
+	ctx = id.PutRemapper(ctx, d)
+	ctx, cancel := task.WithCancel(ctx)
+
+	it := &CmdIterator{
+		cmds:    make(chan streamedCmd, streamBufferSize),
+		cancel:  cancel,
+		decoder: d,
+		arena:   a,
+	}
+	d.stream = func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		select {
+		case it.cmds <- streamedCmd{id, cmd}:
+			return nil
+		case <-task.ShouldStop(ctx):
+			return task.StopReason(ctx)
+		}
+	}
+
+	crash.Go(func() {
+		defer close(it.cmds)
+		defer closeSrc()
+		it.err = it.read(ctx, in)
+	})
+	return it, nil
+}
+
+func (it *CmdIterator) read(ctx context.Context, in io.Reader) error {
+	d := it.decoder
+	if err := pack.Read(ctx, in, d, false); err != nil {
+		return readError(ctx, err)
+	}
+	d.flush(ctx)
+	if err := d.streamPending(ctx); err != nil {
+		return err
+	}
+	if d.header == nil {
+		return log.Err(ctx, nil, "Capture was missing header chunk")
+	}
+	return nil
+}
+
+// Next returns the next command of the capture and its identifier.
+// Once all the commands have been returned, Next returns io.EOF, or the error
+// that stopped the decoding of the capture.
+func (it *CmdIterator) Next() (api.CmdID, api.Cmd, error) {
+	c, ok := <-it.cmds
+	if !ok {
+		if it.err != nil {
+			return 0, nil, it.err
+		}
+		return 0, nil, io.EOF
+	}
+	return c.id, c.cmd, nil
+}
+
+// Header returns the header of the capture, or nil if it has not been decoded.
+// The header precedes the commands, so it is available once Next has returned
+// a command.
+func (it *CmdIterator) Header() *Header {
+	return it.decoder.header
+}
+
+// InitialState returns the initial state of the capture. The initial state
+// precedes the commands, so it is complete once Next has returned a command.
+func (it *CmdIterator) InitialState() *InitialState {
+	return it.decoder.builder.initialState
+}
+
+// Arena returns the arena holding the allocations of the decoded commands.
+func (it *CmdIterator) Arena() arena.Arena {
+	return it.arena
+}
+
+// Close stops the decoding of the capture, releases its data source and
+// disposes of the arena holding the decoded commands.
+func (it *CmdIterator) Close() {
+	it.cancel()
+	for range it.cmds {
+	}
+	it.arena.Dispose()
+}
@@ -39,6 +39,11 @@ type Client interface {
 
 	// Close closes the client connection.
 	Close() error
+
+	// Shutdown asks the server to stop accepting new requests, wait for the
+	// in-flight requests to finish, and then exit. If timeout is non-zero then
+	// the server will stop after timeout even if requests are still in flight.
+	Shutdown(ctx context.Context, timeout time.Duration) error
 }
 
 // Bind creates a new rpc client using conn for communication.
@@ -58,6 +63,13 @@ type client struct {
 
 func (c *client) Close() error { return c.close() }
 
+func (c *client) Shutdown(ctx context.Context, timeout time.Duration) error {
+	_, err := c.client.Shutdown(ctx, &service.ShutdownRequest{
+		TimeoutMs: uint32(timeout / time.Millisecond),
+	})
+	return err
+}
+
 func (c *client) Ping(ctx context.Context) error {
 	_, err := c.client.Ping(ctx, &service.PingRequest{})
 	return err
@@ -324,6 +336,19 @@ func (c *client) LoadCapture(ctx context.Context, path string) (*path.Capture, e
 	return res.GetCapture(), nil
 }
 
+func (c *client) GetCaptureInfo(ctx context.Context, path string) (*service.CaptureInfo, error) {
+	res, err := c.client.GetCaptureInfo(ctx, &service.GetCaptureInfoRequest{
+		Path: path,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetInfo(), nil
+}
+
 func (c *client) SaveCapture(ctx context.Context, capture *path.Capture, path string) error {
 	res, err := c.client.SaveCapture(ctx, &service.SaveCaptureRequest{
 		Capture: capture,
@@ -589,3 +614,38 @@ func (c *client) ValidateDevice(ctx context.Context, device *path.Device) error
 	}
 	return nil
 }
+
+func (c *client) ListTasks(ctx context.Context) ([]*service.ResolveTask, error) {
+	res, err := c.client.ListTasks(ctx, &service.ListTasksRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetTasks().List, nil
+}
+
+func (c *client) CancelTask(ctx context.Context, id *path.ID) (bool, error) {
+	res, err := c.client.CancelTask(ctx, &service.CancelTaskRequest{
+		Id: id,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := res.GetError(); err != nil {
+		return false, err.Get()
+	}
+	return res.GetCancelled(), nil
+}
+
+func (c *client) ClearCache(ctx context.Context) (uint64, error) {
+	res, err := c.client.ClearCache(ctx, &service.ClearCacheRequest{})
+	if err != nil {
+		return 0, err
+	}
+	if err := res.GetError(); err != nil {
+		return 0, err.Get()
+	}
+	return res.GetEvicted(), nil
+}
@@ -17,6 +17,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/gapid/core/app/status"
 	"github.com/google/gapid/core/context/keys"
@@ -38,6 +39,35 @@ type Database interface {
 	IsResolved(context.Context, id.ID) bool
 	// Contains returns true if the database has an entry for the specified id.
 	Contains(context.Context, id.ID) bool
+	// Tasks returns the resolves that have been started but not yet finished,
+	// oldest first.
+	Tasks(context.Context) []Task
+	// Cancel cancels the in-flight resolve of the specified id, returning
+	// false if the id is not being resolved.
+	Cancel(context.Context, id.ID) bool
+	// Stats returns the size of the database.
+	Stats(context.Context) Stats
+	// Evict drops the resolved objects of the records for which pred returns
+	// true, returning the number of objects dropped. The records are kept, so
+	// the objects are decoded and resolved again when next requested.
+	// Records without encoded data, plain old data values, and in-flight
+	// resolves, are not evicted.
+	Evict(ctx context.Context, pred func(id id.ID, ty string) bool) int
+}
+
+// Stats holds the size of a database.
+type Stats struct {
+	Entries int    // The number of records in the database.
+	Objects int    // The number of records holding a decoded or resolved object.
+	Bytes   uint64 // The approximate size of the records in bytes.
+}
+
+// Task describes a resolve that has been started but not yet finished.
+type Task struct {
+	ID      id.ID     // The identifier of the object being resolved.
+	Type    string    // The type of the object being resolved.
+	Waiting int       // The number of callers waiting on the resolve.
+	Started time.Time // The time the resolve was started.
 }
 
 // Store stores v to the database held by the context.
@@ -50,6 +80,23 @@ func Resolve(ctx context.Context, id id.ID) (interface{}, error) {
 	return Get(ctx).Resolve(ctx, id)
 }
 
+// Tasks returns the in-flight resolves of the database held by the context.
+func Tasks(ctx context.Context) []Task {
+	return Get(ctx).Tasks(ctx)
+}
+
+// Cancel cancels the in-flight resolve of id with the database held by the
+// context.
+func Cancel(ctx context.Context, id id.ID) bool {
+	return Get(ctx).Cancel(ctx, id)
+}
+
+// Clear drops all the resolved objects held by the database held by the
+// context that can be resolved again, returning the number of objects dropped.
+func Clear(ctx context.Context) int {
+	return Get(ctx).Evict(ctx, func(id.ID, string) bool { return true })
+}
+
 // Build stores resolvable into d, and then resolves and returns the resolved
 // object.
 func Build(ctx context.Context, r Resolvable) (interface{}, error) {
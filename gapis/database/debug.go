@@ -25,11 +25,13 @@ import (
 )
 
 // resolveChain is a value that is stored in the context of a Resolve().
-// It holds a pointer to the record that is currently being resolved, and a
-// pointer to the parent resolve (if any exists). This forms a chain of resolves
-// that can be walked for displaying resolve stack traces.
+// It holds a pointer to the record that is currently being resolved, its
+// resolve state, and a pointer to the parent resolve (if any exists). This
+// forms a chain of resolves that can be walked for displaying resolve stack
+// traces.
 type resolveChain struct {
 	record *record
+	state  *resolveState
 	parent *resolveChain
 }
 
@@ -93,13 +95,15 @@ func (d *memory) resolvePanicHandler(ctx context.Context) {
 			fmt.Fprintf(buf, " Store():\n")
 			fmt.Fprintln(buf, indent(r.created.String(), 2))
 			fmt.Fprintln(buf)
-			for i, c := range r.resolveState.callstacks {
+			// The state is taken from the chain, as the record drops its state
+			// when the resolve is cancelled.
+			for i, s := range c.state.callstacks {
 				if i >= 10 {
-					fmt.Fprintf(buf, " ... %d more Build() calls (truncated)\n", len(r.resolveState.callstacks)-i-1)
+					fmt.Fprintf(buf, " ... %d more Build() calls (truncated)\n", len(c.state.callstacks)-i-1)
 					break
 				}
 				fmt.Fprintf(buf, " Build() #%d:\n", i)
-				fmt.Fprintln(buf, indent(c.String(), 2))
+				fmt.Fprintln(buf, indent(s.String(), 2))
 			}
 		}
 
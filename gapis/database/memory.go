@@ -20,16 +20,26 @@ import (
 	"fmt"
 	"hash"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/app/benchmark"
 	"github.com/google/gapid/core/app/crash"
 	"github.com/google/gapid/core/app/status"
 	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/data/pod"
 	"github.com/google/gapid/core/data/protoconv"
 	"github.com/google/gapid/core/event/task"
 )
 
+var (
+	recordsCounter        = benchmark.Integer("database.records")
+	resolvesCounter       = benchmark.Integer("database.resolves")
+	activeResolvesCounter = benchmark.Integer("database.resolves.active")
+)
+
 // NewInMemory builds a new in memory database.
 func NewInMemory(ctx context.Context) Database {
 	m := &memory{}
@@ -66,6 +76,10 @@ const blob = recordType("<blob>")
 // a raw byte slice
 const blobFunc = recordType("<blobFunc>")
 
+// podValue is the record type for plain old data values, which decode to a
+// pod.Value rather than to the stored type.
+var podValue = recordType(proto.MessageName(&pod.Value{}))
+
 type record struct {
 	data         []byte      // data is the encoded object
 	ty           recordType  // ty is the type of the encoded object
@@ -80,6 +94,7 @@ type resolveState struct {
 	finished   chan struct{}   // Signal that resolve has finished. Set to nil when done.
 	waiting    uint32          // Number of go-routines waiting for the resolve
 	cancel     func()          // Cancels ctx
+	started    time.Time       // The time the resolve was started
 	callstacks []callstack
 }
 
@@ -88,8 +103,12 @@ func (r *record) decode(ctx context.Context) (interface{}, error) {
 	case blob:
 		return r.data, nil
 	default:
+		// MessageType returns the pointer type of the message.
 		ty := proto.MessageType(string(r.ty))
-		msg := reflect.New(ty).Interface().(proto.Message)
+		if ty == nil {
+			return nil, fmt.Errorf("Unknown message type '%v'", r.ty)
+		}
+		msg := reflect.New(ty.Elem()).Interface().(proto.Message)
 		if err := proto.Unmarshal(r.data, msg); err != nil {
 			return nil, err
 		}
@@ -97,30 +116,33 @@ func (r *record) decode(ctx context.Context) (interface{}, error) {
 	}
 }
 
-func (r *record) resolve(ctx context.Context) error {
+// resolve decodes and resolves obj, the current object of the record, and
+// returns the resolved object. The record itself is not modified, as resolve
+// is called without the database lock held.
+func (r *record) resolve(ctx context.Context, obj interface{}, rs *resolveState) (interface{}, error) {
 	// Decode the object if we don't have the object already.
-	if r.object == nil {
-		obj, err := r.decode(ctx)
+	if obj == nil {
+		decoded, err := r.decode(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		r.object = obj
+		obj = decoded
 	}
 
 	// Convert protos to Go objects if we can.
-	if msg, ok := r.object.(proto.Message); ok {
-		obj, err := protoconv.ToObject(ctx, msg)
+	if msg, ok := obj.(proto.Message); ok {
+		converted, err := protoconv.ToObject(ctx, msg)
 		switch err := err.(type) {
 		case nil:
-			r.object = obj
+			obj = converted
 		case protoconv.ErrNoConverterRegistered:
 			if err.Object != msg {
 				// We got a ErrNoConverterRegistered error, but it wasn't for
 				// the outermost object!
-				return err
+				return nil, err
 			}
 		default:
-			return err
+			return nil, err
 		}
 	}
 
@@ -128,17 +150,17 @@ func (r *record) resolve(ctx context.Context) error {
 	for {
 		// If the object implements resolvable, then we need to resolve it.
 		// Is the database value resolvable?
-		resolvable, isResolvable := r.object.(Resolvable)
+		resolvable, isResolvable := obj.(Resolvable)
 		if !isResolvable {
-			return nil
+			return obj, nil
 		}
-		ctx = status.Start(ctx, "DB Resolve<%T> %p", resolvable, r.resolveState)
+		ctx = status.Start(ctx, "DB Resolve<%T> %p", resolvable, rs)
 		defer status.Finish(ctx)
 		resolved, err := resolvable.Resolve(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		r.object = resolved
+		obj = resolved
 	}
 }
 
@@ -183,6 +205,7 @@ func (d *memory) Store(ctx context.Context, val interface{}) (id.ID, error) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 	if _, got := d.records[id]; !got {
+		recordsCounter.Increment()
 		if dontStoreData {
 			d.records[id] = &record{data: nil, ty: ty, object: val, created: getCallstack(4)}
 		} else {
@@ -215,36 +238,50 @@ func (d *memory) resolveLocked(ctx context.Context, id id.ID) (interface{}, erro
 	if build {
 		// First request for this resolvable.
 
-		// Grab the resolve chain from the caller's context.
-		rc := &resolveChain{r, getResolveChain(ctx)}
-
 		// Build a cancellable context for the resolve from database's resolve
 		// context. We use this as we don't to cancel the resolve if a single
 		// caller cancel's their context.
 		resolveCtx, cancel := task.WithCancel(d.resolveCtx)
 
 		rs = &resolveState{
-			ctx:      rc.bind(resolveCtx),
 			finished: make(chan struct{}),
 			cancel:   cancel,
+			started:  time.Now(),
 		}
+
+		// Grab the resolve chain from the caller's context.
+		rc := &resolveChain{r, rs, getResolveChain(ctx)}
+		rs.ctx = rc.bind(resolveCtx)
+
 		r.resolveState = rs
+		resolvesCounter.Increment()
+		activeResolvesCounter.Increment()
 
 		// Build the resolvable on a separate go-routine.
 		ctx := ctx // Don't let changes to ctx leak into this go-routine.
+		obj := r.object
 		crash.Go(func() {
 			// Propagate the status, so that resolve tasks appear under the
 			// context that first triggered the resolve.
 			ctx := status.PutTask(rs.ctx, status.GetTask(ctx))
 
 			defer d.resolvePanicHandler(ctx)
-			err := r.resolve(ctx)
+			defer activeResolvesCounter.Add(-1)
+			obj, err := r.resolve(ctx, obj, rs)
 
-			// Signal that the resolvable has finished.
 			d.mutex.Lock()
-			close(rs.finished)
-			rs.err, rs.finished = err, nil
-			d.mutex.Unlock()
+			defer d.mutex.Unlock()
+			// The resolve state is detached from the record when the resolve
+			// is cancelled, in which case another resolve may have started,
+			// and the result is dropped.
+			if r.resolveState == rs && err == nil {
+				r.object = obj
+			}
+			// Signal that the resolvable has finished, unless it was cancelled.
+			if rs.finished != nil {
+				close(rs.finished)
+				rs.err, rs.finished = err, nil
+			}
 		})
 	}
 
@@ -317,8 +354,86 @@ func (d *memory) IsResolved(ctx context.Context, id id.ID) (res bool) {
 		return false
 	}
 	rs := r.resolveState
-	if rs.finished == nil {
+	if rs != nil && rs.finished == nil {
 		return true
 	}
 	return false
 }
+
+// Implements Database
+func (d *memory) Tasks(ctx context.Context) []Task {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	out := []Task{}
+	for id, r := range d.records {
+		if rs := r.resolveState; rs != nil && rs.finished != nil {
+			out = append(out, Task{
+				ID:      id,
+				Type:    fmt.Sprintf("%T", r.object),
+				Waiting: int(rs.waiting),
+				Started: rs.started,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Started.Before(out[j].Started) })
+	return out
+}
+
+// Implements Database
+func (d *memory) Cancel(ctx context.Context, id id.ID) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	r, got := d.records[id]
+	if !got {
+		return false
+	}
+	rs := r.resolveState
+	if rs == nil || rs.finished == nil {
+		return false
+	}
+	// Release the waiting callers now, rather than when the resolvable notices
+	// the cancellation, and remove the resolve state so that the next request
+	// starts a new resolve.
+	rs.cancel()
+	close(rs.finished)
+	rs.err, rs.finished = context.Canceled, nil
+	r.resolveState = nil
+	return true
+}
+
+// Implements Database
+func (d *memory) Stats(ctx context.Context) Stats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	out := Stats{Entries: len(d.records)}
+	for _, r := range d.records {
+		// Only the encoded data is counted, the size of the decoded and
+		// resolved objects is not known.
+		out.Bytes += uint64(len(r.data))
+		if r.object != nil {
+			out.Objects++
+		}
+	}
+	return out
+}
+
+// Implements Database
+func (d *memory) Evict(ctx context.Context, pred func(id id.ID, ty string) bool) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	count := 0
+	for id, r := range d.records {
+		if r.data == nil || r.object == nil || r.ty == blob || r.ty == podValue {
+			continue // Cannot be decoded again, or nothing to drop.
+		}
+		if rs := r.resolveState; rs != nil && rs.finished != nil {
+			continue // Being resolved.
+		}
+		if !pred(id, string(r.ty)) {
+			continue
+		}
+		r.object, r.resolveState = nil, nil
+		count++
+	}
+	return count
+}
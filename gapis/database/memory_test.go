@@ -0,0 +1,242 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/database"
+)
+
+// blocker is a Resolvable whose resolve blocks until it is released, even
+// once the resolve is cancelled.
+type blocker string
+
+type blockerState struct {
+	started chan struct{} // Signalled each time a resolve starts.
+	release chan struct{} // Closed to release the resolves.
+}
+
+var blockers = struct {
+	sync.Mutex
+	states map[blocker]*blockerState
+}{states: map[blocker]*blockerState{}}
+
+// newBlocker returns the blocker with the given name, in its initial state.
+func newBlocker(name string) blocker {
+	blockers.Lock()
+	defer blockers.Unlock()
+	b := blocker(name)
+	blockers.states[b] = &blockerState{started: make(chan struct{}, 10), release: make(chan struct{})}
+	return b
+}
+
+func (b blocker) state() *blockerState {
+	blockers.Lock()
+	defer blockers.Unlock()
+	return blockers.states[b]
+}
+
+func (b blocker) Resolve(ctx context.Context) (interface{}, error) {
+	s := b.state()
+	s.started <- struct{}{}
+	<-s.release
+	return string(b) + " resolved", nil
+}
+
+// evictable is a Resolvable message, which can be decoded again once its
+// resolved object is evicted. If a blocker of the same name exists, the
+// resolve blocks on it.
+type evictable struct {
+	Name string
+}
+
+func init() {
+	proto.RegisterType((*evictable)(nil), "database_test.evictable")
+}
+
+func (m *evictable) Reset()                   { *m = evictable{} }
+func (m *evictable) String() string           { return m.Name }
+func (*evictable) ProtoMessage()              {}
+func (m *evictable) Marshal() ([]byte, error) { return []byte(m.Name), nil }
+func (m *evictable) Unmarshal(b []byte) error { m.Name = string(b); return nil }
+
+func (m *evictable) Resolve(ctx context.Context) (interface{}, error) {
+	if s := blocker(m.Name).state(); s != nil {
+		s.started <- struct{}{}
+		<-s.release
+	}
+	return m.Name + " resolved", nil
+}
+
+type result struct {
+	val interface{}
+	err error
+}
+
+// resolve starts resolving id, and returns the channel the result is sent to.
+func resolve(ctx context.Context, db database.Database, id id.ID) <-chan result {
+	out := make(chan result, 1)
+	go func() {
+		val, err := db.Resolve(ctx, id)
+		out <- result{val, err}
+	}()
+	return out
+}
+
+// wait returns the value received from c, failing the test after a timeout.
+func wait(t *testing.T, c <-chan result) result {
+	select {
+	case r := <-c:
+		return r
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the resolve")
+		return result{}
+	}
+}
+
+// waitStarted waits for a resolve of b to start, failing the test after a
+// timeout.
+func waitStarted(t *testing.T, b blocker) {
+	select {
+	case <-b.state().started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the resolve to start")
+	}
+}
+
+func TestTasks(t *testing.T) {
+	ctx := log.Testing(t)
+	db := database.NewInMemory(ctx)
+	b := newBlocker("tasks")
+	rid, err := db.Store(ctx, b)
+	assert.For(ctx, "Store").ThatError(err).Succeeded()
+	assert.For(ctx, "idle tasks").That(db.Tasks(ctx)).DeepEquals([]database.Task{})
+
+	res := resolve(ctx, db, rid)
+	waitStarted(t, b)
+	tasks := db.Tasks(ctx)
+	if assert.For(ctx, "tasks").That(len(tasks)).Equals(1) {
+		assert.For(ctx, "id").That(tasks[0].ID).Equals(rid)
+		assert.For(ctx, "type").That(tasks[0].Type).Equals("database_test.blocker")
+		assert.For(ctx, "waiting").That(tasks[0].Waiting).Equals(1)
+	}
+	assert.For(ctx, "resolved").That(db.IsResolved(ctx, rid)).Equals(false)
+
+	close(b.state().release)
+	r := wait(t, res)
+	assert.For(ctx, "err").ThatError(r.err).Succeeded()
+	assert.For(ctx, "val").That(r.val).Equals("tasks resolved")
+	assert.For(ctx, "finished tasks").That(db.Tasks(ctx)).DeepEquals([]database.Task{})
+	assert.For(ctx, "resolved").That(db.IsResolved(ctx, rid)).Equals(true)
+}
+
+func TestCancel(t *testing.T) {
+	ctx := log.Testing(t)
+	db := database.NewInMemory(ctx)
+	b := newBlocker("cancel")
+	rid, err := db.Store(ctx, b)
+	assert.For(ctx, "Store").ThatError(err).Succeeded()
+	assert.For(ctx, "cancel idle").That(db.Cancel(ctx, rid)).Equals(false)
+	assert.For(ctx, "cancel unknown").That(db.Cancel(ctx, id.Unique())).Equals(false)
+
+	// Cancelling releases the waiting callers, even though the resolve has
+	// not noticed the cancellation.
+	first := resolve(ctx, db, rid)
+	waitStarted(t, b)
+	assert.For(ctx, "cancel").That(db.Cancel(ctx, rid)).Equals(true)
+	r := wait(t, first)
+	assert.For(ctx, "cancelled").ThatError(r.err).Equals(context.Canceled)
+	assert.For(ctx, "cancelled tasks").That(db.Tasks(ctx)).DeepEquals([]database.Task{})
+	assert.For(ctx, "cancel again").That(db.Cancel(ctx, rid)).Equals(false)
+
+	// The next request starts a new resolve, which runs alongside the
+	// cancelled one.
+	second := resolve(ctx, db, rid)
+	waitStarted(t, b)
+	assert.For(ctx, "tasks").That(len(db.Tasks(ctx))).Equals(1)
+
+	close(b.state().release)
+	r = wait(t, second)
+	assert.For(ctx, "err").ThatError(r.err).Succeeded()
+	assert.For(ctx, "val").That(r.val).Equals("cancel resolved")
+	assert.For(ctx, "resolved").That(db.IsResolved(ctx, rid)).Equals(true)
+}
+
+func TestEvict(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		name     string
+		val      interface{}
+		resolve  bool
+		pred     bool
+		expected int
+	}{
+		{"resolved", &evictable{Name: "resolved"}, true, true, 1},
+		{"stored", &evictable{Name: "stored"}, false, true, 1},
+		{"rejected", &evictable{Name: "rejected"}, true, false, 0},
+		{"blob", []byte{1, 2, 3}, true, true, 0},
+		{"blob function", func() ([]byte, error) { return []byte{1, 2, 3}, nil }, true, true, 0},
+		{"plain old data", "text", true, true, 0},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		db := database.NewInMemory(ctx)
+		rid, err := db.Store(ctx, test.val)
+		assert.For(ctx, "Store").ThatError(err).Succeeded()
+		if test.resolve {
+			_, err := db.Resolve(ctx, rid)
+			assert.For(ctx, "Resolve").ThatError(err).Succeeded()
+		}
+		evicted := db.Evict(ctx, func(got id.ID, ty string) bool {
+			assert.For(ctx, "id").That(got).Equals(rid)
+			return test.pred
+		})
+		assert.For(ctx, "evicted").That(evicted).Equals(test.expected)
+		assert.For(ctx, "objects").That(db.Stats(ctx).Objects).Equals(1 - test.expected)
+		assert.For(ctx, "entries").That(db.Stats(ctx).Entries).Equals(1)
+
+		// Evicted objects are decoded and resolved again.
+		if m, ok := test.val.(*evictable); ok {
+			val, err := db.Resolve(ctx, rid)
+			assert.For(ctx, "Resolve").ThatError(err).Succeeded()
+			assert.For(ctx, "val").That(val).Equals(m.Name + " resolved")
+		}
+	}
+}
+
+func TestEvictInFlight(t *testing.T) {
+	ctx := log.Testing(t)
+	db := database.NewInMemory(ctx)
+	b := newBlocker("in flight")
+	rid, err := db.Store(ctx, &evictable{Name: string(b)})
+	assert.For(ctx, "Store").ThatError(err).Succeeded()
+
+	res := resolve(ctx, db, rid)
+	waitStarted(t, b)
+	all := func(id.ID, string) bool { return true }
+	assert.For(ctx, "in flight").That(db.Evict(ctx, all)).Equals(0)
+
+	close(b.state().release)
+	r := wait(t, res)
+	assert.For(ctx, "err").ThatError(r.err).Succeeded()
+	assert.For(ctx, "finished").That(db.Evict(ctx, all)).Equals(1)
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"context"
+)
+
+// diffChunkSize is the number of bytes of each Data read at a time by Diff.
+const diffChunkSize = 64 * 1024
+
+// Diff returns the ranges of bytes that differ between a and b, which are
+// expected to be two snapshots of the same memory region. The ranges are
+// relative to the start of the data, sorted, and adjacent differing bytes are
+// coalesced into a single range.
+// If a and b are of different sizes, then the bytes past the end of the
+// smaller one are all considered to differ, so the last range returned ends at
+// the end of the larger one.
+func Diff(ctx context.Context, a, b Data) (RangeList, error) {
+	common, size := a.Size(), b.Size()
+	if common > size {
+		common, size = size, common
+	}
+
+	out := RangeList{}
+	add := func(r Range) {
+		if n := len(out); n > 0 && out[n-1].End() == r.Base {
+			out[n-1].Size += r.Size
+		} else {
+			out = append(out, r)
+		}
+	}
+
+	bufA, bufB := make([]byte, diffChunkSize), make([]byte, diffChunkSize)
+	for offset := uint64(0); offset < common; offset += diffChunkSize {
+		n := common - offset
+		if n > diffChunkSize {
+			n = diffChunkSize
+		}
+		chunkA, chunkB := bufA[:n], bufB[:n]
+		if err := a.Get(ctx, offset, chunkA); err != nil {
+			return nil, err
+		}
+		if err := b.Get(ctx, offset, chunkB); err != nil {
+			return nil, err
+		}
+		if bytes.Equal(chunkA, chunkB) {
+			continue
+		}
+		for i := uint64(0); i < n; i++ {
+			if chunkA[i] == chunkB[i] {
+				continue
+			}
+			start := i
+			for i < n && chunkA[i] != chunkB[i] {
+				i++
+			}
+			add(Range{Base: offset + start, Size: i - start})
+		}
+	}
+	if size > common {
+		add(Range{Base: common, Size: size - common})
+	}
+	return out, nil
+}
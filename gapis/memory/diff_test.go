@@ -0,0 +1,57 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+)
+
+func TestDiff(t *testing.T) {
+	ctx := log.Testing(t)
+	large := make([]byte, diffChunkSize*2+10)
+	changed := append([]byte{}, large...)
+	changed[diffChunkSize-2] = 1
+	changed[diffChunkSize-1] = 1
+	changed[diffChunkSize] = 1 // Coalesced across chunks.
+	changed[diffChunkSize*2+9] = 1
+
+	for _, test := range []struct {
+		name     string
+		a, b     []byte
+		expected RangeList
+	}{
+		{"empty", []byte{}, []byte{}, RangeList{}},
+		{"equal", []byte{1, 2, 3}, []byte{1, 2, 3}, RangeList{}},
+		{"single", []byte{1, 2, 3}, []byte{1, 0, 3}, RangeList{{Base: 1, Size: 1}}},
+		{"coalesced", []byte{1, 2, 3, 4, 5}, []byte{0, 0, 3, 0, 0}, RangeList{
+			{Base: 0, Size: 2},
+			{Base: 3, Size: 2},
+		}},
+		{"a longer", []byte{1, 2, 3, 4}, []byte{1, 2}, RangeList{{Base: 2, Size: 2}}},
+		{"b longer", []byte{1, 2}, []byte{1, 0, 3, 4}, RangeList{{Base: 1, Size: 3}}},
+		{"chunks", large, changed, RangeList{
+			{Base: diffChunkSize - 2, Size: 3},
+			{Base: diffChunkSize*2 + 9, Size: 1},
+		}},
+	} {
+		got, err := Diff(ctx, Blob(test.a), Blob(test.b))
+		if assert.For(ctx, "%s err", test.name).ThatError(err).Succeeded() {
+			assert.For(ctx, test.name).That(got).DeepEquals(test.expected)
+		}
+	}
+}
@@ -71,7 +71,7 @@ func SetupProfileLayersSource(ctx context.Context, d adb.Device, packageName str
 		packages = append(packages, gapidapk.PackageName(abi))
 	}
 
-	cleanup, err := android.SetupLayers(ctx, d, packageName, packages, []string{}, true)
+	cleanup, err := d.SetGPUDebugLayers(ctx, packageName, packages, nil, true)
 	if err != nil {
 		return cleanup.Invoke(ctx), log.Err(ctx, err, "Failed to setup gpu.renderstages layer packages.")
 	}
@@ -94,7 +94,7 @@ func setupProfileLayers(ctx context.Context, d adb.Device, driver adb.Driver, pa
 		enabledLayers = append(enabledLayers, renderStageVulkanLayerName)
 	}
 
-	cleanup, err := android.SetupLayers(ctx, d, packageName, packages, enabledLayers, true)
+	cleanup, err := d.SetGPUDebugLayers(ctx, packageName, packages, enabledLayers, true)
 	if err != nil {
 		return cleanup.Invoke(ctx), log.Err(ctx, err, "Failed to setup gpu.renderstages environment.")
 	}
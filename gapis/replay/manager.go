@@ -19,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/gapid/core/app/benchmark"
 	"github.com/google/gapid/core/app/status"
 	"github.com/google/gapid/core/data/id"
 	"github.com/google/gapid/core/log"
@@ -38,6 +39,11 @@ const (
 	defaultBatchDelay    = time.Millisecond * 100
 )
 
+var (
+	activeReplaysCounter = benchmark.Integer("replay.requests.active")
+	schedulersCounter    = benchmark.Integer("replay.schedulers")
+)
+
 // Manager executes replay requests.
 type Manager interface {
 	// Replay requests that req is to be performed on the device described by
@@ -103,6 +109,9 @@ func (m *manager) Replay(
 	status.Block(ctx)
 	defer status.Unblock(ctx)
 
+	activeReplaysCounter.Increment()
+	defer activeReplaysCounter.Add(-1)
+
 	log.D(ctx, "Replay request")
 	s, err := m.scheduler(ctx, intent.Device.ID.ID())
 	if err != nil {
@@ -153,6 +162,7 @@ func (m *manager) createScheduler(ctx context.Context, device bind.Device) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	m.schedulers[deviceID] = scheduler.New(ctx, deviceID, m.batch)
+	schedulersCounter.Set(int64(len(m.schedulers)))
 }
 
 func (m *manager) destroyScheduler(ctx context.Context, device bind.Device) {
@@ -161,6 +171,7 @@ func (m *manager) destroyScheduler(ctx context.Context, device bind.Device) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	delete(m.schedulers, deviceID)
+	schedulersCounter.Set(int64(len(m.schedulers)))
 }
 
 func (m *manager) connect(ctx context.Context, device bind.Device, replayABI *device.ABI) (*gapir.ConnectionKey, error) {
@@ -16,12 +16,16 @@ package initialcmds
 
 import (
 	"context"
+	"runtime/debug"
 
 	"github.com/google/gapid/core/app/benchmark"
+	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/math/interval"
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
 )
 
@@ -46,13 +50,28 @@ func InitialCommands(ctx context.Context, c *path.Capture) ([]api.Cmd, interval.
 }
 
 // Resolve returns the resolved initialCommandData.
-func (r *InitialCmdsResolvable) Resolve(ctx context.Context) (interface{}, error) {
+// A panic while rebuilding the state, such as one caused by a malformed state
+// block, is returned as an ErrDataUnavailable error.
+func (r *InitialCmdsResolvable) Resolve(ctx context.Context) (out interface{}, err error) {
 	c, err := capture.ResolveGraphicsFromPath(ctx, r.Capture)
 
 	if err != nil {
 		return nil, err
 	}
 
+	defer func() {
+		if p := recover(); p != nil {
+			log.E(ctx, "Panic rebuilding the initial state of capture %v: %v\n%s", r.Capture.ID, p, debug.Stack())
+			device := c.Header.GetDevice().GetName()
+			if device == "" {
+				device = c.Header.GetDevice().GetSerial()
+			}
+			out, err = nil, &service.ErrDataUnavailable{
+				Reason: messages.ErrInitialStateUnavailable(r.Capture.ID.ID().String(), device),
+			}
+		}
+	}()
+
 	ranges := interval.U64RangeList{}
 	cmds := []api.Cmd{}
 
@@ -0,0 +1,70 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package initialcmds_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/math/interval"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/resolve/initialcmds"
+	"github.com/google/gapid/gapis/service"
+)
+
+// malformedAPI is the test API, rebuilding the state without checking that
+// the state block holds the API's state.
+type malformedAPI struct{ test.API }
+
+func (a malformedAPI) RebuildState(ctx context.Context, s *api.GlobalState) ([]api.Cmd, interval.U64RangeList) {
+	s.APIs[a.ID()].SetupInitialState(ctx)
+	return nil, nil
+}
+
+func TestInitialCommandsMalformedState(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{
+		Device: &device.Instance{Name: "test-device"},
+		ABI:    device.WindowsX86_64,
+	}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, []api.Cmd{test.Cmds.A})
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+	// The state block is missing the state of the capture's API.
+	c.APIs = []api.API{malformedAPI{}}
+	p, err := c.Path(ctx)
+	if !assert.For(ctx, "capture.Path").ThatError(err).Succeeded() {
+		return
+	}
+
+	_, _, err = initialcmds.InitialCommands(ctx, p)
+	if !assert.For(ctx, "InitialCommands").ThatError(err).Failed() {
+		return
+	}
+	unavailable, ok := err.(*service.ErrDataUnavailable)
+	if !assert.For(ctx, "ErrDataUnavailable").That(ok).Equals(true) {
+		return
+	}
+	assert.For(ctx, "reason").That(unavailable.Reason.Arguments["device"].Unpack()).Equals("test-device")
+}
@@ -26,10 +26,12 @@ import (
 
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/app/benchmark"
 	"github.com/google/gapid/core/app/crash"
 	"github.com/google/gapid/core/app/status"
 	"github.com/google/gapid/core/context/keys"
 	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/fault"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/log/log_pb"
 	"github.com/google/gapid/core/net/grpcutil"
@@ -53,6 +55,11 @@ func Listen(ctx context.Context, addr string, cfg Config) error {
 // NewWithListener starts a new GRPC server listening on l.
 // This is a blocking call.
 func NewWithListener(ctx context.Context, l net.Listener, cfg Config, srvChan chan<- *grpc.Server) error {
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		return err
+	}
+
 	s := &grpcServer{
 		handler:      New(ctx, cfg),
 		bindCtx:      func(c context.Context) context.Context { return keys.Clone(c, ctx) },
@@ -60,6 +67,14 @@ func NewWithListener(ctx context.Context, l net.Listener, cfg Config, srvChan ch
 		interrupters: map[int]func(){},
 	}
 
+	options := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.unaryInterceptor(auth.ServerInterceptor(cfg.AuthToken))),
+		grpc.StreamInterceptor(s.streamInterceptor),
+	}
+	if creds != nil {
+		options = append(options, grpc.Creds(creds))
+	}
+
 	done := make(chan error)
 	ctx, stop := task.WithCancel(ctx)
 	crash.Go(func() {
@@ -69,6 +84,7 @@ func NewWithListener(ctx context.Context, l net.Listener, cfg Config, srvChan ch
 				fmt.Printf("Bound on port '%d'\n", addr.Port)
 			}
 			service.RegisterGapidServer(server, s)
+			s.server, s.stop = server, stop
 			if srvChan != nil {
 				srvChan <- server
 			}
@@ -78,7 +94,7 @@ func NewWithListener(ctx context.Context, l net.Listener, cfg Config, srvChan ch
 				crash.Go(func() { s.stopOnInterrupt(ctx, server, stop) })
 			}
 			return nil
-		}, grpc.UnaryInterceptor(auth.ServerInterceptor(cfg.AuthToken)))
+		}, options...)
 	})
 
 	select {
@@ -92,6 +108,12 @@ func NewWithListener(ctx context.Context, l net.Listener, cfg Config, srvChan ch
 	}
 }
 
+// activeRPCsCounter is the number of RPCs currently in flight.
+var activeRPCsCounter = benchmark.Integer("server.rpcs.active")
+
+// errShuttingDown is the error returned for RPCs made after a Shutdown RPC.
+const errShuttingDown = fault.Const("Server is shutting down")
+
 type grpcServer struct {
 	handler         Server
 	bindCtx         func(context.Context) context.Context
@@ -99,12 +121,35 @@ type grpcServer struct {
 	inFlightRPCs    int64
 	interrupters    map[int]func()
 	lastInterrupter int
+	shuttingDown    int32
+	server          *grpc.Server
+	stop            func()
+}
+
+// unaryInterceptor returns a grpc.UnaryServerInterceptor that rejects RPCs
+// once the server is shutting down, and passes all others to next.
+func (s *grpcServer) unaryInterceptor(next grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if atomic.LoadInt32(&s.shuttingDown) != 0 {
+			return nil, errShuttingDown
+		}
+		return next(ctx, req, info, handler)
+	}
+}
+
+// streamInterceptor rejects streaming RPCs once the server is shutting down.
+func (s *grpcServer) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if atomic.LoadInt32(&s.shuttingDown) != 0 {
+		return errShuttingDown
+	}
+	return handler(srv, ss)
 }
 
 // inRPC should be called at the start of an RPC call. The returned function
 // should be called when the RPC call finishes.
 func (s *grpcServer) inRPC() func() {
 	atomic.AddInt64(&s.inFlightRPCs, 1)
+	activeRPCsCounter.Increment()
 	select {
 	case s.keepAlive <- struct{}{}:
 	default:
@@ -118,6 +163,7 @@ func (s *grpcServer) inRPC() func() {
 			panic("Should never happen: inFlightRPCs counter is going below zero")
 		}
 		atomic.AddInt64(&s.inFlightRPCs, -1)
+		activeRPCsCounter.Add(-1)
 	}
 }
 
@@ -185,6 +231,46 @@ func (s *grpcServer) addInterrupter(f func()) (remove func()) {
 	return func() { delete(s.interrupters, li) }
 }
 
+// Shutdown stops the server from accepting new RPCs, waits for the in-flight
+// RPCs to finish or for the requested timeout to elapse, and then stops the
+// server.
+func (s *grpcServer) Shutdown(ctx xctx.Context, req *service.ShutdownRequest) (*service.ShutdownResponse, error) {
+	if !atomic.CompareAndSwapInt32(&s.shuttingDown, 0, 1) {
+		return &service.ShutdownResponse{}, nil // Already shutting down.
+	}
+	timeout := time.Duration(req.TimeoutMs) * time.Millisecond
+	log.I(ctx, "Shutdown requested with %v RPCs in flight", atomic.LoadInt64(&s.inFlightRPCs))
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		deadline = time.After(timeout)
+	}
+	drained := true
+wait:
+	for atomic.LoadInt64(&s.inFlightRPCs) != 0 {
+		select {
+		case <-deadline:
+			log.W(ctx, "Shutdown timed out after %v with %v RPCs in flight", timeout, atomic.LoadInt64(&s.inFlightRPCs))
+			drained = false
+			break wait
+		case <-ctx.Done():
+			drained = false
+			break wait
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	crash.Go(func() {
+		// Stopping the server gracefully waits for this RPC to respond.
+		s.stop()
+		if !drained {
+			time.Sleep(time.Second) // Give the response a chance to reach the client.
+			s.server.Stop()
+		}
+	})
+	return &service.ShutdownResponse{}, nil
+}
+
 func (s *grpcServer) Ping(ctx xctx.Context, req *service.PingRequest) (*service.PingResponse, error) {
 	defer s.inRPC()()
 	err := s.handler.Ping(s.bindCtx(ctx))
@@ -469,6 +555,15 @@ func (s *grpcServer) LoadCapture(ctx xctx.Context, req *service.LoadCaptureReque
 	return &service.LoadCaptureResponse{Res: &service.LoadCaptureResponse_Capture{Capture: capture}}, nil
 }
 
+func (s *grpcServer) GetCaptureInfo(ctx xctx.Context, req *service.GetCaptureInfoRequest) (*service.GetCaptureInfoResponse, error) {
+	defer s.inRPC()()
+	info, err := s.handler.GetCaptureInfo(s.bindCtx(ctx), req.Path)
+	if err := service.NewError(err); err != nil {
+		return &service.GetCaptureInfoResponse{Res: &service.GetCaptureInfoResponse_Error{Error: err}}, nil
+	}
+	return &service.GetCaptureInfoResponse{Res: &service.GetCaptureInfoResponse_Info{Info: info}}, nil
+}
+
 func (s *grpcServer) SaveCapture(ctx xctx.Context, req *service.SaveCaptureRequest) (*service.SaveCaptureResponse, error) {
 	defer s.inRPC()()
 	err := s.handler.SaveCapture(s.bindCtx(ctx), req.Capture, req.Path)
@@ -679,3 +774,34 @@ func (s *grpcServer) ValidateDevice(ctx xctx.Context, req *service.ValidateDevic
 	}
 	return &service.ValidateDeviceResponse{}, nil
 }
+
+func (s *grpcServer) ListTasks(ctx xctx.Context, req *service.ListTasksRequest) (*service.ListTasksResponse, error) {
+	defer s.inRPC()()
+	tasks, err := s.handler.ListTasks(s.bindCtx(ctx))
+	if err := service.NewError(err); err != nil {
+		return &service.ListTasksResponse{Res: &service.ListTasksResponse_Error{Error: err}}, nil
+	}
+	return &service.ListTasksResponse{
+		Res: &service.ListTasksResponse_Tasks{
+			Tasks: &service.ResolveTasks{List: tasks},
+		},
+	}, nil
+}
+
+func (s *grpcServer) CancelTask(ctx xctx.Context, req *service.CancelTaskRequest) (*service.CancelTaskResponse, error) {
+	defer s.inRPC()()
+	cancelled, err := s.handler.CancelTask(s.bindCtx(ctx), req.Id)
+	if err := service.NewError(err); err != nil {
+		return &service.CancelTaskResponse{Res: &service.CancelTaskResponse_Error{Error: err}}, nil
+	}
+	return &service.CancelTaskResponse{Res: &service.CancelTaskResponse_Cancelled{Cancelled: cancelled}}, nil
+}
+
+func (s *grpcServer) ClearCache(ctx xctx.Context, req *service.ClearCacheRequest) (*service.ClearCacheResponse, error) {
+	defer s.inRPC()()
+	evicted, err := s.handler.ClearCache(s.bindCtx(ctx))
+	if err := service.NewError(err); err != nil {
+		return &service.ClearCacheResponse{Res: &service.ClearCacheResponse_Error{Error: err}}, nil
+	}
+	return &service.ClearCacheResponse{Res: &service.ClearCacheResponse_Evicted{Evicted: evicted}}, nil
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/config"
+	"github.com/google/gapid/gapis/database"
 	"github.com/google/gapid/gapis/messages"
 	perfetto "github.com/google/gapid/gapis/perfetto/service"
 	"github.com/google/gapid/gapis/replay"
@@ -76,6 +77,15 @@ type Config struct {
 	DeviceScanDone   task.Signal
 	LogBroadcaster   *log.Broadcaster
 	IdleTimeout      time.Duration
+	// TLSCertFile and TLSKeyFile are the paths to the PEM encoded certificate
+	// and private key used to serve the RPCs over TLS. If empty, the RPCs are
+	// served without TLS.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile is the path to the PEM encoded certificate authorities
+	// used to verify client certificates. If set, clients must present a
+	// certificate signed by one of these authorities.
+	TLSClientCAFile string
 }
 
 // Server is the server interface to GAPIS.
@@ -301,6 +311,20 @@ func (s *server) LoadCapture(ctx context.Context, path string) (*path.Capture, e
 	return p, nil
 }
 
+func (s *server) GetCaptureInfo(ctx context.Context, path string) (*service.CaptureInfo, error) {
+	ctx = status.Start(ctx, "RPC GetCaptureInfo")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetCaptureInfo")
+	if !s.enableLocalFiles {
+		return nil, fmt.Errorf("Server not configured to allow reading of local files")
+	}
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return capture.ReadInfo(ctx, fileInfo.Name(), &capture.File{Path: path})
+}
+
 func (s *server) SaveCapture(ctx context.Context, c *path.Capture, path string) error {
 	ctx = status.Start(ctx, "RPC SaveCapture")
 	defer status.Finish(ctx)
@@ -948,3 +972,44 @@ func (s *server) ValidateDevice(ctx context.Context, d *path.Device) error {
 	ctx = log.Enter(ctx, "ValidateDevice")
 	return trace.Validate(ctx, d)
 }
+
+func (s *server) ListTasks(ctx context.Context) ([]*service.ResolveTask, error) {
+	ctx = status.Start(ctx, "RPC ListTasks")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "ListTasks")
+	tasks := database.Tasks(ctx)
+	out := make([]*service.ResolveTask, len(tasks))
+	for i, t := range tasks {
+		out[i] = &service.ResolveTask{
+			Id:        path.NewID(t.ID),
+			Type:      t.Type,
+			Waiting:   uint32(t.Waiting),
+			ElapsedMs: uint64(time.Since(t.Started) / time.Millisecond),
+		}
+	}
+	return out, nil
+}
+
+func (s *server) CancelTask(ctx context.Context, p *path.ID) (bool, error) {
+	ctx = status.Start(ctx, "RPC CancelTask")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "CancelTask")
+	if !p.IsValid() {
+		return false, &service.ErrInvalidArgument{Reason: messages.ErrMessage("Invalid task identifier")}
+	}
+	cancelled := database.Cancel(ctx, p.ID())
+	if cancelled {
+		log.I(ctx, "Cancelled resolve %v", p.ID())
+	}
+	return cancelled, nil
+}
+
+func (s *server) ClearCache(ctx context.Context) (uint64, error) {
+	ctx = status.Start(ctx, "RPC ClearCache")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "ClearCache")
+	before := database.Get(ctx).Stats(ctx)
+	evicted := database.Clear(ctx)
+	log.I(ctx, "Dropped %d of %d cached objects", evicted, before.Objects)
+	return uint64(evicted), nil
+}
@@ -79,6 +79,10 @@ type Service interface {
 	// capture identifier.
 	LoadCapture(ctx context.Context, path string) (*path.Capture, error)
 
+	// GetCaptureInfo returns a summary of the local capture file, without
+	// loading the capture.
+	GetCaptureInfo(ctx context.Context, path string) (*CaptureInfo, error)
+
 	// SaveCapture saves the capture to a local file.
 	SaveCapture(ctx context.Context, c *path.Capture, path string) error
 
@@ -193,6 +197,18 @@ type Service interface {
 	// ValidateDevice validates the GPU profiling capabilities of the given device and returns
 	// an error if validation failed or the GPU profiling data is invalid.
 	ValidateDevice(ctx context.Context, d *path.Device) error
+
+	// ListTasks returns the database resolves that have been started but not
+	// yet finished.
+	ListTasks(ctx context.Context) ([]*ResolveTask, error)
+
+	// CancelTask cancels the in-flight database resolve with the given
+	// identifier. It returns false if the resolve had already finished.
+	CancelTask(ctx context.Context, id *path.ID) (bool, error)
+
+	// ClearCache drops the resolved objects cached by the server's database,
+	// returning the number of objects dropped.
+	ClearCache(ctx context.Context) (uint64, error)
 }
 
 type TraceHandler interface {
@@ -131,6 +131,32 @@ void set_error_msg(code_with_debug_info_t* x, std::string msg) {
   strcpy(x->message, msg.c_str());
 }
 
+// shaderLanguage sets lang to the glslang language of the shader type, and
+// returns false if the shader type is not supported.
+bool shaderLanguage(shader_type shader_ty, EShLanguage* lang) {
+  switch (shader_ty) {
+    case VERTEX:
+      *lang = EShLangVertex;
+      return true;
+    case TESS_CONTROL:
+      *lang = EShLangTessControl;
+      return true;
+    case TESS_EVALUATION:
+      *lang = EShLangTessEvaluation;
+      return true;
+    case GEOMETRY:
+      *lang = EShLangGeometry;
+      return true;
+    case FRAGMENT:
+      *lang = EShLangFragment;
+      return true;
+    case COMPUTE:
+      *lang = EShLangCompute;
+      return true;
+  }
+  return false;
+}
+
 std::vector<unsigned int> parseGlslang(const char* code, const char* preamble,
                                        std::string* err_msg,
                                        shader_type shader_ty,
@@ -140,31 +166,9 @@ std::vector<unsigned int> parseGlslang(const char* code, const char* preamble,
 
   EShMessages messages = relaxed_errs ? EShMsgRelaxedErrors : EShMsgDefault;
   EShLanguage lang = EShLangVertex;
-  switch (shader_ty) {
-    case VERTEX: {
-      lang = EShLangVertex;
-      break;
-    }
-    case TESS_CONTROL: {
-      lang = EShLangTessControl;
-      break;
-    }
-    case TESS_EVALUATION: {
-      lang = EShLangTessEvaluation;
-      break;
-    }
-    case GEOMETRY: {
-      lang = EShLangGeometry;
-      break;
-    }
-    case FRAGMENT: {
-      lang = EShLangFragment;
-      break;
-    }
-    case COMPUTE: {
-      lang = EShLangCompute;
-      break;
-    }
+  if (!shaderLanguage(shader_ty, &lang)) {
+    *err_msg = "Unsupported shader type";
+    return spirv;
   }
 
   glslang::EShClient env_client = glslang::EShClientNone;
@@ -431,6 +435,11 @@ void deleteBinary(spirv_binary_t* binary) {
   delete binary;
 }
 
+bool isSupportedShaderType(shader_type shader_ty) {
+  EShLanguage lang;
+  return shaderLanguage(shader_ty, &lang);
+}
+
 const char* opcodeToString(uint32_t opcode) {
   return spvOpcodeString(static_cast<SpvOp>(opcode));
 }
@@ -111,6 +111,8 @@ void deleteBinary(spirv_binary_t*);
 
 const char* opcodeToString(uint32_t);
 
+bool isSupportedShaderType(shader_type);
+
 glsl_compile_result_t* compileGlsl(const char* code, const compile_options_t*);
 
 void deleteCompileResult(glsl_compile_result_t*);
@@ -0,0 +1,277 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shadertools
+
+//#include <third_party/SPIRV-Reflect/spirv_reflect.h>
+import "C"
+
+import (
+	"fmt"
+	"sort"
+	"unsafe"
+)
+
+// Variable is a named and typed variable of a shader.
+type Variable struct {
+	// The name of the variable.
+	Name string
+	// The GLSL name of the variable's type, like vec4 or sampler2D.
+	Type string
+}
+
+// Uniform is a uniform or storage buffer block used by a shader.
+type Uniform struct {
+	Variable
+	// The descriptor set of the block.
+	Set uint32
+	// The binding of the block in the descriptor set.
+	Binding uint32
+	// The members of the block.
+	Members []Variable
+}
+
+// Sampler is a sampler, texture or image used by a shader.
+type Sampler struct {
+	Variable
+	// The descriptor set of the sampler.
+	Set uint32
+	// The binding of the sampler in the descriptor set.
+	Binding uint32
+	// The number of descriptors, greater than one for arrays of samplers.
+	Count uint32
+}
+
+// Input is an input variable of a shader, like a vertex attribute.
+// Built-in inputs are not reported.
+type Input struct {
+	Variable
+	// The location of the input.
+	Location uint32
+}
+
+// Reflection describes the resources used by a shader.
+type Reflection struct {
+	// The uniform blocks, sorted by set and binding.
+	Uniforms []Uniform
+	// The samplers, sorted by set and binding.
+	Samplers []Sampler
+	// The inputs, sorted by location.
+	Inputs []Input
+}
+
+// Reflect compiles the GLSL source code with the given options, and returns
+// the resources used by the compiled module.
+func Reflect(source string, o CompileOptions) (Reflection, error) {
+	shader, err := CompileGlsl(source, o)
+	if err != nil {
+		return Reflection{}, err
+	}
+	return ReflectSpirv(shader)
+}
+
+// ReflectSpirv returns the resources used by the SPIR-V module shader.
+func ReflectSpirv(shader []uint32) (Reflection, error) {
+	module := C.SpvReflectShaderModule{}
+	shaderPtr := unsafe.Pointer(nil)
+	if len(shader) > 0 {
+		shaderPtr = unsafe.Pointer(&shader[0])
+	}
+	if err := spvReflectError(C.spvReflectCreateShaderModule(
+		C.size_t(len(shader)*4),
+		shaderPtr,
+		&module)); err != nil {
+		return Reflection{}, err
+	}
+	defer C.spvReflectDestroyShaderModule(&module)
+
+	out := Reflection{}
+
+	bindingCount := C.uint32_t(0)
+	if err := spvReflectError(C.spvReflectEnumerateDescriptorBindings(
+		&module, &bindingCount, nil)); err != nil {
+		return Reflection{}, err
+	}
+	bindings := make([]*C.SpvReflectDescriptorBinding, bindingCount)
+	if bindingCount > 0 {
+		if err := spvReflectError(C.spvReflectEnumerateDescriptorBindings(
+			&module, &bindingCount, &bindings[0])); err != nil {
+			return Reflection{}, err
+		}
+	}
+	for _, b := range bindings {
+		v := Variable{Name: C.GoString(b.name)}
+		switch b.descriptor_type {
+		case C.SPV_REFLECT_DESCRIPTOR_TYPE_UNIFORM_BUFFER,
+			C.SPV_REFLECT_DESCRIPTOR_TYPE_UNIFORM_BUFFER_DYNAMIC,
+			C.SPV_REFLECT_DESCRIPTOR_TYPE_STORAGE_BUFFER,
+			C.SPV_REFLECT_DESCRIPTOR_TYPE_STORAGE_BUFFER_DYNAMIC:
+			v.Type = typeName(b.type_description)
+			u := Uniform{Variable: v, Set: uint32(b.set), Binding: uint32(b.binding)}
+			for i := C.uint32_t(0); i < b.block.member_count; i++ {
+				memberPtr := uintptr(unsafe.Pointer(b.block.members)) +
+					uintptr(i)*unsafe.Sizeof(*b.block.members)
+				m := (*C.SpvReflectBlockVariable)(unsafe.Pointer(memberPtr))
+				u.Members = append(u.Members, Variable{
+					Name: C.GoString(m.name),
+					Type: typeName(m.type_description),
+				})
+			}
+			out.Uniforms = append(out.Uniforms, u)
+		case C.SPV_REFLECT_DESCRIPTOR_TYPE_SAMPLER,
+			C.SPV_REFLECT_DESCRIPTOR_TYPE_COMBINED_IMAGE_SAMPLER,
+			C.SPV_REFLECT_DESCRIPTOR_TYPE_SAMPLED_IMAGE,
+			C.SPV_REFLECT_DESCRIPTOR_TYPE_STORAGE_IMAGE:
+			v.Type = samplerTypeName(b)
+			count := C.uint32_t(1)
+			for j := C.uint32_t(0); j < b.array.dims_count; j++ {
+				count *= b.array.dims[j]
+			}
+			out.Samplers = append(out.Samplers, Sampler{
+				Variable: v,
+				Set:      uint32(b.set),
+				Binding:  uint32(b.binding),
+				Count:    uint32(count),
+			})
+		}
+	}
+	sort.Slice(out.Uniforms, func(i, j int) bool {
+		a, b := out.Uniforms[i], out.Uniforms[j]
+		return a.Set < b.Set || (a.Set == b.Set && a.Binding < b.Binding)
+	})
+	sort.Slice(out.Samplers, func(i, j int) bool {
+		a, b := out.Samplers[i], out.Samplers[j]
+		return a.Set < b.Set || (a.Set == b.Set && a.Binding < b.Binding)
+	})
+
+	inputCount := C.uint32_t(0)
+	if err := spvReflectError(C.spvReflectEnumerateInputVariables(
+		&module, &inputCount, nil)); err != nil {
+		return Reflection{}, err
+	}
+	inputs := make([]*C.SpvReflectInterfaceVariable, inputCount)
+	if inputCount > 0 {
+		if err := spvReflectError(C.spvReflectEnumerateInputVariables(
+			&module, &inputCount, &inputs[0])); err != nil {
+			return Reflection{}, err
+		}
+	}
+	for _, in := range inputs {
+		if uint32(in.decoration_flags)&uint32(C.SPV_REFLECT_DECORATION_BUILT_IN) != 0 {
+			continue
+		}
+		out.Inputs = append(out.Inputs, Input{
+			Variable: Variable{
+				Name: C.GoString(in.name),
+				Type: typeName(in.type_description),
+			},
+			Location: uint32(in.location),
+		})
+	}
+	sort.Slice(out.Inputs, func(i, j int) bool {
+		return out.Inputs[i].Location < out.Inputs[j].Location
+	})
+
+	return out, nil
+}
+
+func spvReflectError(res C.SpvReflectResult) error {
+	if res == C.SPV_REFLECT_RESULT_SUCCESS {
+		return nil
+	}
+	return fmt.Errorf("SPIRV-Reflect failed with error code %v", res)
+}
+
+// typeName returns the GLSL name of the type described by desc.
+func typeName(desc *C.SpvReflectTypeDescription) string {
+	if desc == nil {
+		return ""
+	}
+	if name := C.GoString(desc.type_name); name != "" {
+		return name // Structs
+	}
+	flags := uint32(desc.type_flags)
+	numeric := desc.traits.numeric
+	scalar, prefix := "float", ""
+	switch {
+	case flags&uint32(C.SPV_REFLECT_TYPE_FLAG_BOOL) != 0:
+		scalar, prefix = "bool", "b"
+	case flags&uint32(C.SPV_REFLECT_TYPE_FLAG_INT) != 0:
+		if numeric.scalar.signedness != 0 {
+			scalar, prefix = "int", "i"
+		} else {
+			scalar, prefix = "uint", "u"
+		}
+	case numeric.scalar.width == 64:
+		scalar, prefix = "double", "d"
+	}
+
+	name := scalar
+	switch {
+	case flags&uint32(C.SPV_REFLECT_TYPE_FLAG_MATRIX) != 0:
+		cols, rows := numeric.matrix.column_count, numeric.matrix.row_count
+		if cols == rows {
+			name = fmt.Sprintf("%vmat%d", prefix, cols)
+		} else {
+			name = fmt.Sprintf("%vmat%dx%d", prefix, cols, rows)
+		}
+	case flags&uint32(C.SPV_REFLECT_TYPE_FLAG_VECTOR) != 0:
+		name = fmt.Sprintf("%vvec%d", prefix, numeric.vector.component_count)
+	}
+	array := desc.traits.array
+	for i := C.uint32_t(0); i < array.dims_count; i++ {
+		name += fmt.Sprintf("[%d]", array.dims[i])
+	}
+	return name
+}
+
+// samplerTypeName returns the GLSL name of the type of the sampler, texture or
+// image binding b.
+func samplerTypeName(b *C.SpvReflectDescriptorBinding) string {
+	name := ""
+	switch b.descriptor_type {
+	case C.SPV_REFLECT_DESCRIPTOR_TYPE_SAMPLER:
+		return "sampler"
+	case C.SPV_REFLECT_DESCRIPTOR_TYPE_COMBINED_IMAGE_SAMPLER:
+		name = "sampler"
+	case C.SPV_REFLECT_DESCRIPTOR_TYPE_SAMPLED_IMAGE:
+		name = "texture"
+	case C.SPV_REFLECT_DESCRIPTOR_TYPE_STORAGE_IMAGE:
+		name = "image"
+	}
+	switch b.image.dim {
+	case C.SpvDim1D:
+		name += "1D"
+	case C.SpvDim2D:
+		name += "2D"
+	case C.SpvDim3D:
+		name += "3D"
+	case C.SpvDimCube:
+		name += "Cube"
+	case C.SpvDimRect:
+		name += "2DRect"
+	case C.SpvDimBuffer:
+		name += "Buffer"
+	}
+	if b.image.ms != 0 {
+		name += "MS"
+	}
+	if b.image.arrayed != 0 {
+		name += "Array"
+	}
+	if b.image.depth == 1 && b.descriptor_type == C.SPV_REFLECT_DESCRIPTOR_TYPE_COMBINED_IMAGE_SAMPLER {
+		name += "Shadow"
+	}
+	return name
+}
@@ -112,6 +112,12 @@ func (t ShaderType) String() string {
 	}
 }
 
+// Supported returns true if the library was built with support for shaders
+// of type t.
+func (t ShaderType) Supported() bool {
+	return bool(C.isSupportedShaderType(C.shader_type(t)))
+}
+
 // ConvertOptions controls how ConvertGlsl converts its passed-in GLSL source code.
 type ConvertOptions struct {
 	// The type of shader.
@@ -89,6 +89,21 @@ void main()
 	}
 }
 
+func TestSupported(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, ty := range []shadertools.ShaderType{
+		shadertools.TypeVertex,
+		shadertools.TypeTessControl,
+		shadertools.TypeTessEvaluation,
+		shadertools.TypeGeometry,
+		shadertools.TypeFragment,
+		shadertools.TypeCompute,
+	} {
+		assert.For(ctx, "%v supported", ty).That(ty.Supported()).Equals(true)
+	}
+	assert.For(ctx, "unknown supported").That(shadertools.ShaderType(-1).Supported()).Equals(false)
+}
+
 func TestCompileGlsl(t *testing.T) {
 	for _, test := range []struct {
 		desc     string
@@ -548,6 +563,51 @@ func TestParseDescriptorSets(t *testing.T) {
 	}
 }
 
+func TestReflect(t *testing.T) {
+	ctx := log.Testing(t)
+	src := `#version 450
+layout(set = 0, binding = 1) uniform Transforms {
+	mat4 mvp;
+	vec4 tint;
+} transforms;
+layout(set = 1, binding = 0) uniform sampler2D tex;
+layout(location = 0) in vec3 position;
+layout(location = 1) in vec2 uv;
+layout(location = 0) out vec4 color;
+void main() {
+	color = textureLod(tex, uv, 0.0) * transforms.tint;
+	gl_Position = transforms.mvp * vec4(position, 1.0);
+}`
+	got, err := shadertools.Reflect(src, shadertools.CompileOptions{
+		ShaderType: shadertools.TypeVertex,
+		ClientType: shadertools.Vulkan,
+	})
+	expected := shadertools.Reflection{
+		Uniforms: []shadertools.Uniform{{
+			Variable: shadertools.Variable{Name: "transforms", Type: "Transforms"},
+			Set:      0,
+			Binding:  1,
+			Members: []shadertools.Variable{
+				{Name: "mvp", Type: "mat4"},
+				{Name: "tint", Type: "vec4"},
+			},
+		}},
+		Samplers: []shadertools.Sampler{{
+			Variable: shadertools.Variable{Name: "tex", Type: "sampler2D"},
+			Set:      1,
+			Binding:  0,
+			Count:    1,
+		}},
+		Inputs: []shadertools.Input{
+			{Variable: shadertools.Variable{Name: "position", Type: "vec3"}, Location: 0},
+			{Variable: shadertools.Variable{Name: "uv", Type: "vec2"}, Location: 1},
+		},
+	}
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		assert.For(ctx, "reflection").That(got).DeepEquals(expected)
+	}
+}
+
 var (
 	multientrypoint_spv = `
 ; SPIR-V
@@ -58,3 +58,18 @@ type Tag struct {
 	Identifier string
 	Type       string
 }
+
+// Plural represents a plural selection in the form:
+// {identifier, plural, one{body} other{body}}.
+type Plural struct {
+	Identifier string
+	Cases      []*PluralCase
+}
+
+// PluralCase represents a single case of a plural selection. Category is
+// either a plural category (zero, one, two, few, many or other) or an exact
+// value in the form '=N'.
+type PluralCase struct {
+	Category string
+	Body     Node
+}
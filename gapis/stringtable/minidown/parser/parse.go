@@ -149,6 +149,12 @@ func (p *parser) parse() *node.Block {
 				p.add(&node.Tag{Identifier: str, Type: "string"})
 			}
 
+		case token.Plural:
+			str := t.CST().Tok().String()
+			str = strings.SplitN(str[1:], ",", 2)[0] // trim { and everything from ,
+			p.stack.push(&node.Plural{Identifier: strings.TrimSpace(str)})
+			continue // Don't add whitespace suffix.
+
 		case token.OpenBracket:
 			switch {
 			case t.Is('['):
@@ -157,6 +163,12 @@ func (p *parser) parse() *node.Block {
 				p.stack.push(l)
 				p.stack.push(l.Body)
 
+			case t.Is('{') && p.pluralCase() != nil:
+				// Opening plural case body
+				c := p.pluralCase()
+				c.Body = &node.Block{}
+				p.stack.push(c.Body)
+
 			default:
 				p.add(&node.Text{Text: t.CST().Tok().String()})
 			}
@@ -187,6 +199,26 @@ func (p *parser) parse() *node.Block {
 					p.add(&node.Text{Text: t.CST().Tok().String()})
 				}
 
+			case t.Is('}'):
+				if _, ok := p.stack.peek(1).(*node.Plural); ok {
+					// closing plural case body
+					p.stack.pop() // body
+				} else if plural, ok := p.stack.head().(*node.Plural); ok {
+					// closing plural
+					p.stack.pop() // plural
+					if len(plural.Cases) == 0 {
+						p.errors.Add(nil, t.CST(), "Plural '%s' has no cases", plural.Identifier)
+					}
+					for _, c := range plural.Cases {
+						if c.Body == nil {
+							p.errors.Add(nil, t.CST(), "Plural '%s' case '%s' has no body", plural.Identifier, c.Category)
+						}
+					}
+					p.add(plural)
+				} else {
+					p.add(&node.Text{Text: t.CST().Tok().String()})
+				}
+
 			default:
 				p.add(&node.Text{Text: t.CST().Tok().String()})
 			}
@@ -208,9 +240,25 @@ func (p *parser) parse() *node.Block {
 		}
 	}
 	p.handleNewLine()
+	for _, n := range p.stack {
+		if plural, ok := n.(*node.Plural); ok {
+			p.errors.Add(nil, p.curr.CST(), "Unclosed plural '%s'", plural.Identifier)
+		}
+	}
 	return root
 }
 
+// pluralCase returns the plural case awaiting its body, if the node at the top
+// of the stack is a plural. Otherwise pluralCase returns nil.
+func (p *parser) pluralCase() *node.PluralCase {
+	if plural, ok := p.stack.head().(*node.Plural); ok {
+		if c := len(plural.Cases); c > 0 && plural.Cases[c-1].Body == nil {
+			return plural.Cases[c-1]
+		}
+	}
+	return nil
+}
+
 // handleNewLine adjusts nodes on the stack for a newline.
 func (p *parser) handleNewLine() {
 	for {
@@ -279,6 +327,13 @@ func addTo(parent, n node.Node) {
 		}
 		addTo(parent.Body, n)
 
+	case *node.Plural:
+		// Text between the cases of a plural names the next case. Anything else
+		// is ignored.
+		if text, ok := n.(*node.Text); ok {
+			parent.Cases = append(parent.Cases, &node.PluralCase{Category: text.Text})
+		}
+
 	default:
 		panic(fmt.Errorf("Node %T cannot have children", parent))
 	}
@@ -352,6 +407,10 @@ func compact(n node.Node) node.Node {
 	case *node.Link:
 		n.Body = compact(n.Body)
 		n.Target = compact(n.Target)
+	case *node.Plural:
+		for _, c := range n.Cases {
+			c.Body = compact(c.Body)
+		}
 	case *node.Text, *node.NewLine, *node.Whitespace, *node.Tag, nil:
 	default:
 		panic(fmt.Errorf("Unknown node type %T", n))
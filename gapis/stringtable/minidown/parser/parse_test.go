@@ -38,6 +38,10 @@ func TestParser(t *testing.T) {
 	H3 := func(n node.Node) node.Node { return &node.Heading{Scale: 3, Body: n} }
 	WS := func() node.Node { return &node.Whitespace{} }
 	NL := func() node.Node { return &node.NewLine{} }
+	P := func(id string, c ...*node.PluralCase) node.Node { return &node.Plural{Identifier: id, Cases: c} }
+	C := func(category string, n node.Node) *node.PluralCase {
+		return &node.PluralCase{Category: category, Body: n}
+	}
 
 	for _, test := range []struct {
 		source   string
@@ -130,9 +134,36 @@ func TestParser(t *testing.T) {
 
 		{`This is an [unclosed link](target.`,
 			B(T("This is an"), WS(), L(T("unclosed link"), nil), T("(target."))},
+
+		{`You have {count, plural, one{# frame} other{# frames}}.`,
+			B(
+				T("You have"), WS(),
+				P("count", C("one", T("# frame")), C("other", T("# frames"))),
+				T("."),
+			)},
+
+		{`{n, plural, =0{No frames} other{{{n:int}} frames}}`,
+			P("n", C("=0", T("No frames")), C("other", B(TAGT("n", "int"), WS(), T("frames"))))},
 	} {
 		got, errs := parser.Parse("test", test.source)
 		assert.For(test.source).ThatSlice(errs).IsEmpty()
 		assert.For(test.source).That(got).DeepEquals(test.expected)
 	}
 }
+
+func TestParserErrors(t *testing.T) {
+	assert := assert.To(t)
+
+	for _, test := range []struct {
+		source   string
+		expected string
+	}{
+		{`{count, plural, one{# frame} other{# frames}`, "Unclosed plural 'count'"},
+		{`{count, plural, one other{# frames}}`, "Plural 'count' case 'one' has no body"},
+	} {
+		_, errs := parser.Parse("test", test.source)
+		if assert.For(test.source).ThatSlice(errs).IsLength(1) {
+			assert.For(test.source).ThatString(errs[0].Message).Equals(test.expected)
+		}
+	}
+}
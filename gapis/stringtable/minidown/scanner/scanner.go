@@ -117,6 +117,8 @@ func Scan(filename, data string) ([]token.Token, parse.ErrorList) {
 				p.ParseLeaf(root, func(cst *cst.Leaf) {
 					if ok, typed := parseTag(p); ok {
 						tokens = append(tokens, token.Tag{Leaf: cst, Typed: typed})
+					} else if parsePlural(p) {
+						tokens = append(tokens, token.Plural{Leaf: cst})
 					} else {
 						p.Advance()
 						tokens = append(tokens, token.OpenBracket{Leaf: cst})
@@ -217,3 +219,36 @@ func parseTag(p *parse.Parser) (bool, bool) {
 		}
 	}
 }
+
+// parsePlural attempts to parse the start of a plural selection in the form:
+// '{identifier, plural,'
+func parsePlural(p *parse.Parser) bool {
+	i := 1
+	space := func() {
+		for c := p.PeekN(i); c == ' ' || c == '\t'; c = p.PeekN(i) {
+			i++
+		}
+	}
+	word := func() string {
+		space()
+		r := []rune{}
+		for c := p.PeekN(i); unicode.IsNumber(c) || unicode.IsLetter(c) || c == '_'; c = p.PeekN(i) {
+			r = append(r, c)
+			i++
+		}
+		return string(r)
+	}
+	comma := func() bool {
+		space()
+		if p.PeekN(i) != ',' {
+			return false
+		}
+		i++
+		return true
+	}
+	if word() == "" || !comma() || word() != "plural" || !comma() {
+		return false
+	}
+	p.AdvanceN(i)
+	return true
+}
@@ -35,6 +35,7 @@ func TestScanner(t *testing.T) {
 	E := func(t string) tok { return tok{t, "token.Emphasis"} }
 	T := func(t string) tok { return tok{t, "token.Text"} }
 	TAG := func(t string) tok { return tok{"{{" + t + "}}", "token.Tag"} }
+	PL := func(t string) tok { return tok{t, "token.Plural"} }
 	OB := func(r rune) tok { return tok{string([]rune{r}), "token.OpenBracket"} }
 	CB := func(r rune) tok { return tok{string([]rune{r}), "token.CloseBracket"} }
 	NL := func() tok { return tok{"\n", "token.NewLine"} }
@@ -83,6 +84,13 @@ func TestScanner(t *testing.T) {
 		{`\[{{Tag_in_brackets}}\]`, []tok{
 			T("["), TAG("Tag_in_brackets"), T("]"),
 		}},
+		{`{count, plural, one{# frame} other{{{count}} frames}}`, []tok{
+			PL("{count, plural,"), T("one"), OB('{'), H("#"), T("frame"), CB('}'),
+			T("other"), OB('{'), TAG("count"), T("frames"), CB('}'), CB('}'),
+		}},
+		{`{not, a plural}`, []tok{
+			OB('{'), T("not,"), T("a"), T("plural"), CB('}'),
+		}},
 	} {
 		tokens, errs := scanner.Scan("test", test.source)
 		assert.For(test.source).ThatSlice(errs).IsEmpty()
@@ -64,6 +64,11 @@ type Tag struct {
 	Typed bool
 }
 
+// Plural is the start of a plural selection, in the form '{identifier, plural,'.
+type Plural struct {
+	*cst.Leaf
+}
+
 // OpenBracket represents a '(', '[' or '{'.
 type OpenBracket struct {
 	*cst.Leaf
@@ -98,6 +103,9 @@ func (t Text) CST() cst.Node { return t.Leaf }
 // CST returns the cst.Node of this token.
 func (t Tag) CST() cst.Node { return t.Leaf }
 
+// CST returns the cst.Node of this token.
+func (t Plural) CST() cst.Node { return t.Leaf }
+
 // CST returns the cst.Node of this token.
 func (t OpenBracket) CST() cst.Node { return t.Leaf }
 
@@ -29,7 +29,7 @@ func (m *Msg) Text(tbl *StringTable) string {
 	if tbl != nil {
 		if entry, ok := tbl.Entries[m.Identifier]; ok {
 			b := &bytes.Buffer{}
-			writeNodes(entry, m.Arguments, b)
+			writeNodes(entry, tbl.GetInfo().GetCultureCode(), m.Arguments, b)
 			return b.String()
 		}
 	}
@@ -44,11 +44,13 @@ func (m *Msg) Text(tbl *StringTable) string {
 	return fmt.Sprintf("<%v [%v]>", m.Identifier, strings.Join(args, ", "))
 }
 
-func writeNodes(n interface{}, args map[string]*Value, w *bytes.Buffer) {
+func writeNodes(n interface{}, culture string, args map[string]*Value, w *bytes.Buffer) {
 	switch n := n.(type) {
+	case *Node:
+		writeNodes(n.body(), culture, args, w)
 	case *Block:
 		for _, n := range n.Children {
-			writeNodes(n, args, w)
+			writeNodes(n, culture, args, w)
 		}
 	case *Text:
 		w.WriteString(n.Text)
@@ -57,23 +59,60 @@ func writeNodes(n interface{}, args map[string]*Value, w *bytes.Buffer) {
 	case *Parameter:
 		v := args[n.Key]
 		w.WriteString(fmt.Sprintf("%v", v))
+	case *Plural:
+		writeNodes(n.Select(culture, args), culture, args, w)
 	case *Link:
-		writeNodes(n.Body, args, w)
+		writeNodes(n.Body, culture, args, w)
 	case *Bold:
-		writeNodes(n.Body, args, w)
+		writeNodes(n.Body, culture, args, w)
 	case *Italic:
-		writeNodes(n.Body, args, w)
+		writeNodes(n.Body, culture, args, w)
 	case *Underlined:
-		writeNodes(n.Body, args, w)
+		writeNodes(n.Body, culture, args, w)
 	case *Heading:
-		writeNodes(n.Body, args, w)
+		writeNodes(n.Body, culture, args, w)
 	case *Code:
-		writeNodes(n.Body, args, w)
+		writeNodes(n.Body, culture, args, w)
 	case *List:
 		for _, n := range n.Items {
 			w.WriteString(" * ")
-			writeNodes(n, args, w)
+			writeNodes(n, culture, args, w)
 			w.WriteRune('\n')
 		}
 	}
 }
+
+// body returns the node type held by the node.
+func (n *Node) body() interface{} {
+	switch n := n.GetNode().(type) {
+	case *Node_Block:
+		return n.Block
+	case *Node_Text:
+		return n.Text
+	case *Node_LineBreak:
+		return n.LineBreak
+	case *Node_Whitespace:
+		return n.Whitespace
+	case *Node_Parameter:
+		return n.Parameter
+	case *Node_Link:
+		return n.Link
+	case *Node_Bold:
+		return n.Bold
+	case *Node_Italic:
+		return n.Italic
+	case *Node_Underlined:
+		return n.Underlined
+	case *Node_Heading:
+		return n.Heading
+	case *Node_Code:
+		return n.Code
+	case *Node_List:
+		return n.List
+	case *Node_Formatter:
+		return n.Formatter
+	case *Node_Plural:
+		return n.Plural
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ package parser
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	st "github.com/google/gapid/gapis/stringtable"
@@ -168,9 +169,8 @@ func convert(in node.Node, currentKey string, typeMap ParameterTypeMap, tagSet t
 			tagSet[*in] = true
 			param := &st.Parameter{Key: in.Identifier}
 			paramID := ParameterID{ParameterKey: param.Key, EntryKey: currentKey}
-			// Check if we've met parameter with such an identifier, but type differs
-			// (therefore tagSet contains different node).
-			if t, typeExists := typeMap[paramID]; typeExists {
+			// Check if we've met parameter with such an identifier, but type differs.
+			if t, typeExists := typeMap[paramID]; typeExists && t != in.Type {
 				return nil, []error{fmt.Errorf("Entry %s contains duplicate parameters"+
 					" %s with different types: %s != %s", paramID.EntryKey,
 					paramID.ParameterKey, t, in.Type)}
@@ -180,7 +180,118 @@ func convert(in node.Node, currentKey string, typeMap ParameterTypeMap, tagSet t
 		}
 		return nil, nil
 
+	case *node.Block:
+		block := &st.Block{}
+		var errs []error
+		for _, n := range in.Children {
+			converted, e := convert(n, currentKey, typeMap, tagSet)
+			errs = append(errs, e...)
+			if converted != nil {
+				block.Children = append(block.Children, converted)
+			}
+		}
+		return &st.Node{Node: &st.Node_Block{Block: block}}, errs
+
+	case *node.Plural:
+		paramID := ParameterID{ParameterKey: in.Identifier, EntryKey: currentKey}
+		if t, typeExists := typeMap[paramID]; typeExists && t != pluralCountType {
+			return nil, []error{fmt.Errorf("Entry %s contains duplicate parameters"+
+				" %s with different types: %s != %s", paramID.EntryKey,
+				paramID.ParameterKey, t, pluralCountType)}
+		}
+		typeMap[paramID] = pluralCountType
+
+		var errs []error
+		plural := &st.Plural{Key: in.Identifier, Cases: map[string]*st.Node{}}
+		for _, c := range in.Cases {
+			if !isPluralCategory(c.Category) {
+				errs = append(errs, fmt.Errorf("Entry %s plural %s has invalid case '%s'",
+					currentKey, in.Identifier, c.Category))
+				continue
+			}
+			if _, dup := plural.Cases[c.Category]; dup {
+				errs = append(errs, fmt.Errorf("Entry %s plural %s has duplicate case '%s'",
+					currentKey, in.Identifier, c.Category))
+				continue
+			}
+			body := &st.Node{}
+			if c.Body != nil {
+				// Parameters can be used by each of the cases, so each case
+				// gets its own copy of the processed tags.
+				caseTags := make(map[node.Tag]bool, len(tagSet))
+				for t := range tagSet {
+					caseTags[t] = true
+				}
+				converted, e := convert(c.Body, currentKey, typeMap, caseTags)
+				errs = append(errs, e...)
+				if converted != nil {
+					body = withCount(converted, in.Identifier)
+				}
+			}
+			plural.Cases[c.Category] = body
+		}
+		return &st.Node{Node: &st.Node_Plural{Plural: plural}}, errs
+
 	default:
 		return nil, []error{fmt.Errorf("Stringtable does not currently support %T", in)}
 	}
 }
+
+// pluralCountType is the parameter type of the count of a plural.
+const pluralCountType = "s64"
+
+// isPluralCategory returns true if category is a plural category, or is an
+// exact value in the form '=N'.
+func isPluralCategory(category string) bool {
+	switch category {
+	case st.PluralZero, st.PluralOne, st.PluralTwo, st.PluralFew, st.PluralMany, st.PluralOther:
+		return true
+	}
+	if strings.HasPrefix(category, "=") {
+		_, err := strconv.ParseInt(category[1:], 10, 64)
+		return err == nil
+	}
+	return false
+}
+
+// withCount returns n with each '#' in its text replaced with the parameter
+// holding the count of the plural.
+func withCount(n *st.Node, key string) *st.Node {
+	switch body := n.Node.(type) {
+	case *st.Node_Text:
+		parts := strings.Split(body.Text.Text, "#")
+		if len(parts) == 1 {
+			return n
+		}
+		block := &st.Block{}
+		for i, part := range parts {
+			if i > 0 {
+				block.Children = append(block.Children, &st.Node{
+					Node: &st.Node_Parameter{Parameter: &st.Parameter{Key: key}},
+				})
+			}
+			if part != "" {
+				block.Children = append(block.Children, &st.Node{
+					Node: &st.Node_Text{Text: &st.Text{Text: part}},
+				})
+			}
+		}
+		return &st.Node{Node: &st.Node_Block{Block: block}}
+	case *st.Node_Block:
+		children := make([]*st.Node, 0, len(body.Block.Children))
+		for _, c := range body.Block.Children {
+			if _, isText := c.Node.(*st.Node_Text); isText {
+				// Splice in the parts of the text, rather than nesting blocks.
+				if b := withCount(c, key).GetBlock(); b != nil {
+					children = append(children, b.Children...)
+					continue
+				}
+			}
+			children = append(children, withCount(c, key))
+		}
+		body.Block.Children = children
+	case *st.Node_Link:
+		body.Link.Body = withCount(body.Link.Body, key)
+	}
+	return n
+}
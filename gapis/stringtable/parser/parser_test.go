@@ -43,6 +43,9 @@ line
 # LINKS
 [{{person}}]({{link}}) likes to use [google](http://www.google.com).
 
+# PLURAL
+{count, plural, =0{No frames} one{# frame} other{{{count:s64}} of # frames}}
+
 ` + "# SINGLE_LINE_STRING_CRLF\r\nThis is\r\n a simple\r\n string"
 
 func TestParser(t *testing.T) {
@@ -58,6 +61,9 @@ func TestParser(t *testing.T) {
 	P := func(k string) *st.Node { return &st.Node{Node: &st.Node_Parameter{Parameter: &st.Parameter{Key: k}}} }
 	L := func(b, t *st.Node) *st.Node { return &st.Node{Node: &st.Node_Link{Link: &st.Link{Body: b, Target: t}}} }
 	WS := func() *st.Node { return &st.Node{Node: &st.Node_Whitespace{Whitespace: &st.Whitespace{}}} }
+	PL := func(k string, c map[string]*st.Node) *st.Node {
+		return &st.Node{Node: &st.Node_Plural{Plural: &st.Plural{Key: k, Cases: c}}}
+	}
 
 	for _, test := range []struct {
 		key      string
@@ -80,6 +86,11 @@ func TestParser(t *testing.T) {
 			WS(), T("likes to use"), WS(),
 			L(T("google"), T("http://www.google.com")),
 			T("."))},
+		{"PLURAL", PL("count", map[string]*st.Node{
+			"=0":    T("No frames"),
+			"one":   B(P("count"), T(" frame")),
+			"other": B(P("count"), WS(), T("of "), P("count"), T(" frames")),
+		})},
 		{"SINGLE_LINE_STRING_CRLF", T("This is a simple string")},
 	} {
 		got, found := loc.Entries[test.key]
@@ -0,0 +1,159 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stringtable
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Plural categories, as defined by the Unicode CLDR.
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+// pluralRule returns the plural category of the integer n.
+type pluralRule func(n int64) string
+
+// pluralRules is a map of language code to plural rule, with the categories
+// the rule can select. Languages not listed use the English rule.
+var pluralRules = map[string]struct {
+	rule       pluralRule
+	categories []string
+}{
+	"en": {oneOther, []string{PluralOne, PluralOther}},
+	"fr": {zeroOneOther, []string{PluralOne, PluralOther}},
+	"ja": {otherOnly, []string{PluralOther}},
+	"ko": {otherOnly, []string{PluralOther}},
+	"zh": {otherOnly, []string{PluralOther}},
+	"cs": {oneFewOther, []string{PluralOne, PluralFew, PluralOther}},
+	"sk": {oneFewOther, []string{PluralOne, PluralFew, PluralOther}},
+	"pl": {polish, []string{PluralOne, PluralFew, PluralMany, PluralOther}},
+	"ru": {eastSlavic, []string{PluralOne, PluralFew, PluralMany, PluralOther}},
+	"uk": {eastSlavic, []string{PluralOne, PluralFew, PluralMany, PluralOther}},
+}
+
+func oneOther(n int64) string {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func zeroOneOther(n int64) string {
+	if n == 0 || n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func otherOnly(n int64) string {
+	return PluralOther
+}
+
+func oneFewOther(n int64) string {
+	switch {
+	case n == 1:
+		return PluralOne
+	case n >= 2 && n <= 4:
+		return PluralFew
+	}
+	return PluralOther
+}
+
+func polish(n int64) string {
+	switch {
+	case n == 1:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+func eastSlavic(n int64) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+// language returns the plural rules for the culture code, like en-us.
+func language(cultureCode string) (pluralRule, []string) {
+	lang := strings.ToLower(strings.SplitN(cultureCode, "-", 2)[0])
+	if r, ok := pluralRules[lang]; ok {
+		return r.rule, r.categories
+	}
+	r := pluralRules["en"]
+	return r.rule, r.categories
+}
+
+// PluralCategory returns the plural category of the count n in the culture.
+func PluralCategory(cultureCode string, n int64) string {
+	if n < 0 {
+		n = -n
+	}
+	rule, _ := language(cultureCode)
+	return rule(n)
+}
+
+// PluralCategories returns the plural categories that a plural must provide
+// cases for in the culture.
+func PluralCategories(cultureCode string) []string {
+	_, categories := language(cultureCode)
+	return categories
+}
+
+// Select returns the case of the plural for the count held by args, using the
+// plural rules of the culture. A case for the exact count takes precedence
+// over the case for its plural category, and the other case is used if the
+// count is missing or is not an integer.
+func (p *Plural) Select(cultureCode string, args map[string]*Value) *Node {
+	n, ok := count(args[p.Key])
+	if !ok {
+		return p.Cases[PluralOther]
+	}
+	if c, ok := p.Cases[fmt.Sprintf("=%d", n)]; ok {
+		return c
+	}
+	if c, ok := p.Cases[PluralCategory(cultureCode, n)]; ok {
+		return c
+	}
+	return p.Cases[PluralOther]
+}
+
+// count returns the integer held by v.
+func count(v *Value) (int64, bool) {
+	if v == nil || v.Value == nil {
+		return 0, false
+	}
+	r := reflect.ValueOf(v.Unpack())
+	switch r.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(r.Uint()), true
+	}
+	return 0, false
+}
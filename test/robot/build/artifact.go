@@ -73,7 +73,10 @@ func (a *artifacts) apply(ctx context.Context, artifact *Artifact) error {
 
 func (a *artifacts) search(ctx context.Context, query *search.Query, handler ArtifactHandler) error {
 	filter := eval.Filter(ctx, query, reflect.TypeOf(&Artifact{}), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, a.entries)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(&Artifact{}), event.AsProducer(ctx, a.entries))
+	if err != nil {
+		return err
+	}
 	if query.Monitor {
 		return event.Monitor(ctx, &a.mu, a.onAdd.Listen, initial, filter)
 	}
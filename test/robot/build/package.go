@@ -85,7 +85,10 @@ func (p *packages) apply(ctx context.Context, pkg *Package) error {
 
 func (p *packages) search(ctx context.Context, query *search.Query, handler PackageHandler) error {
 	filter := eval.Filter(ctx, query, packageClass, event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, p.entries)
+	initial, err := eval.Select(ctx, query, packageClass, event.AsProducer(ctx, p.entries))
+	if err != nil {
+		return err
+	}
 	if query.Monitor {
 		return event.Monitor(ctx, &p.mu, p.onChange.Listen, initial, filter)
 	}
@@ -84,7 +84,10 @@ func (t *tracks) apply(ctx context.Context, track *Track) error {
 
 func (t *tracks) search(ctx context.Context, query *search.Query, handler TrackHandler) error {
 	filter := eval.Filter(ctx, query, trackClass, event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, t.entries)
+	initial, err := eval.Select(ctx, query, trackClass, event.AsProducer(ctx, t.entries))
+	if err != nil {
+		return err
+	}
 	if query.Monitor {
 		return event.Monitor(ctx, &t.mu, t.onChange.Listen, initial, filter)
 	}
@@ -59,7 +59,10 @@ func (l *devices) apply(ctx context.Context, entry *Device) error {
 
 func (l *devices) search(ctx context.Context, query *search.Query, handler DeviceHandler) error {
 	filter := eval.Filter(ctx, query, reflect.TypeOf(&Device{}), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, l.entries)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(&Device{}), event.AsProducer(ctx, l.entries))
+	if err != nil {
+		return err
+	}
 	if query.Monitor {
 		return event.Monitor(ctx, &l.mu, l.onChange.Listen, initial, filter)
 	}
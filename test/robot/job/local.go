@@ -53,7 +53,10 @@ func (m *local) SearchDevices(ctx context.Context, query *search.Query, handler
 // It searches the set of persisted workers, and supports monitoring of workers as they are registered.
 func (m *local) SearchWorkers(ctx context.Context, query *search.Query, handler WorkerHandler) error {
 	filter := eval.Filter(ctx, query, reflect.TypeOf(&Worker{}), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, m.entries)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(&Worker{}), event.AsProducer(ctx, m.entries))
+	if err != nil {
+		return err
+	}
 	if query.Monitor {
 		return event.Monitor(ctx, &m.mu, m.onChange.Listen, initial, filter)
 	}
@@ -141,7 +141,10 @@ func (a *Actions) ByInput(ctx context.Context, input proto.Message) Action {
 // Search runs the query for each entry in the action list, and hands the matches to the action handler.
 func (a *Actions) Search(ctx context.Context, query *search.Query, handler interface{}) error {
 	filter := eval.Filter(ctx, query, reflect.TypeOf(a.nullAction), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, a.entries)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(a.nullAction), event.AsProducer(ctx, a.entries))
+	if err != nil {
+		return err
+	}
 	if query.Monitor {
 		return event.Monitor(ctx, &a.mu, a.onChange.Listen, initial, filter)
 	}
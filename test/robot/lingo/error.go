@@ -15,7 +15,6 @@
 package lingo
 
 import (
-	"bytes"
 	"fmt"
 	"strings"
 
@@ -83,24 +82,20 @@ func (s *Scanner) Error(err error, msg string) error {
 	return result
 }
 
-func positionOf(data []byte, offset int) (line, column int) {
-	last := 0
-	line = 1
-	for {
-		count := bytes.IndexRune(data[last:], '\n')
-		if count < 0 || last+count >= offset {
-			return line, offset + 1 - last
-		}
-		last += count + 1
-		line++
+// ErrorPosition returns the position in the scanned input at which the error
+// err was found, and true if err was returned by a Scanner.
+func ErrorPosition(err error) (Position, bool) {
+	if se, ok := err.(scanError); ok {
+		return se.scanner.Position(se.offset), true
 	}
+	return Position{}, false
 }
 
 // Error is to make scanError conform to the error interface.
 // It returns a message that includes the scan stream position and associated messages/errors.
 func (err scanError) Error() string {
-	line, col := positionOf(err.scanner.data, err.offset)
-	result := fmt.Sprintf("%s:%d:%d:%s", err.scanner.name, line, col, err.message)
+	pos := err.scanner.Position(err.offset)
+	result := fmt.Sprintf("%s:%v:%s", err.scanner.name, pos, err.message)
 	if err.cause != nil {
 		result = fmt.Sprintf("%s:%s", result, err.cause)
 	}
@@ -112,6 +107,5 @@ func (err scanError) Error() string {
 
 // Trace writes a message to the context at info level
 func (s *Scanner) Trace(msg string) {
-	line, col := positionOf(s.data, s.offset)
-	log.I(s.ctx, "%s:%d:%d:%s", s.name, line, col, msg)
+	log.I(s.ctx, "%s:%v:%s", s.name, s.Position(s.offset), msg)
 }
@@ -26,7 +26,20 @@ type Record struct {
 // Records is a list of Record objects that represent the ordered sequence of parse results.
 type Records []Record
 
-// Node is the form used when Records are reconstituted into a
+// Position is a location in the scanned input.
+type Position struct {
+	Offset int // The byte offset from the start of the input.
+	Line   int // The line number, starting at 1.
+	Column int // The byte offset from the start of the line, starting at 1.
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Node is the form used when Records are reconstituted into a tree.
+// Start and End are the byte offsets of the scan stream bounds of the node,
+// which can be converted to a Position with Scanner.Position.
 type Node struct {
 	Start    int
 	End      int
@@ -37,12 +50,12 @@ type Node struct {
 
 // ToCST converts from a record list to a node span tree.
 func (r Records) ToCST() *Node {
-	root := &Node{
-		Start: 0,
-		End:   len(r),
-	}
+	root := &Node{}
 	active := root
 	for _, entry := range r {
+		if entry.End > root.End {
+			root.End = entry.End
+		}
 		for entry.Start >= active.End && active.Parent != nil {
 			active = active.Parent
 		}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"regexp"
+	"sort"
 	"unicode/utf8"
 )
 
@@ -40,6 +41,7 @@ type Scanner struct {
 	skipping  bool
 	records   *Records
 	watermark scanError
+	lines     []int // The offsets of the line starts, built on demand.
 }
 
 // NewByteScanner builds a scanner over an input byte slice.
@@ -54,6 +56,38 @@ func NewStringScanner(ctx context.Context, name string, input string, records *R
 	return NewByteScanner(ctx, name, []byte(input), records)
 }
 
+// Position returns the position in the input of the given byte offset.
+// Offsets outside of the input are clamped to its bounds, so the offset of a
+// pre-start marker is the start of the input.
+func (s *Scanner) Position(offset int) Position {
+	switch {
+	case offset < 0:
+		offset = 0
+	case offset > len(s.data):
+		offset = len(s.data)
+	}
+	if s.lines == nil {
+		s.lines = []int{0}
+		for i, b := range s.data {
+			if b == '\n' {
+				s.lines = append(s.lines, i+1)
+			}
+		}
+	}
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset })
+	return Position{
+		Offset: offset,
+		Line:   line,
+		Column: offset + 1 - s.lines[line-1],
+	}
+}
+
+// Span returns the positions in the input of the bounds of the node n, which
+// must be from the Records of this scanner.
+func (s *Scanner) Span(n *Node) (start, end Position) {
+	return s.Position(n.Start), s.Position(n.End)
+}
+
 // WasOk is a helper function called by generated parser code.
 // It is used to abandon the value result, and return true if there was no error.
 // This is used in cases where the sub-parser is optional and the result is not needed.
@@ -0,0 +1,88 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lingo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/test/robot/lingo"
+)
+
+const input = "ab\ncd\n\nef"
+
+func TestPosition(t *testing.T) {
+	ctx := log.Testing(t)
+	s := lingo.NewStringScanner(ctx, "test", input, nil)
+	for _, test := range []struct {
+		offset   int
+		expected lingo.Position
+	}{
+		{0, lingo.Position{Offset: 0, Line: 1, Column: 1}},
+		{1, lingo.Position{Offset: 1, Line: 1, Column: 2}},
+		{2, lingo.Position{Offset: 2, Line: 1, Column: 3}},
+		{3, lingo.Position{Offset: 3, Line: 2, Column: 1}},
+		{5, lingo.Position{Offset: 5, Line: 2, Column: 3}},
+		{6, lingo.Position{Offset: 6, Line: 3, Column: 1}},
+		{7, lingo.Position{Offset: 7, Line: 4, Column: 1}},
+		{9, lingo.Position{Offset: 9, Line: 4, Column: 3}},
+		// Offsets outside of the input are clamped.
+		{-1, lingo.Position{Offset: 0, Line: 1, Column: 1}},
+		{100, lingo.Position{Offset: 9, Line: 4, Column: 3}},
+	} {
+		got := s.Position(test.offset)
+		assert.For(ctx, "Position(%d)", test.offset).That(got).Equals(test.expected)
+	}
+	assert.For(ctx, "String").That(s.Position(4).String()).Equals("2:2")
+}
+
+func TestSpan(t *testing.T) {
+	ctx := log.Testing(t)
+	records := lingo.Records{}
+	s := lingo.NewStringScanner(ctx, "test", input, &records)
+	s.Literal("ab\n")
+	m := s.Mark()
+	s.Literal("cd\n")
+	s.Literal("\ne")
+	s.Register(m, "node")
+
+	root := records.ToCST()
+	if !assert.For(ctx, "children").That(len(root.Children)).Equals(1) {
+		return
+	}
+	start, end := s.Span(root.Children[0])
+	assert.For(ctx, "start").That(start).Equals(lingo.Position{Offset: 3, Line: 2, Column: 1})
+	assert.For(ctx, "end").That(end).Equals(lingo.Position{Offset: 8, Line: 4, Column: 2})
+}
+
+func TestErrorPosition(t *testing.T) {
+	ctx := log.Testing(t)
+	s := lingo.NewStringScanner(ctx, "test", input, nil)
+	s.Literal("ab\nc")
+	pos, ok := lingo.ErrorPosition(s.Error(nil, "expected x"))
+	assert.For(ctx, "ok").That(ok).Equals(true)
+	assert.For(ctx, "pos").That(pos).Equals(lingo.Position{Offset: 4, Line: 2, Column: 2})
+
+	// Errors found before the start of the input are at its start.
+	s.Reset(s.PreMark())
+	pos, ok = lingo.ErrorPosition(s.Error(nil, "expected x"))
+	assert.For(ctx, "pre-start ok").That(ok).Equals(true)
+	assert.For(ctx, "pre-start pos").That(pos).Equals(lingo.Position{Offset: 0, Line: 1, Column: 1})
+
+	_, ok = lingo.ErrorPosition(errors.New("not a scan error"))
+	assert.For(ctx, "other ok").That(ok).Equals(false)
+}
@@ -17,17 +17,28 @@ package master
 import (
 	"context"
 	"io"
+	"time"
 
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
 	"github.com/google/gapid/test/robot/search"
 	"github.com/pkg/errors"
 )
 
+// heartbeatFrequency is how often an orbiting satellite sends a heartbeat to
+// the master. It must be well below the heartbeat timeout of the master.
+const heartbeatFrequency = time.Second * 5
+
 // Client is a wrapper over a Master object that provides client targeted features.
 type Client struct {
 	// Master is the master this client is talking to.
 	// This should not be modifed, the results are undefined if you do.
-	Master   Master
+	Master Master
+	// Devices, if set, returns the devices the satellite's workers can run on.
+	// They are reported to the master when orbiting, and with every heartbeat.
+	Devices  func(context.Context) []*device.Instance
 	shutdown Shutdown
 	name     string
 }
@@ -40,8 +51,17 @@ func NewClient(ctx context.Context, m Master) *Client {
 }
 
 // Orbit registers a satellite with the master.
+// Once identified, the satellite sends heartbeats to the master until it
+// leaves orbit.
 // The function will only return when the connection is lost.
 func (c *Client) Orbit(ctx context.Context, services ServiceList) (Shutdown, error) {
+	ctx, stop := task.WithCancel(ctx)
+	defer stop()
+	devices := func(context.Context) []*device.Instance { return services.Devices }
+	if c.Devices != nil {
+		devices = c.Devices
+		services.Devices = devices(ctx)
+	}
 	err := c.Master.Orbit(ctx, services,
 		func(ctx context.Context, command *Command) error {
 			switch do := command.Do.(type) {
@@ -50,6 +70,8 @@ func (c *Client) Orbit(ctx context.Context, services ServiceList) (Shutdown, err
 			case *Command_Identify:
 				c.name = do.Identify.Name
 				log.I(ctx, "Identified as %s", c.name)
+				name := c.name
+				crash.Go(func() { c.heartbeat(ctx, name, devices) })
 				return nil
 			case *Command_Shutdown:
 				// abort the report stream
@@ -66,6 +88,22 @@ func (c *Client) Orbit(ctx context.Context, services ServiceList) (Shutdown, err
 	return c.shutdown, err
 }
 
+// heartbeat tells the master that the named satellite is alive, and which
+// devices it has, until ctx is stopped.
+func (c *Client) heartbeat(ctx context.Context, name string, devices func(context.Context) []*device.Instance) {
+	for {
+		select {
+		case <-task.ShouldStop(ctx):
+			return
+		case <-time.After(heartbeatFrequency):
+			request := &HeartbeatRequest{Name: name, Devices: devices(ctx)}
+			if _, err := c.Master.Heartbeat(ctx, request); err != nil {
+				log.W(ctx, "Heartbeat to master failed: %v", err)
+			}
+		}
+	}
+}
+
 // Shutdown causes a graceful shutdown of the server.
 func (c *Client) Shutdown(ctx context.Context, to ...string) error {
 	_, err := c.Master.Shutdown(ctx, &ShutdownRequest{
@@ -106,3 +144,17 @@ func (c *Client) Kill(ctx context.Context, to ...string) error {
 func (c *Client) Search(ctx context.Context, query *search.Query, handler SatelliteHandler) error {
 	return c.Master.Search(ctx, query, handler)
 }
+
+// FindSatellites returns the satellites that offer the capability and have a
+// device that matches deviceFilter. A nil deviceFilter matches all satellites
+// that offer the capability.
+func (c *Client) FindSatellites(ctx context.Context, capability Capability, deviceFilter *search.Query) ([]*Satellite, error) {
+	response, err := c.Master.FindSatellites(ctx, &FindSatellitesRequest{
+		Capability: capability,
+		Devices:    deviceFilter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Satellites, nil
+}
@@ -23,6 +23,9 @@ import (
 
 	"github.com/google/gapid/core/app/crash"
 	"github.com/google/gapid/core/event"
+	"github.com/google/gapid/core/fault"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
 	"github.com/google/gapid/test/robot/search"
 	"github.com/google/gapid/test/robot/search/eval"
 )
@@ -30,20 +33,39 @@ import (
 const masterName = "Master"
 const keepaliveFrequency = time.Second * 10
 
-var satelliteClass = reflect.TypeOf(&Satellite{})
+// DefaultHeartbeatTimeout is the default time after which a satellite that has
+// not sent a heartbeat is dropped by the master.
+const DefaultHeartbeatTimeout = time.Second * 30
+
+// ErrUnknownSatellite is returned by Heartbeat for satellites the master is not
+// managing, either because they never orbited or because they were dropped.
+const ErrUnknownSatellite = fault.Const("Satellite is not orbiting the master")
+
+var (
+	satelliteClass = reflect.TypeOf(&Satellite{})
+	deviceClass    = reflect.TypeOf(&device.Instance{})
+)
 
 type local struct {
-	satelliteLock sync.Mutex
-	satellites    []*satellite
-	nextID        int32
-	keepalive     *time.Ticker
-	onChange      event.Broadcast
+	satelliteLock    sync.Mutex
+	satellites       []*satellite
+	nextID           int32
+	keepalive        *time.Ticker
+	heartbeatTimeout time.Duration
+	onChange         event.Broadcast
 }
 
 // NewLocal creates a new local Master that manages it's own satellites.
-func NewLocal(ctx context.Context) Master {
+// Satellites that do not send a heartbeat for heartbeatTimeout are marked
+// offline and dropped. If heartbeatTimeout is not positive,
+// DefaultHeartbeatTimeout is used.
+func NewLocal(ctx context.Context, heartbeatTimeout time.Duration) Master {
+	if heartbeatTimeout <= 0 {
+		heartbeatTimeout = DefaultHeartbeatTimeout
+	}
 	l := &local{
-		keepalive: time.NewTicker(keepaliveFrequency),
+		keepalive:        time.NewTicker(keepaliveFrequency),
+		heartbeatTimeout: heartbeatTimeout,
 	}
 	crash.Go(func() { l.run(ctx) })
 	return l
@@ -57,25 +79,98 @@ func (m *local) Close(ctx context.Context) {
 // It searches the set of active satellites, and supports monitoring of satellites as they start orbiting.
 func (m *local) Search(ctx context.Context, query *search.Query, handler SatelliteHandler) error {
 	filter := eval.Filter(ctx, query, satelliteClass, event.AsHandler(ctx, handler))
-	initial := m.producer(ctx)
+	// Monitor feeds the initial satellites with the lock held.
+	initial, err := eval.Select(ctx, query, satelliteClass, m.producer(query.Monitor))
+	if err != nil {
+		return err
+	}
 	if query.Monitor {
 		return event.Monitor(ctx, &m.satelliteLock, m.onChange.Listen, initial, filter)
 	}
 	return event.Feed(ctx, filter, initial)
 }
 
+// FindSatellites implements Master.FindSatellites
+// It returns the active satellites that offer the requested capability and
+// have a device that matches the device filter.
+func (m *local) FindSatellites(ctx context.Context, request *FindSatellitesRequest) (*FindSatellitesResponse, error) {
+	var matches event.Predicate
+	if request.GetDevices().GetExpression() != nil {
+		pred, err := eval.Compile(ctx, request.Devices, deviceClass)
+		if err != nil {
+			return nil, err
+		}
+		matches = pred
+	}
+	m.satelliteLock.Lock()
+	defer m.satelliteLock.Unlock()
+	response := &FindSatellitesResponse{}
+	for _, sat := range m.satellites {
+		services := sat.info.Services
+		if !services.Offers(request.Capability) {
+			continue
+		}
+		if matches != nil && !anyDevice(ctx, services.GetDevices(), matches) {
+			continue
+		}
+		response.Satellites = append(response.Satellites, &Satellite{
+			Name:     sat.info.Name,
+			Services: services,
+		})
+	}
+	return response, nil
+}
+
+func anyDevice(ctx context.Context, devices []*device.Instance, pred event.Predicate) bool {
+	for _, d := range devices {
+		if pred(ctx, d) {
+			return true
+		}
+	}
+	return false
+}
+
 // Orbit implements Master.Orbit
 // It will start orbiting the master, and will not return until it leaves orbit.
 func (m *local) Orbit(ctx context.Context, services ServiceList, commands CommandHandler) error {
 	sat := m.addSatellite(ctx, services)
 	defer m.removeSatellite(ctx, sat)
 	crash.Go(func() {
-		sat.sendCommand(ctx, &Command{Do: &Command_Identify{Identify: &Identify{Name: sat.info.Name}}})
+		sat.sendCommand(ctx, &Command{Do: &Command_Identify{Identify: &Identify{Name: sat.name}}})
 	})
-	sat.processCommands(ctx, commands)
+	if !sat.processCommands(ctx, commands) {
+		return log.Errf(ctx, nil, "Satellite %s missed its heartbeats", sat.name)
+	}
 	return nil
 }
 
+// Heartbeat implements Master.Heartbeat
+// It records that the named satellite is still alive.
+func (m *local) Heartbeat(ctx context.Context, request *HeartbeatRequest) (*HeartbeatResponse, error) {
+	m.satelliteLock.Lock()
+	defer m.satelliteLock.Unlock()
+	for _, sat := range m.satellites {
+		if sat.name == request.Name {
+			sat.lastSeen = time.Now()
+			// Replace rather than modify the satellite info, as it may be in
+			// use by searches.
+			old := sat.info.Services
+			sat.info = &Satellite{
+				Name: sat.name,
+				Services: &ServiceList{
+					Master:  old.Master,
+					Worker:  old.Worker,
+					Web:     old.Web,
+					Devices: request.Devices,
+				},
+			}
+			m.onChange.Send(ctx, sat.info)
+			return &HeartbeatResponse{}, nil
+		}
+	}
+	return nil, ErrUnknownSatellite
+}
+
 // Shutdown implements Master.Shutdown
 // It broadcasts the shutdown message to all satellites currently orbiting the master.
 func (m *local) Shutdown(ctx context.Context, request *ShutdownRequest) (*ShutdownResponse, error) {
@@ -90,14 +185,28 @@ func (m *local) getSatellites() []*satellite {
 	return append([]*satellite(nil), m.satellites...)
 }
 
-func (m *local) producer(ctx context.Context) event.Producer {
-	i := 0
+// producer returns a producer of the info of the active satellites. The info
+// is copied from the satellite list on the first call, which takes the
+// satelliteLock unless held is true, in which case the caller must hold it.
+func (m *local) producer(held bool) event.Producer {
+	var infos []*Satellite
+	started := false
 	return func(ctx context.Context) interface{} {
-		if i >= len(m.satellites) {
+		if !started {
+			started = true
+			if !held {
+				m.satelliteLock.Lock()
+				defer m.satelliteLock.Unlock()
+			}
+			for _, sat := range m.satellites {
+				infos = append(infos, sat.info)
+			}
+		}
+		if len(infos) == 0 {
 			return nil
 		}
-		res := m.satellites[i]
-		i++
+		res := infos[0]
+		infos = infos[1:]
 		return res
 	}
 }
@@ -118,22 +227,46 @@ func (m *local) addSatellite(ctx context.Context, services ServiceList) *satelli
 	m.nextID++
 	sat := newSatellite(ctx, name, services)
 	m.satellites = append(m.satellites, sat)
+	log.I(ctx, "Satellite %s is online", name)
+	m.onChange.Send(ctx, sat.info)
 	return sat
 }
 
 func (m *local) removeSatellite(ctx context.Context, sat *satellite) error {
+	sat.setOffline()
 	m.satelliteLock.Lock()
 	defer m.satelliteLock.Unlock()
 	// find and remove the satellite from the list
 	for i, e := range m.satellites {
 		if e == sat {
 			m.satellites = append(m.satellites[:i], m.satellites[i+1:]...)
+			log.I(ctx, "Satellite %s left orbit", sat.name)
+			m.onChange.Send(ctx, sat.offlineInfo())
 			break
 		}
 	}
 	return nil
 }
 
+// dropSilentSatellites marks the satellites that have not sent a heartbeat
+// within the heartbeat timeout as offline, removes them from the list and
+// reports them to the monitors.
+func (m *local) dropSilentSatellites(ctx context.Context) {
+	m.satelliteLock.Lock()
+	defer m.satelliteLock.Unlock()
+	live := make([]*satellite, 0, len(m.satellites))
+	for _, sat := range m.satellites {
+		if silent := time.Since(sat.lastSeen); silent > m.heartbeatTimeout {
+			log.W(ctx, "Satellite %s is offline, no heartbeat for %v", sat.name, silent)
+			sat.setOffline()
+			m.onChange.Send(ctx, sat.offlineInfo())
+			continue
+		}
+		live = append(live, sat)
+	}
+	m.satellites = live
+}
+
 func serverInList(name string, servers []string) bool {
 	if len(servers) == 0 {
 		return true
@@ -149,7 +282,7 @@ func serverInList(name string, servers []string) bool {
 func (m *local) broadcast(ctx context.Context, command *Command, to []string) error {
 	// First send the command to all the registered services that match the to list
 	for _, sat := range m.getSatellites() {
-		if !serverInList(sat.info.Name, to) {
+		if !serverInList(sat.name, to) {
 			continue
 		}
 		sat.sendCommand(ctx, command)
@@ -161,6 +294,7 @@ func (m *local) run(ctx context.Context) {
 	ping := &Command{Do: &Command_Ping{Ping: &Ping{}}}
 	// each time a tick happens
 	for _ = range m.keepalive.C {
+		m.dropSilentSatellites(ctx)
 		// ping all the satellites to see if they are still up
 		for _, sat := range m.getSatellites() {
 			sat.sendCommand(ctx, ping)
@@ -0,0 +1,142 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/test/robot/search"
+)
+
+// monitor starts a monitoring search of the satellites of m, and returns the
+// channel that the reported satellites are sent to.
+func monitor(ctx context.Context, m Master) <-chan *Satellite {
+	found := make(chan *Satellite, 1024)
+	crash.Go(func() {
+		m.Search(ctx, &search.Query{Monitor: true}, func(ctx context.Context, sat *Satellite) error {
+			found <- sat
+			return nil
+		})
+	})
+	return found
+}
+
+// orbit starts a worker satellite orbiting m, and returns the channel that
+// the result of the orbit is sent to.
+func orbit(ctx context.Context, m Master) <-chan error {
+	done := make(chan error, 1)
+	crash.Go(func() {
+		done <- m.Orbit(ctx, ServiceList{Worker: true}, func(context.Context, *Command) error { return nil })
+	})
+	return done
+}
+
+// next returns the next satellite reported to found.
+func next(t *testing.T, found <-chan *Satellite) *Satellite {
+	select {
+	case sat := <-found:
+		return sat
+	case <-time.After(10 * time.Second):
+		t.Fatal("Satellite was not reported")
+		return nil
+	}
+}
+
+func TestDefaultHeartbeatTimeout(t *testing.T) {
+	ctx := log.Testing(t)
+	m := NewLocal(ctx, 0).(*local)
+	defer m.Close(ctx)
+	assert.For(ctx, "timeout").That(m.heartbeatTimeout).Equals(DefaultHeartbeatTimeout)
+}
+
+func TestHeartbeat(t *testing.T) {
+	ctx, cancel := task.WithCancel(log.Testing(t))
+	defer cancel()
+	m := NewLocal(ctx, time.Hour)
+	defer m.Close(ctx)
+	found := monitor(ctx, m)
+	orbit(ctx, m)
+
+	sat := next(t, found)
+	assert.For(ctx, "name").That(sat.Name).Equals("Worker_0")
+	assert.For(ctx, "devices").That(len(sat.Services.Devices)).Equals(0)
+
+	// Search the satellites while heartbeats replace their services.
+	done := make(chan struct{})
+	crash.Go(func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			m.Search(ctx, &search.Query{}, func(ctx context.Context, sat *Satellite) error {
+				_ = sat.Services.Devices
+				return nil
+			})
+		}
+	})
+	devices := []*device.Instance{{Name: "phone"}}
+	for i := 0; i < 100; i++ {
+		_, err := m.Heartbeat(ctx, &HeartbeatRequest{Name: "Worker_0", Devices: devices})
+		assert.For(ctx, "heartbeat").ThatError(err).Succeeded()
+	}
+	<-done
+
+	// Each heartbeat publishes the new services.
+	sat = next(t, found)
+	assert.For(ctx, "name").That(sat.Name).Equals("Worker_0")
+	assert.For(ctx, "devices").That(sat.Services.Devices).DeepEquals(devices)
+	assert.For(ctx, "offline").That(sat.Offline).Equals(false)
+
+	res, err := m.FindSatellites(ctx, &FindSatellitesRequest{Capability: Capability_WorkerCapability})
+	if assert.For(ctx, "FindSatellites").ThatError(err).Succeeded() &&
+		assert.For(ctx, "satellites").That(len(res.Satellites)).Equals(1) {
+		assert.For(ctx, "devices").That(res.Satellites[0].Services.Devices).DeepEquals(devices)
+	}
+
+	_, err = m.Heartbeat(ctx, &HeartbeatRequest{Name: "Worker_1"})
+	assert.For(ctx, "unknown").ThatError(err).Equals(ErrUnknownSatellite)
+}
+
+func TestDropSilentSatellites(t *testing.T) {
+	ctx, cancel := task.WithCancel(log.Testing(t))
+	defer cancel()
+	m := NewLocal(ctx, 10*time.Millisecond).(*local)
+	defer m.Close(ctx)
+	found := monitor(ctx, m)
+	done := orbit(ctx, m)
+	assert.For(ctx, "online").That(next(t, found).Offline).Equals(false)
+
+	time.Sleep(20 * time.Millisecond)
+	m.dropSilentSatellites(ctx)
+
+	sat := next(t, found)
+	assert.For(ctx, "name").That(sat.Name).Equals("Worker_0")
+	assert.For(ctx, "offline").That(sat.Offline).Equals(true)
+	select {
+	case err := <-done:
+		assert.For(ctx, "orbit").ThatError(err).Failed()
+	case <-time.After(10 * time.Second):
+		t.Fatal("Dropped satellite did not leave orbit")
+	}
+	assert.For(ctx, "satellites").That(len(m.getSatellites())).Equals(0)
+
+	_, err := m.Heartbeat(ctx, &HeartbeatRequest{Name: "Worker_0"})
+	assert.For(ctx, "heartbeat").ThatError(err).Equals(ErrUnknownSatellite)
+}
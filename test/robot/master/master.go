@@ -20,6 +20,13 @@ import (
 	"github.com/google/gapid/test/robot/search"
 )
 
+const (
+	AnyCapability    = Capability_AnyCapability
+	MasterCapability = Capability_MasterCapability
+	WorkerCapability = Capability_WorkerCapability
+	WebCapability    = Capability_WebCapability
+)
+
 type SatelliteHandler func(context.Context, *Satellite) error
 type CommandHandler func(context.Context, *Command) error
 
@@ -28,9 +35,29 @@ type CommandHandler func(context.Context, *Command) error
 type Master interface {
 	// Search returns a iterator of matching satellites from the store.
 	Search(context.Context, *search.Query, SatelliteHandler) error
+	// FindSatellites returns the satellites that offer a service on devices that match a filter.
+	FindSatellites(context.Context, *FindSatellitesRequest) (*FindSatellitesResponse, error)
 	// Orbit adds a satellite to the set being managed by the master.
 	// The master will use the returned command stream to control the satellite.
 	Orbit(context.Context, ServiceList, CommandHandler) error
 	// Shutdown is called to ask the master to send shutdown requests to satellites.
 	Shutdown(context.Context, *ShutdownRequest) (*ShutdownResponse, error)
+	// Heartbeat is called by orbiting satellites to tell the master they are still alive.
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+}
+
+// Offers returns true if the service list includes the capability.
+func (s *ServiceList) Offers(c Capability) bool {
+	switch c {
+	case AnyCapability:
+		return true
+	case MasterCapability:
+		return s.GetMaster()
+	case WorkerCapability:
+		return s.GetWorker()
+	case WebCapability:
+		return s.GetWeb()
+	default:
+		return false
+	}
 }
@@ -44,6 +44,12 @@ func (m *remote) Search(ctx context.Context, query *search.Query, handler Satell
 	return event.Feed(ctx, event.AsHandler(ctx, handler), grpcutil.ToProducer(stream))
 }
 
+// FindSatellites implements Master.FindSatellites
+// It forwards the call through grpc to the remote implementation.
+func (m *remote) FindSatellites(ctx context.Context, request *FindSatellitesRequest) (*FindSatellitesResponse, error) {
+	return m.client.FindSatellites(ctx, request)
+}
+
 // Orbit implements Master.Orbit
 // It forwards the call through grpc to the remote implementation.
 func (m *remote) Orbit(ctx context.Context, services ServiceList, handler CommandHandler) error {
@@ -60,3 +66,9 @@ func (m *remote) Orbit(ctx context.Context, services ServiceList, handler Comman
 func (m *remote) Shutdown(ctx context.Context, request *ShutdownRequest) (*ShutdownResponse, error) {
 	return m.client.Shutdown(ctx, request)
 }
+
+// Heartbeat implements Master.Heartbeat
+// It forwards the call through grpc to the remote implementation.
+func (m *remote) Heartbeat(ctx context.Context, request *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return m.client.Heartbeat(ctx, request)
+}
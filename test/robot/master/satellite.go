@@ -17,14 +17,19 @@ package master
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/google/gapid/core/event/task"
 )
 
 type satellite struct {
-	lock   sync.Mutex
-	info   *Satellite
-	issues chan issue
+	lock     sync.Mutex
+	name     string
+	info     *Satellite // guarded by the master's satelliteLock, replaced rather than modified
+	issues   chan issue
+	lastSeen time.Time     // guarded by the master's satelliteLock
+	offline  chan struct{} // closed when the satellite missed its heartbeats
+	once     sync.Once
 }
 
 // issue is used to package a command with a channel to feed the result of
@@ -36,21 +41,38 @@ type issue struct {
 
 func newSatellite(ctx context.Context, name string, services ServiceList) *satellite {
 	return &satellite{
+		name: name,
 		info: &Satellite{
 			Name:     name,
 			Services: &services,
 		},
-		issues: make(chan issue),
+		issues:   make(chan issue),
+		lastSeen: time.Now(),
+		offline:  make(chan struct{}),
 	}
 }
 
+// offlineInfo returns the info of the satellite, marked as offline. It must be
+// called with the master's satelliteLock held.
+func (sat *satellite) offlineInfo() *Satellite {
+	return &Satellite{Name: sat.name, Services: sat.info.Services, Offline: true}
+}
+
+// setOffline stops the satellite from processing any further commands.
+func (sat *satellite) setOffline() {
+	sat.once.Do(func() { close(sat.offline) })
+}
+
 // processCommands reads the issues from the channel and hands them to the command handler, sending
 // the result back through the issue channel.
-func (sat *satellite) processCommands(ctx context.Context, handler CommandHandler) {
+// It returns false if it stopped because the satellite went offline.
+func (sat *satellite) processCommands(ctx context.Context, handler CommandHandler) bool {
 	for {
 		select {
 		case <-task.ShouldStop(ctx):
-			return
+			return true
+		case <-sat.offline:
+			return false
 		case i := <-sat.issues:
 			i.result <- handler(ctx, i.command)
 			close(i.result)
@@ -61,11 +83,19 @@ func (sat *satellite) processCommands(ctx context.Context, handler CommandHandle
 // sendCommand posts an issue for the command into the channel, then blocks until it gets a result.
 func (sat *satellite) sendCommand(ctx context.Context, command *Command) {
 	result := make(chan error)
+	sent := false
 	sat.lock.Lock()
 	if sat.issues != nil {
-		sat.issues <- issue{command: command, result: result}
+		select {
+		case sat.issues <- issue{command: command, result: result}:
+			sent = true
+		case <-sat.offline:
+		}
 	}
 	sat.lock.Unlock()
+	if !sent {
+		return
+	}
 	err := <-result
 	if err != nil {
 		sat.lock.Lock()
@@ -43,6 +43,12 @@ func (s *server) Search(query *search.Query, stream Service_SearchServer) error
 	return s.master.Search(ctx, query, func(ctx context.Context, e *Satellite) error { return stream.Send(e) })
 }
 
+// FindSatellites implements ServiceServer.FindSatellites
+// It delegates the call to the provided Master implementation.
+func (s *server) FindSatellites(ctx xctx.Context, request *FindSatellitesRequest) (*FindSatellitesResponse, error) {
+	return s.master.FindSatellites(ctx, request)
+}
+
 // Orbit implements ServiceServer.Orbit
 // It delegates the call to the provided Master implementation.
 func (s *server) Orbit(request *OrbitRequest, stream Service_OrbitServer) error {
@@ -52,6 +58,12 @@ func (s *server) Orbit(request *OrbitRequest, stream Service_OrbitServer) error
 	)
 }
 
+// Heartbeat implements ServiceServer.Heartbeat
+// It delegates the call to the provided Master implementation.
+func (s *server) Heartbeat(ctx xctx.Context, request *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return s.master.Heartbeat(ctx, request)
+}
+
 // Shutdown implements ServiceServer.Shutdown
 // It delegates the call to the provided Master implementation.
 func (s *server) Shutdown(ctx xctx.Context, request *ShutdownRequest) (*ShutdownResponse, error) {
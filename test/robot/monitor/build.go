@@ -101,10 +101,13 @@ func (o *DataOwner) updateTrack(ctx context.Context, track *build.Track) error {
 		for i, e := range data.Tracks.entries {
 			if track.Id == e.Id {
 				data.Tracks.entries[i].Track = *track
+				data.notify(EntityUpdated, data.Tracks.entries[i])
 				return
 			}
 		}
-		data.Tracks.entries = append(data.Tracks.entries, &Track{Track: *track})
+		entry := &Track{Track: *track}
+		data.Tracks.entries = append(data.Tracks.entries, entry)
+		data.notify(EntityAdded, entry)
 	})
 	return nil
 }
@@ -114,10 +117,13 @@ func (o *DataOwner) updatePackage(ctx context.Context, pkg *build.Package) error
 		for i, e := range data.Packages.entries {
 			if pkg.Id == e.Id {
 				data.Packages.entries[i].Package = *pkg
+				data.notify(EntityUpdated, data.Packages.entries[i])
 				return
 			}
 		}
-		data.Packages.entries = append(data.Packages.entries, &Package{Package: *pkg})
+		entry := &Package{Package: *pkg}
+		data.Packages.entries = append(data.Packages.entries, entry)
+		data.notify(EntityAdded, entry)
 	})
 	return nil
 }
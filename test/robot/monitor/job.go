@@ -60,10 +60,13 @@ func (o *DataOwner) updateDevice(ctx context.Context, device *job.Device) error
 		for i, e := range data.Devices.entries {
 			if device.Id == e.Id {
 				data.Devices.entries[i].Device = *device
+				data.notify(EntityUpdated, data.Devices.entries[i])
 				return
 			}
 		}
-		data.Devices.entries = append(data.Devices.entries, &Device{Device: *device})
+		entry := &Device{Device: *device}
+		data.Devices.entries = append(data.Devices.entries, entry)
+		data.notify(EntityAdded, entry)
 	})
 	return nil
 }
@@ -78,10 +81,13 @@ func (o *DataOwner) updateWorker(ctx context.Context, worker *job.Worker) error
 		for i, e := range data.Workers.entries {
 			if worker.Host == e.Host && worker.Target == e.Target {
 				data.Workers.entries[i].Worker = *worker
+				data.notify(EntityUpdated, data.Workers.entries[i])
 				return
 			}
 		}
-		data.Workers.entries = append(data.Workers.entries, &Worker{Worker: *worker})
+		entry := &Worker{Worker: *worker}
+		data.Workers.entries = append(data.Workers.entries, entry)
+		data.notify(EntityAdded, entry)
 	})
 	return nil
 }
@@ -59,6 +59,11 @@ type Data struct {
 	Traces   Traces
 	Reports  Reports
 	Replays  Replays
+
+	// subscribersMu guards subscribers, so that subscribing does not wait for
+	// the data lock, which Run holds while it is not waiting for new data.
+	subscribersMu sync.Mutex
+	subscribers   []*subscriber
 }
 
 type DataOwner struct {
@@ -38,8 +38,13 @@ func (r *Replays) All() []*Replay {
 
 func (o *DataOwner) updateReplay(ctx context.Context, action *replay.Action) error {
 	o.Write(func(data *Data) {
-		entry, _ := data.Replays.FindOrCreate(ctx, action)
+		entry, found := data.Replays.FindOrCreate(ctx, action)
 		entry.Action = *action
+		if found {
+			data.notify(EntityUpdated, entry)
+		} else {
+			data.notify(EntityAdded, entry)
+		}
 	})
 	return nil
 }
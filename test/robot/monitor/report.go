@@ -38,8 +38,13 @@ func (r *Reports) All() []*Report {
 
 func (o *DataOwner) updateReport(ctx context.Context, action *report.Action) error {
 	o.Write(func(data *Data) {
-		entry, _ := data.Reports.FindOrCreate(ctx, action)
+		entry, found := data.Reports.FindOrCreate(ctx, action)
 		entry.Action = *action
+		if found {
+			data.notify(EntityUpdated, entry)
+		} else {
+			data.notify(EntityAdded, entry)
+		}
 	})
 	return nil
 }
@@ -0,0 +1,132 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/file"
+)
+
+// Save writes the entries held by the owner to the file at path.
+// The snapshot is written to a temporary file that is then renamed over path,
+// so that path holds either the previous or the new snapshot, and never a
+// partially written one.
+func (o DataOwner) Save(ctx context.Context, path file.Path) error {
+	var buf []byte
+	err := error(nil)
+	o.Read(func(data *Data) {
+		// Marshal under the lock, as updates overwrite the entries in place.
+		buf, err = proto.Marshal(data.snapshot())
+	})
+	if err != nil {
+		return log.Errf(ctx, err, "Encoding monitor snapshot")
+	}
+
+	tmp, err := ioutil.TempFile(path.Parent().System(), path.Basename()+".tmp")
+	if err != nil {
+		return log.Errf(ctx, err, "Creating monitor snapshot %v", path)
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed.
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return log.Errf(ctx, err, "Writing monitor snapshot %v", path)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return log.Errf(ctx, err, "Writing monitor snapshot %v", path)
+	}
+	if err := tmp.Close(); err != nil {
+		return log.Errf(ctx, err, "Writing monitor snapshot %v", path)
+	}
+	if err := os.Rename(tmp.Name(), path.System()); err != nil {
+		return log.Errf(ctx, err, "Replacing monitor snapshot %v", path)
+	}
+	return nil
+}
+
+// snapshot returns a Snapshot holding all the entries in data.
+// The entries are shared with data, so it must be used under the data lock.
+func (data *Data) snapshot() *Snapshot {
+	s := &Snapshot{}
+	for _, e := range data.Devices.entries {
+		s.Devices = append(s.Devices, &e.Device)
+	}
+	for _, e := range data.Workers.entries {
+		s.Workers = append(s.Workers, &e.Worker)
+	}
+	for _, e := range data.Subjects.entries {
+		s.Subjects = append(s.Subjects, &e.Subject)
+	}
+	for _, e := range data.Tracks.entries {
+		s.Tracks = append(s.Tracks, &e.Track)
+	}
+	for _, e := range data.Packages.entries {
+		s.Packages = append(s.Packages, &e.Package)
+	}
+	for _, e := range data.Traces.entries {
+		s.Traces = append(s.Traces, &e.Action)
+	}
+	for _, e := range data.Reports.entries {
+		s.Reports = append(s.Reports, &e.Action)
+	}
+	for _, e := range data.Replays.entries {
+		s.Replays = append(s.Replays, &e.Action)
+	}
+	return s
+}
+
+// Load reads the snapshot written by Save from the file at path, and adds its
+// entries to the owner exactly as if they had just been received from the
+// managers. Entries later received from the managers replace loaded ones.
+func (o DataOwner) Load(ctx context.Context, path file.Path) error {
+	buf, err := ioutil.ReadFile(path.System())
+	if err != nil {
+		return log.Errf(ctx, err, "Reading monitor snapshot %v", path)
+	}
+	s := &Snapshot{}
+	if err := proto.Unmarshal(buf, s); err != nil {
+		return log.Errf(ctx, err, "Decoding monitor snapshot %v", path)
+	}
+	for _, e := range s.Devices {
+		o.updateDevice(ctx, e)
+	}
+	for _, e := range s.Workers {
+		o.updateWorker(ctx, e)
+	}
+	for _, e := range s.Subjects {
+		o.updateSubject(ctx, e)
+	}
+	for _, e := range s.Tracks {
+		o.updateTrack(ctx, e)
+	}
+	for _, e := range s.Packages {
+		o.updatePackage(ctx, e)
+	}
+	for _, e := range s.Traces {
+		o.updateTrace(ctx, e)
+	}
+	for _, e := range s.Reports {
+		o.updateReport(ctx, e)
+	}
+	for _, e := range s.Replays {
+		o.updateReplay(ctx, e)
+	}
+	return nil
+}
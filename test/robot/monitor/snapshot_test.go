@@ -0,0 +1,97 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/file"
+	"github.com/google/gapid/test/robot/build"
+	"github.com/google/gapid/test/robot/job"
+	"github.com/google/gapid/test/robot/replay"
+	"github.com/google/gapid/test/robot/report"
+	"github.com/google/gapid/test/robot/subject"
+	"github.com/google/gapid/test/robot/trace"
+)
+
+func snapshotOf(o DataOwner) *Snapshot {
+	var s *Snapshot
+	o.Read(func(data *Data) { s = proto.Clone(data.snapshot()).(*Snapshot) })
+	return s
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	ctx := log.Testing(t)
+	dir, err := ioutil.TempDir("", "monitor-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := file.Abs(dir).Join("monitor.snapshot")
+
+	owner := NewDataOwner()
+	owner.updateDevice(ctx, &job.Device{Id: "device-a"})
+	owner.updateDevice(ctx, &job.Device{Id: "device-b"})
+	owner.updateWorker(ctx, &job.Worker{Host: "device-a", Target: "device-b", Operation: []job.Operation{job.Trace}})
+	owner.updateSubject(ctx, &subject.Subject{Id: "subject", Obb: "obb"})
+	owner.updateTrack(ctx, &build.Track{Id: "track", Name: "master", Head: "package-b"})
+	owner.updatePackage(ctx, &build.Package{Id: "package-a"})
+	owner.updatePackage(ctx, &build.Package{Id: "package-b", Parent: "package-a"})
+	owner.updateTrace(ctx, &trace.Action{Id: "trace", Input: &trace.Input{Subject: "subject"}, Host: "device-a"})
+	owner.updateReport(ctx, &report.Action{Id: "report", Input: &report.Input{Trace: "trace"}, Host: "device-a"})
+	owner.updateReplay(ctx, &replay.Action{Id: "replay", Input: &replay.Input{Trace: "trace"}, Host: "device-a"})
+	expected := snapshotOf(owner)
+
+	assert.For(ctx, "Save").ThatError(owner.Save(ctx, path)).Succeeded()
+	loaded := NewDataOwner()
+	assert.For(ctx, "Load").ThatError(loaded.Load(ctx, path)).Succeeded()
+	got := snapshotOf(loaded)
+	assert.For(ctx, "round trip").That(proto.Equal(got, expected)).Equals(true)
+	assert.For(ctx, "devices").That(len(got.Devices)).Equals(2)
+	assert.For(ctx, "packages").That(len(got.Packages)).Equals(2)
+
+	// Saving again replaces the snapshot, and leaves no temporary files behind.
+	owner.updateDevice(ctx, &job.Device{Id: "device-c"})
+	assert.For(ctx, "Save").ThatError(owner.Save(ctx, path)).Succeeded()
+	loaded = NewDataOwner()
+	assert.For(ctx, "Load").ThatError(loaded.Load(ctx, path)).Succeeded()
+	assert.For(ctx, "devices").That(len(snapshotOf(loaded).Devices)).Equals(3)
+	files, err := ioutil.ReadDir(dir)
+	if assert.For(ctx, "ReadDir").ThatError(err).Succeeded() {
+		assert.For(ctx, "files").That(len(files)).Equals(1)
+	}
+}
+
+func TestSnapshotLoadErrors(t *testing.T) {
+	ctx := log.Testing(t)
+	dir, err := ioutil.TempDir("", "monitor-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := file.Abs(dir).Join("monitor.snapshot")
+
+	owner := NewDataOwner()
+	assert.For(ctx, "missing").ThatError(owner.Load(ctx, path)).Failed()
+	if err := ioutil.WriteFile(path.System(), []byte("not a snapshot"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	assert.For(ctx, "corrupt").ThatError(owner.Load(ctx, path)).Failed()
+}
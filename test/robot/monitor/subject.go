@@ -51,10 +51,13 @@ func (o *DataOwner) updateSubject(ctx context.Context, subj *subject.Subject) er
 		for i, e := range data.Subjects.entries {
 			if subj.Id == e.Id {
 				data.Subjects.entries[i].Subject = *subj
+				data.notify(EntityUpdated, data.Subjects.entries[i])
 				return
 			}
 		}
-		data.Subjects.entries = append(data.Subjects.entries, &Subject{Subject: *subj})
+		entry := &Subject{Subject: *subj}
+		data.Subjects.entries = append(data.Subjects.entries, entry)
+		data.notify(EntityAdded, entry)
 	})
 	return nil
 }
@@ -0,0 +1,146 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/gapid/core/app/crash"
+)
+
+// EventKind is the kind of change an Event reports.
+type EventKind int
+
+const (
+	// EntityAdded is reported when a new entity is seen.
+	EntityAdded EventKind = iota
+	// EntityUpdated is reported when a new version of an entity is seen.
+	EntityUpdated
+	// EntityRemoved is reported when an entity is dropped.
+	// None of the monitored services remove entities, so the data owner never
+	// drops them and this is not reported yet.
+	EntityRemoved
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EntityAdded:
+		return "Added"
+	case EntityUpdated:
+		return "Updated"
+	case EntityRemoved:
+		return "Removed"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is a change to one of the entities tracked by Data.
+type Event struct {
+	// Kind is the kind of change.
+	Kind EventKind
+	// Entity is the monitor's own wrapper for the entity, such as a *Trace or a
+	// *Device. It is a handle to the live entry, so its members must only be
+	// accessed from within DataOwner.Read, and are not resolved until they are.
+	Entity interface{}
+}
+
+// Filter is the type for a function that selects the events a subscriber
+// receives.
+// Filters are invoked with the data lock held, so they may inspect the entity
+// but must not call DataOwner.Read or DataOwner.Write.
+type Filter func(Event) bool
+
+// subscriber is a registered Subscribe call.
+type subscriber struct {
+	filter  Filter
+	mu      sync.Mutex
+	pending []Event
+	wake    chan struct{}
+}
+
+// Subscribe returns a channel that receives an event each time an entity that
+// matches filter is added or updated. Entities are never removed, see
+// EntityRemoved. A nil filter matches all events.
+// Events are queued, rather than dropped, while the receiver is busy.
+// The channel is closed once ctx is done.
+// Subscribe does not wait for the data lock, so it may be called while the
+// data is being read or written.
+func (o DataOwner) Subscribe(ctx context.Context, filter Filter) <-chan Event {
+	s := &subscriber{
+		filter: filter,
+		wake:   make(chan struct{}, 1),
+	}
+	o.data.subscribersMu.Lock()
+	o.data.subscribers = append(o.data.subscribers, s)
+	o.data.subscribersMu.Unlock()
+
+	out := make(chan Event)
+	crash.Go(func() {
+		defer close(out)
+		defer o.unsubscribe(s)
+		for {
+			select {
+			case <-s.wake:
+			case <-ctx.Done():
+				return
+			}
+			s.mu.Lock()
+			events := s.pending
+			s.pending = nil
+			s.mu.Unlock()
+			for _, e := range events {
+				select {
+				case out <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+	return out
+}
+
+func (o DataOwner) unsubscribe(s *subscriber) {
+	o.data.subscribersMu.Lock()
+	defer o.data.subscribersMu.Unlock()
+	for i, e := range o.data.subscribers {
+		if e == s {
+			o.data.subscribers = append(o.data.subscribers[:i], o.data.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// notify queues an event for all the matching subscribers.
+// It must be called with the data lock held, and does not block.
+func (data *Data) notify(kind EventKind, entity interface{}) {
+	e := Event{Kind: kind, Entity: entity}
+	data.subscribersMu.Lock()
+	defer data.subscribersMu.Unlock()
+	for _, s := range data.subscribers {
+		if s.filter != nil && !s.filter(e) {
+			continue
+		}
+		s.mu.Lock()
+		s.pending = append(s.pending, e)
+		s.mu.Unlock()
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+)
+
+// next returns the next event received from events, failing the test if none
+// arrives in time.
+func next(t *testing.T, events <-chan Event) Event {
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	owner := NewDataOwner()
+	all := owner.Subscribe(ctx, nil)
+	updates := owner.Subscribe(ctx, func(e Event) bool { return e.Kind == EntityUpdated })
+
+	// Events are queued while nothing is receiving them.
+	owner.Write(func(data *Data) {
+		data.notify(EntityAdded, "a")
+		data.notify(EntityUpdated, "a")
+		data.notify(EntityAdded, "b")
+	})
+
+	assert.For(ctx, "all").That(next(t, all)).Equals(Event{EntityAdded, "a"})
+	assert.For(ctx, "all").That(next(t, all)).Equals(Event{EntityUpdated, "a"})
+	assert.For(ctx, "all").That(next(t, all)).Equals(Event{EntityAdded, "b"})
+	assert.For(ctx, "updates").That(next(t, updates)).Equals(Event{EntityUpdated, "a"})
+	select {
+	case e := <-updates:
+		t.Errorf("Unexpected event %v", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestSubscribeWhileLocked(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	owner := NewDataOwner()
+
+	// Run holds the data lock while it is not waiting for new data.
+	subscribed := make(chan (<-chan Event))
+	owner.Read(func(data *Data) {
+		go func() { subscribed <- owner.Subscribe(ctx, nil) }()
+		select {
+		case events := <-subscribed:
+			data.notify(EntityAdded, "a")
+			assert.For(ctx, "event").That(next(t, events)).Equals(Event{EntityAdded, "a"})
+		case <-time.After(5 * time.Second):
+			t.Error("Subscribe blocked on the data lock")
+		}
+	})
+}
+
+func TestUnsubscribe(t *testing.T) {
+	ctx := log.Testing(t)
+	owner := NewDataOwner()
+	sub, cancel := context.WithCancel(ctx)
+	events := owner.Subscribe(sub, nil)
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		assert.For(ctx, "open").That(ok).Equals(false)
+	case <-time.After(5 * time.Second):
+		t.Fatal("The channel was not closed")
+	}
+	for end := time.Now().Add(5 * time.Second); time.Now().Before(end); time.Sleep(time.Millisecond) {
+		owner.data.subscribersMu.Lock()
+		count := len(owner.data.subscribers)
+		owner.data.subscribersMu.Unlock()
+		if count == 0 {
+			return
+		}
+	}
+	t.Error("The subscriber was not removed")
+}
@@ -49,8 +49,13 @@ func (t *Traces) MatchPackage(p *Package) []*Trace {
 
 func (o *DataOwner) updateTrace(ctx context.Context, action *trace.Action) error {
 	o.Write(func(data *Data) {
-		entry, _ := data.Traces.FindOrCreate(ctx, action)
+		entry, found := data.Traces.FindOrCreate(ctx, action)
 		entry.Action = *action
+		if found {
+			data.notify(EntityUpdated, entry)
+		} else {
+			data.notify(EntityAdded, entry)
+		}
 	})
 	return nil
 }
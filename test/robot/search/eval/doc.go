@@ -15,4 +15,5 @@
 // Package eval supplies logic for automatically applying a search query to
 // a set of records.
 // The main entry point is eval.Compile, that builds and returns a Matcher.
+// eval.Select additionally applies the ordering and limit of the query.
 package eval
@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"time"
 
+	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/google/gapid/core/event"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/test/robot/search"
@@ -33,6 +35,7 @@ var (
 	unsignedType = reflect.TypeOf(uint64(0))
 	doubleType   = reflect.TypeOf(float64(0))
 	stringType   = reflect.TypeOf("")
+	durationType = reflect.TypeOf((*duration.Duration)(nil))
 )
 
 // Compile takes a search query and a a value type and produces a function that will perform the
@@ -165,11 +168,11 @@ func compileBinaryNumeric(ctx context.Context, expr *search.Binary, t reflect.Ty
 	testU func(uint64, uint64) bool,
 	testD func(float64, float64) bool,
 ) (eval, reflect.Type, error) {
-	lhs, lt, err := compileExpression(ctx, expr.Lhs, t)
+	lhs, lt, err := compileNumeric(ctx, expr.Lhs, t)
 	if err != nil {
 		return nil, boolType, err
 	}
-	rhs, rt, err := compileExpression(ctx, expr.Rhs, t)
+	rhs, rt, err := compileNumeric(ctx, expr.Rhs, t)
 	if err != nil {
 		return nil, boolType, err
 	}
@@ -188,9 +191,67 @@ func compileBinaryNumeric(ctx context.Context, expr *search.Binary, t reflect.Ty
 			return testD(lhs(ctx, value).(float64), rhs(ctx, value).(float64))
 		}, boolType, nil
 	}
+	if isNumeric(lt) && isNumeric(rt) {
+		// Mixed numeric types, such as an unsigned field and a signed literal,
+		// are compared as doubles.
+		return func(ctx context.Context, value interface{}) interface{} {
+			return testD(toDouble(lhs(ctx, value)), toDouble(rhs(ctx, value)))
+		}, boolType, nil
+	}
 	return nil, boolType, log.Errf(ctx, nil, "no numeric comparison possible (%v with %v)", lt, rt)
 }
 
+func isNumeric(t reflect.Type) bool {
+	return t == signedType || t == unsignedType || t == doubleType
+}
+
+func toDouble(v interface{}) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	default:
+		return v.(float64)
+	}
+}
+
+// compileNumeric compiles an expression for a numeric comparison.
+// Values of named numeric types, such as time.Duration, are converted to the
+// matching literal type, and protobuf durations are converted to signed
+// nanoseconds.
+func compileNumeric(ctx context.Context, expr *search.Expression, t reflect.Type) (eval, reflect.Type, error) {
+	e, et, err := compileExpression(ctx, expr, t)
+	if err != nil {
+		return nil, boolType, err
+	}
+	switch {
+	case et == signedType, et == unsignedType, et == doubleType:
+		return e, et, nil
+	case et == durationType:
+		return func(ctx context.Context, value interface{}) interface{} {
+			d := e(ctx, value).(*duration.Duration)
+			return d.GetSeconds()*int64(time.Second) + int64(d.GetNanos())
+		}, signedType, nil
+	}
+	switch et.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(ctx context.Context, value interface{}) interface{} {
+			return reflect.ValueOf(e(ctx, value)).Int()
+		}, signedType, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(ctx context.Context, value interface{}) interface{} {
+			return reflect.ValueOf(e(ctx, value)).Uint()
+		}, unsignedType, nil
+	case reflect.Float32, reflect.Float64:
+		return func(ctx context.Context, value interface{}) interface{} {
+			return reflect.ValueOf(e(ctx, value)).Float()
+		}, doubleType, nil
+	default:
+		return e, et, nil
+	}
+}
+
 func compileNot(ctx context.Context, expr *search.Expression, t reflect.Type) (eval, reflect.Type, error) {
 	rhs, res, err := compileExpression(ctx, expr, t)
 	if err != nil {
@@ -0,0 +1,257 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
+	"github.com/google/gapid/test/robot/search/script"
+)
+
+type entry struct {
+	Name string
+	Size int
+	Ok   bool
+}
+
+var (
+	entryClass = reflect.TypeOf(&entry{})
+	entries    = []*entry{
+		{"a", 3, true},
+		{"b", 5, false},
+		{"c", 1, true},
+		{"d", 5, true},
+		{"e", 2, false},
+	}
+)
+
+// selectNames returns the names of the entries selected by the query.
+func selectNames(ctx context.Context, query *search.Query) ([]string, error) {
+	i := 0
+	src := func(ctx context.Context) interface{} {
+		if i >= len(entries) {
+			return nil
+		}
+		i++
+		return entries[i-1]
+	}
+	selected, err := eval.Select(ctx, query, entryClass, src)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for v := selected(ctx); v != nil; v = selected(ctx) {
+		names = append(names, v.(*entry).Name)
+	}
+	return names, nil
+}
+
+func TestSelect(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		query    string
+		expected []string
+	}{
+		{"Ok", []string{"a", "c", "d"}},
+		{"Ok order by Size", []string{"c", "a", "d"}},
+		{"true order by Size desc, Name", []string{"b", "d", "a", "e", "c"}},
+		{"Ok order by Size desc limit 2", []string{"d", "a"}},
+		{"Size > 2 limit 2", []string{"a", "b"}},
+		{"order by Name desc", []string{"e", "d", "c", "b", "a"}},
+		{"order by Size limit 2", []string{"c", "e"}},
+		{"limit 3", []string{"a", "b", "c"}},
+		{"limit 0", []string{"a", "b", "c", "d", "e"}},
+	} {
+		ctx := log.V{"query": test.query}.Bind(ctx)
+		q, err := script.Parse(ctx, test.query)
+		if !assert.For(ctx, "Parse").ThatError(err).Succeeded() {
+			continue
+		}
+		names, err := selectNames(ctx, q.Query())
+		if assert.For(ctx, "Select").ThatError(err).Succeeded() {
+			assert.For(ctx, "names").That(names).DeepEquals(test.expected)
+		}
+	}
+}
+
+func TestPages(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		query    string
+		size     uint32
+		expected [][]string
+	}{
+		{"true", 2, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}},
+		{"order by Size", 2, [][]string{{"c", "e"}, {"a", "b"}, {"d"}}},
+		// A full page cannot tell it is the last, so an empty page follows.
+		{"order by Size", 5, [][]string{{"c", "e", "a", "b", "d"}, {}}},
+		{"Ok order by Size desc", 1, [][]string{{"d"}, {"a"}, {"c"}, {}}},
+		// The limit applies to all the pages together.
+		{"order by Size limit 3", 2, [][]string{{"c", "e"}, {"a"}}},
+		{"order by Size limit 4", 2, [][]string{{"c", "e"}, {"a", "b"}}},
+		{"limit 3", 1, [][]string{{"a"}, {"b"}, {"c"}}},
+	} {
+		ctx := log.V{"query": test.query, "size": test.size}.Bind(ctx)
+		q, err := script.Parse(ctx, test.query)
+		if !assert.For(ctx, "Parse").ThatError(err).Succeeded() {
+			continue
+		}
+		pages := [][]string{}
+		token := ""
+		for len(pages) <= len(test.expected) {
+			query := q.Page(token, test.size).Query()
+			names, err := selectNames(ctx, query)
+			if !assert.For(ctx, "Select").ThatError(err).Succeeded() {
+				break
+			}
+			pages = append(pages, names)
+			page := make([]*entry, len(names))
+			for i, n := range names {
+				page[i] = find(n)
+			}
+			token, err = eval.NextPageToken(ctx, query, entryClass, page)
+			if !assert.For(ctx, "NextPageToken").ThatError(err).Succeeded() || token == "" {
+				break
+			}
+		}
+		assert.For(ctx, "pages").That(pages).DeepEquals(test.expected)
+		assert.For(ctx, "final token").That(token).Equals("")
+	}
+}
+
+// find returns the named entry.
+func find(name string) *entry {
+	for _, e := range entries {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+type sample struct {
+	Signed   int32
+	Unsigned uint16
+	Double   float32
+	Time     time.Duration
+	Proto    *duration.Duration
+}
+
+func TestCompare(t *testing.T) {
+	ctx := log.Testing(t)
+	value := &sample{
+		Signed:   3,
+		Unsigned: 7,
+		Double:   2.5,
+		Time:     90 * time.Second,
+		Proto:    &duration.Duration{Seconds: 5, Nanos: 250000000},
+	}
+	klass := reflect.TypeOf(value)
+	for _, test := range []struct {
+		query    string
+		expected bool
+	}{
+		{"Signed < 4", true},
+		{"Signed < 3", false},
+		{"Signed <= 3", true},
+		{"Signed <= 2", false},
+		{"Signed > 2", true},
+		{"Signed > 3", false},
+		{"Signed >= 3", true},
+		{"Signed >= 4", false},
+		// Mixed signed and unsigned operands.
+		{"Signed < 0x4", true},
+		{"Signed >= 0x4", false},
+		{"Unsigned > 6", true},
+		{"Unsigned <= 7", true},
+		{"Unsigned < 7", false},
+		{"Unsigned > Signed", true},
+		// Float fields compared with integer and float literals.
+		{"Double > 2", true},
+		{"Double < 2.6", true},
+		{"Double >= 2.5", true},
+		{"Double <= 2.4", false},
+		// time.Duration fields compared with duration literals.
+		{"Time > 1m", true},
+		{"Time >= 1m30s", true},
+		{"Time < 90s", false},
+		{"Time <= 1m30s", true},
+		{"Time < 2m", true},
+		// Protobuf durations compared with duration literals.
+		{"Proto > 5s", true},
+		{"Proto < 5250ms", false},
+		{"Proto <= 5250ms", true},
+		{"Proto >= 5300ms", false},
+		{"Time > Proto", true},
+	} {
+		ctx := log.V{"query": test.query}.Bind(ctx)
+		q, err := script.Parse(ctx, test.query)
+		if !assert.For(ctx, "Parse").ThatError(err).Succeeded() {
+			continue
+		}
+		pred, err := eval.Compile(ctx, q.Query(), klass)
+		if assert.For(ctx, "Compile").ThatError(err).Succeeded() {
+			assert.For(ctx, "result").That(pred(ctx, value)).Equals(test.expected)
+		}
+	}
+
+	for _, query := range []string{"Signed <", "Time >= ", "(Double <=)"} {
+		ctx := log.V{"query": query}.Bind(ctx)
+		_, err := script.Parse(ctx, query)
+		if assert.For(ctx, "Parse").ThatError(err).Failed() {
+			assert.For(ctx, "message").ThatString(err.Error()).Contains("Expected value to compare with")
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		query    string
+		expected []string
+	}{
+		{`Name =~ "[a-c]"`, []string{"a", "b", "c"}},
+		{`Name =~ "^(b|d)$"`, []string{"b", "d"}},
+		{`Name =~ "B|D"`, []string{}},
+		{`Name =~ "(?i)B|D"`, []string{"b", "d"}},
+		{`Name ?= "(?i)E"`, []string{"e"}},
+		{`Ok && Name =~ "[a-c]"`, []string{"a", "c"}},
+	} {
+		ctx := log.V{"query": test.query}.Bind(ctx)
+		q, err := script.Parse(ctx, test.query)
+		if !assert.For(ctx, "Parse").ThatError(err).Succeeded() {
+			continue
+		}
+		names, err := selectNames(ctx, q.Query())
+		if assert.For(ctx, "Select").ThatError(err).Succeeded() {
+			assert.For(ctx, "names").That(names).DeepEquals(test.expected)
+		}
+	}
+
+	// An invalid pattern is a parse error reported at the start of the pattern.
+	_, err := script.Parse(ctx, `Ok && Name =~  "a(b"`)
+	if assert.For(ctx, "invalid").ThatError(err).Failed() {
+		assert.For(ctx, "message").ThatString(err.Error()).Contains(`query:1:16:`)
+		assert.For(ctx, "message").ThatString(err.Error()).Contains(`Invalid regular expression`)
+	}
+}
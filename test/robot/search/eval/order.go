@@ -0,0 +1,223 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/google/gapid/core/event"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/test/robot/search"
+)
+
+// key is a compiled order key.
+// get returns the value of the key for an entry, as a value of type t, which
+// is one of int64, uint64, float64, string or bool, and compare orders two such
+// values.
+type key struct {
+	get        eval
+	t          reflect.Type
+	compare    func(x, y interface{}) int
+	descending bool
+}
+
+// keys is the compiled order of a query, most significant key first.
+type keys []key
+
+// values returns the values of all the keys for an entry.
+func (k keys) values(ctx context.Context, value interface{}) []interface{} {
+	res := make([]interface{}, len(k))
+	for i, key := range k {
+		res[i] = key.get(ctx, value)
+	}
+	return res
+}
+
+// compare returns a negative number if x sorts before y, a positive number if
+// x sorts after y, and 0 if they are equivalent.
+func (k keys) compare(x, y []interface{}) int {
+	for i, key := range k {
+		c := key.compare(x[i], y[i])
+		if key.descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// entry is a value along with the values of its order keys.
+type entry struct {
+	value interface{}
+	keys  []interface{}
+}
+
+// Select returns a producer of the values from src that match the query, in
+// the order, up to the limit and from the page requested by the query.
+// If the query is ordered, src is drained on the first call to the returned
+// producer, so that it happens under the same lock as the feed of the results.
+func Select(ctx context.Context, query *search.Query, klass reflect.Type, src event.Producer) (event.Producer, error) {
+	pred, err := Compile(ctx, query, klass)
+	if err != nil {
+		return nil, err
+	}
+	order, err := compileOrder(ctx, query.Order, klass)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(ctx, query.PageToken, order)
+	if err != nil {
+		return nil, err
+	}
+	if query.Limit > 0 && cursor.returned >= int(query.Limit) {
+		// The previous pages hold all the entries allowed by the limit.
+		return func(context.Context) interface{} { return nil }, nil
+	}
+	limit := pageLimit(query, cursor.returned)
+	if len(order) == 0 {
+		// Without an order all the entries are equivalent, so the cursor is
+		// just the number of matching entries to skip.
+		skip, count := cursor.skip, 0
+		return func(ctx context.Context) interface{} {
+			if limit > 0 && count >= limit {
+				return nil
+			}
+			for {
+				value := src(ctx)
+				if value == nil || pred(ctx, value) {
+					if value != nil && skip > 0 {
+						skip--
+						continue
+					}
+					count++
+					return value
+				}
+			}
+		}, nil
+	}
+	var entries []entry
+	loaded := false
+	return func(ctx context.Context) interface{} {
+		if !loaded {
+			loaded = true
+			for value := src(ctx); value != nil; value = src(ctx) {
+				if pred(ctx, value) {
+					entries = append(entries, entry{value, order.values(ctx, value)})
+				}
+			}
+			sort.SliceStable(entries, func(i, j int) bool {
+				return order.compare(entries[i].keys, entries[j].keys) < 0
+			})
+			entries = cursor.after(order, entries)
+			if limit > 0 && len(entries) > limit {
+				entries = entries[:limit]
+			}
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		value := entries[0].value
+		entries = entries[1:]
+		return value
+	}, nil
+}
+
+// pageLimit returns the maximum number of entries to return for the page of
+// the query that follows the returned entries of the previous pages, or 0 if
+// there is no maximum. The limit of the query applies to all the pages
+// together, and must not have been reached.
+func pageLimit(query *search.Query, returned int) int {
+	limit, size := int(query.Limit), int(query.PageSize)
+	if limit == 0 {
+		return size
+	}
+	if left := limit - returned; size == 0 || left < size {
+		return left
+	}
+	return size
+}
+
+func compileOrder(ctx context.Context, order []*search.OrderBy, t reflect.Type) (keys, error) {
+	res := make(keys, len(order))
+	for i, o := range order {
+		key, err := compileKey(ctx, o.Value, t)
+		if err != nil {
+			return nil, err
+		}
+		key.descending = o.Descending
+		res[i] = key
+	}
+	return res, nil
+}
+
+func compileKey(ctx context.Context, expr *search.Expression, t reflect.Type) (key, error) {
+	if expr == nil {
+		return key{}, log.Err(ctx, nil, "Missing order expression")
+	}
+	e, et, err := compileNumeric(ctx, expr, t)
+	if err != nil {
+		return key{}, err
+	}
+	switch {
+	case et == signedType:
+		return key{get: e, t: signedType, compare: func(x, y interface{}) int {
+			a, b := x.(int64), y.(int64)
+			return sign(a < b, a > b)
+		}}, nil
+	case et == unsignedType:
+		return key{get: e, t: unsignedType, compare: func(x, y interface{}) int {
+			a, b := x.(uint64), y.(uint64)
+			return sign(a < b, a > b)
+		}}, nil
+	case et == doubleType:
+		return key{get: e, t: doubleType, compare: func(x, y interface{}) int {
+			a, b := x.(float64), y.(float64)
+			return sign(a < b, a > b)
+		}}, nil
+	case et.Kind() == reflect.String:
+		get := func(ctx context.Context, value interface{}) interface{} {
+			return reflect.ValueOf(e(ctx, value)).String()
+		}
+		return key{get: get, t: stringType, compare: func(x, y interface{}) int {
+			a, b := x.(string), y.(string)
+			return sign(a < b, a > b)
+		}}, nil
+	case et.Kind() == reflect.Bool:
+		get := func(ctx context.Context, value interface{}) interface{} {
+			return reflect.ValueOf(e(ctx, value)).Bool()
+		}
+		return key{get: get, t: boolType, compare: func(x, y interface{}) int {
+			a, b := x.(bool), y.(bool)
+			return sign(!a && b, a && !b)
+		}}, nil
+	default:
+		return key{}, log.Errf(ctx, nil, "Cannot order by values of type %v", et)
+	}
+}
+
+func sign(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/test/robot/search"
+)
+
+// cursor is a decoded page token.
+type cursor struct {
+	keys     []interface{}
+	skip     int
+	returned int
+}
+
+func decodeCursor(ctx context.Context, token string, order keys) (cursor, error) {
+	if token == "" {
+		return cursor{}, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor{}, log.Err(ctx, err, "Invalid page token")
+	}
+	c := &search.Cursor{}
+	if err := proto.Unmarshal(data, c); err != nil {
+		return cursor{}, log.Err(ctx, err, "Invalid page token")
+	}
+	if len(c.Keys) != len(order) {
+		return cursor{}, log.Errf(ctx, nil, "Page token has %v keys, query is ordered by %v", len(c.Keys), len(order))
+	}
+	res := cursor{keys: make([]interface{}, len(order)), skip: int(c.Skip), returned: int(c.Returned)}
+	for i, k := range c.Keys {
+		v := fromLiteral(k)
+		if reflect.TypeOf(v) != order[i].t {
+			return cursor{}, log.Errf(ctx, nil, "Page token key %v does not match the query order (%v)", k, order[i].t)
+		}
+		res.keys[i] = v
+	}
+	return res, nil
+}
+
+// after returns the sorted entries that follow the cursor.
+func (c cursor) after(order keys, entries []entry) []entry {
+	if c.keys == nil {
+		return entries
+	}
+	i := sort.Search(len(entries), func(i int) bool {
+		return order.compare(entries[i].keys, c.keys) >= 0
+	})
+	for skip := c.skip; skip > 0 && i < len(entries) && order.compare(entries[i].keys, c.keys) == 0; skip-- {
+		i++
+	}
+	return entries[i:]
+}
+
+// NextPageToken returns the token of the page of results that follows page.
+// page must be a slice holding the results of query, in the order they were
+// returned, and klass is the type of the values that were searched.
+// If query is not paged, or page is the last page, the empty string is
+// returned. A page is the last if it is not full, or if it reaches the limit
+// of the query.
+// The token marks the position of the last entry of page in the order of the
+// query, so that entries added before it do not change the following pages.
+func NextPageToken(ctx context.Context, query *search.Query, klass reflect.Type, page interface{}) (string, error) {
+	if query.PageSize == 0 {
+		return "", nil
+	}
+	order, err := compileOrder(ctx, query.Order, klass)
+	if err != nil {
+		return "", err
+	}
+	prev, err := decodeCursor(ctx, query.PageToken, order)
+	if err != nil {
+		return "", err
+	}
+	results := reflect.ValueOf(page)
+	count := results.Len()
+	returned := prev.returned + count
+	if count == 0 || count < pageLimit(query, prev.returned) || (query.Limit > 0 && returned >= int(query.Limit)) {
+		return "", nil
+	}
+	last := order.values(ctx, results.Index(count-1).Interface())
+	skip := 0
+	for i := count - 1; i >= 0; i-- {
+		if order.compare(order.values(ctx, results.Index(i).Interface()), last) != 0 {
+			break
+		}
+		skip++
+	}
+	if skip == count && query.PageToken != "" && order.compare(prev.keys, last) == 0 {
+		// The whole page had the same keys as the end of the previous one.
+		skip += prev.skip
+	}
+	c := &search.Cursor{Skip: uint32(skip), Returned: uint32(returned)}
+	for _, v := range last {
+		c.Keys = append(c.Keys, toLiteral(v))
+	}
+	data, err := proto.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func toLiteral(v interface{}) *search.Expression {
+	switch v := v.(type) {
+	case int64:
+		return &search.Expression{Is: &search.Expression_Signed{Signed: v}}
+	case uint64:
+		return &search.Expression{Is: &search.Expression_Unsigned{Unsigned: v}}
+	case float64:
+		return &search.Expression{Is: &search.Expression_Double{Double: v}}
+	case string:
+		return &search.Expression{Is: &search.Expression_String_{String_: v}}
+	default:
+		return &search.Expression{Is: &search.Expression_Boolean{Boolean: v.(bool)}}
+	}
+}
+
+func fromLiteral(e *search.Expression) interface{} {
+	switch e := e.GetIs().(type) {
+	case *search.Expression_Signed:
+		return e.Signed
+	case *search.Expression_Unsigned:
+		return e.Unsigned
+	case *search.Expression_Double:
+		return e.Double
+	case *search.Expression_String_:
+		return e.String_
+	case *search.Expression_Boolean:
+		return e.Boolean
+	default:
+		return nil
+	}
+}
@@ -14,13 +14,31 @@
 
 package query
 
-import "github.com/google/gapid/test/robot/search"
+import (
+	"time"
+
+	"github.com/google/gapid/test/robot/search"
+)
 
 // Builder is the type used to allow fluent construction of search queries.
 type Builder struct {
-	e *search.Expression
+	e         *search.Expression
+	order     []*search.OrderBy
+	limit     uint32
+	pageToken string
+	pageSize  uint32
 }
 
+// Direction is the direction in which the results of a query are sorted.
+type Direction bool
+
+const (
+	// Ascending sorts the results from smallest to largest.
+	Ascending = Direction(false)
+	// Descending sorts the results from largest to smallest.
+	Descending = Direction(true)
+)
+
 // Expression creates a builder from a search expression.
 func Expression(e *search.Expression) Builder {
 	return Builder{e: e}
@@ -39,7 +57,45 @@ func (b Builder) Expression() *search.Expression {
 
 // Query returns the content of the builder as a completed search query.
 func (b Builder) Query() *search.Query {
-	return &search.Query{Expression: b.Expression()}
+	return &search.Query{
+		Expression: b.Expression(),
+		Order:      b.order,
+		Limit:      b.limit,
+		PageToken:  b.pageToken,
+		PageSize:   b.pageSize,
+	}
+}
+
+// OrderBy returns a copy of the builder whose query results are sorted by
+// field in the given direction.
+// Each call adds a key that is less significant than the ones before it.
+// Ordering applies to the query as a whole, so it is not carried into
+// expressions built from b.
+func (b Builder) OrderBy(field Builder, dir Direction) Builder {
+	order := append([]*search.OrderBy{}, b.order...)
+	b.order = append(order, &search.OrderBy{
+		Value:      field.Expression(),
+		Descending: bool(dir),
+	})
+	return b
+}
+
+// Limit returns a copy of the builder whose query returns at most n results.
+// A limit of 0 means the results are not limited.
+// Like OrderBy, the limit is not carried into expressions built from b.
+func (b Builder) Limit(n uint32) Builder {
+	b.limit = n
+	return b
+}
+
+// Page returns a copy of the builder whose query returns the page of at most
+// size results that starts at token.
+// token is the empty string for the first page, and the next page token of the
+// previous page otherwise. A size of 0 means the results are not paged.
+// Like OrderBy, the page is not carried into expressions built from b.
+func (b Builder) Page(token string, size uint32) Builder {
+	b.pageToken, b.pageSize = token, size
+	return b
 }
 
 // Bool builds a boolean literal search expression.
@@ -67,6 +123,12 @@ func Double(value float64) Builder {
 	return Expression(exprDouble(value))
 }
 
+// Duration builds a duration literal search expression.
+// Durations are represented as a signed count of nanoseconds.
+func Duration(value time.Duration) Builder {
+	return Expression(exprSigned(int64(value)))
+}
+
 // Name builds a root name lookup search expression.
 func Name(name string) Builder {
 	return Expression(exprName(name))
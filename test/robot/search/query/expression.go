@@ -14,7 +14,11 @@
 
 package query
 
-import "github.com/google/gapid/test/robot/search"
+import (
+	"time"
+
+	"github.com/google/gapid/test/robot/search"
+)
 
 func exprBool(value bool) *search.Expression {
 	return &search.Expression{Is: &search.Expression_Boolean{
@@ -80,8 +84,8 @@ func exprEqual(lhs, rhs *search.Expression) *search.Expression {
 }
 
 func exprLess(lhs, rhs *search.Expression) *search.Expression {
-	return &search.Expression{Is: &search.Expression_GreaterOrEqual{
-		GreaterOrEqual: &search.Binary{
+	return &search.Expression{Is: &search.Expression_Greater{
+		Greater: &search.Binary{
 			Lhs: rhs,
 			Rhs: lhs,
 		},
@@ -89,8 +93,8 @@ func exprLess(lhs, rhs *search.Expression) *search.Expression {
 }
 
 func exprLessOrEqual(lhs, rhs *search.Expression) *search.Expression {
-	return &search.Expression{Is: &search.Expression_Greater{
-		Greater: &search.Binary{
+	return &search.Expression{Is: &search.Expression_GreaterOrEqual{
+		GreaterOrEqual: &search.Binary{
 			Lhs: rhs,
 			Rhs: lhs,
 		},
@@ -160,6 +164,8 @@ func exprValue(value interface{}) *search.Expression {
 		return exprUnsigned((uint64)(v))
 	case uint64:
 		return exprUnsigned(v)
+	case time.Duration:
+		return exprSigned(int64(v))
 	case float32:
 		return exprDouble((float64)(v))
 	case float64:
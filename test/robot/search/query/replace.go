@@ -20,8 +20,18 @@ import (
 )
 
 // Replace substitues expr for match in the expression tree.
+// The order, limit and page of b are kept, and match is also replaced in the
+// order keys.
 func (b Builder) Replace(match Builder, expr Builder) Builder {
-	return Expression(replace(b.Expression(), match.Expression(), expr.Expression()))
+	r := Expression(replace(b.Expression(), match.Expression(), expr.Expression()))
+	for _, o := range b.order {
+		r.order = append(r.order, &search.OrderBy{
+			Value:      replace(o.Value, match.Expression(), expr.Expression()),
+			Descending: o.Descending,
+		})
+	}
+	r.limit, r.pageToken, r.pageSize = b.limit, b.pageToken, b.pageSize
+	return r
 }
 
 // Set is a small helper on top of Replace for the common case of identifier substitution.
@@ -61,7 +61,10 @@ func (e *entityIndex) Lookup(ctx context.Context, id string) (*stash.Entity, err
 
 func (e *entityIndex) Search(ctx context.Context, query *search.Query, handler stash.EntityHandler) error {
 	filter := eval.Filter(ctx, query, entityClass, event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, e.entities)
+	initial, err := eval.Select(ctx, query, entityClass, event.AsProducer(ctx, e.entities))
+	if err != nil {
+		return err
+	}
 	if query.Monitor {
 		return event.Monitor(ctx, &e.mu, e.onAdd.Listen, initial, filter)
 	}
@@ -83,7 +83,10 @@ func (s *local) apply(ctx context.Context, subject *Subject) error {
 // It searches the set of persisted subjects, and supports monitoring of subjects as they arrive.
 func (s *local) Search(ctx context.Context, query *search.Query, handler Handler) error {
 	filter := eval.Filter(ctx, query, reflect.TypeOf(&Subject{}), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, s.subjects)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(&Subject{}), event.AsProducer(ctx, s.subjects))
+	if err != nil {
+		return err
+	}
 	if query.Monitor {
 		return event.Monitor(ctx, &s.mu, s.onChange.Listen, initial, filter)
 	}
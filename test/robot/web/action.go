@@ -16,7 +16,6 @@ package web
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 
 	"github.com/google/gapid/test/robot/replay"
@@ -37,7 +36,7 @@ func (s *Server) handleReplays(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		json.NewEncoder(w).Encode(result)
+		writeResults(w, r, query, result)
 	}
 }
 
@@ -54,7 +53,7 @@ func (s *Server) handleTraces(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		json.NewEncoder(w).Encode(result)
+		writeResults(w, r, query, result)
 	}
 }
 
@@ -71,6 +70,6 @@ func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		json.NewEncoder(w).Encode(result)
+		writeResults(w, r, query, result)
 	}
 }
@@ -37,7 +37,7 @@ func (s *Server) handleArtifacts(w http.ResponseWriter, r *http.Request) {
 			writeError(w, 500, err)
 			return
 		}
-		json.NewEncoder(w).Encode(result)
+		writeResults(w, r, query, result)
 	}
 }
 
@@ -53,7 +53,7 @@ func (s *Server) handlePackages(w http.ResponseWriter, r *http.Request) {
 			writeError(w, 500, err)
 			return
 		}
-		json.NewEncoder(w).Encode(result)
+		writeResults(w, r, query, result)
 	}
 }
 
@@ -101,6 +101,6 @@ func (s *Server) handleTracks(w http.ResponseWriter, r *http.Request) {
 			writeError(w, 500, err)
 			return
 		}
-		json.NewEncoder(w).Encode(result)
+		writeResults(w, r, query, result)
 	}
 }
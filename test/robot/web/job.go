@@ -16,7 +16,6 @@ package web
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 
 	"github.com/google/gapid/test/robot/job"
@@ -34,7 +33,7 @@ func (s *Server) handleDevices(w http.ResponseWriter, r *http.Request) {
 			writeError(w, 500, err)
 			return
 		}
-		json.NewEncoder(w).Encode(result)
+		writeResults(w, r, query, result)
 	}
 }
 
@@ -50,6 +49,6 @@ func (s *Server) handleWorkers(w http.ResponseWriter, r *http.Request) {
 			writeError(w, 500, err)
 			return
 		}
-		json.NewEncoder(w).Encode(result)
+		writeResults(w, r, query, result)
 	}
 }
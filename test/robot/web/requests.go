@@ -15,21 +15,51 @@
 package web
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
 
 	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
 	"github.com/google/gapid/test/robot/search/script"
 )
 
+// nextPageTokenHeader is the response header that holds the token of the next
+// page of a paged query.
+const nextPageTokenHeader = "Next-Page-Token"
+
 func query(w http.ResponseWriter, r *http.Request) (*search.Query, error) {
 	builder, err := script.Parse(r.Context(), r.FormValue("q"))
 	if err != nil {
 		return nil, writeError(w, 400, err)
 	}
+	if sizeStr := r.FormValue("page_size"); sizeStr != "" {
+		size, err := strconv.ParseUint(sizeStr, 10, 32)
+		if err != nil {
+			return nil, writeError(w, 400, err)
+		}
+		builder = builder.Page(r.FormValue("page_token"), uint32(size))
+	}
 	return builder.Query(), nil
 }
 
+// writeResults writes the slice of results of the query as JSON.
+// If there is another page of results, its token is set in the
+// Next-Page-Token header.
+func writeResults(w http.ResponseWriter, r *http.Request, query *search.Query, result interface{}) {
+	token, err := eval.NextPageToken(r.Context(), query, reflect.TypeOf(result).Elem(), result)
+	if err != nil {
+		writeError(w, 500, err)
+		return
+	}
+	if token != "" {
+		w.Header().Set(nextPageTokenHeader, token)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
 func writeError(w http.ResponseWriter, code int, err error) error {
 	w.WriteHeader(code)
 	fmt.Fprintf(w, "Error processing request: %v", err)
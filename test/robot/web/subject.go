@@ -16,7 +16,6 @@ package web
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 
 	"github.com/google/gapid/test/robot/subject"
@@ -34,6 +33,6 @@ func (s *Server) handleSubjects(w http.ResponseWriter, r *http.Request) {
 			writeError(w, 500, err)
 			return
 		}
-		json.NewEncoder(w).Encode(result)
+		writeResults(w, r, query, result)
 	}
 }